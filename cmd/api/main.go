@@ -98,7 +98,13 @@ func main() {
 	// Start background workers
 	f.DeletionWorker.StartDeletionWorker()
 	f.TokenCleanupWorker.Start()
+	f.ImportWorker.Start()
+	f.EnrichmentWorker.Start()
+	f.AuthorNameBackfillWorker.Start()
 	defer f.TokenCleanupWorker.Stop()
+	defer f.ImportWorker.Stop()
+	defer f.EnrichmentWorker.Stop()
+	defer f.AuthorNameBackfillWorker.Stop()
 	defer f.DeletionWorker.StopDeletionWorker()
 	defer f.CacheWorker.Shutdown()
 
@@ -144,12 +150,6 @@ func initializeEnvironment() error {
 	logger.Init()
 	jwt.InitLogger(logger.Log)
 
-	// Check if upload directory is set
-	if uploadDir := os.Getenv("UPLOAD_DIR"); uploadDir == "" {
-		logger.Log.Error("Upload dir is not set")
-		return fmt.Errorf("upload directory not set")
-	}
-
 	return nil
 }
 
@@ -267,6 +267,15 @@ func gracefulShutdown(ctx context.Context, srv *http.Server, f *factory.Factory,
 	// Stop account deletion worker
 	f.DeletionWorker.StopDeletionWorker()
 
+	// Stop import worker, in-flight jobs are marked as interrupted
+	f.ImportWorker.Stop()
+
+	// Stop metadata enrichment worker, an in-flight scan is marked as interrupted
+	f.EnrichmentWorker.Stop()
+
+	// Stop author name backfill worker, an in-flight backfill is marked as interrupted
+	f.AuthorNameBackfillWorker.Stop()
+
 	// Shutdown cache cleanup worker
 	f.CacheWorker.Shutdown()
 
@@ -278,6 +287,13 @@ func gracefulShutdown(ctx context.Context, srv *http.Server, f *factory.Factory,
 		log.Error("Error cleaning prepared statements", "error", err)
 	}
 
+	// Close the summary provider's client
+	if f.SummaryProvider != nil {
+		if err := f.SummaryProvider.Close(); err != nil {
+			log.Error("Error closing summary provider", "error", err)
+		}
+	}
+
 	// Shut down Redis
 	if defaultRedisClient != nil {
 		if err := defaultRedisClient.Close(); err != nil {
@@ -92,10 +92,31 @@ func (app *application) routes(
 			r.Get("/books/genres", bookHandlers.HandleGetBooksByGenres)
 			r.Get("/books/homepage", bookHandlers.HandleGetHomepageData)
 			r.Get("/books/tags", bookHandlers.HandleGetBooksByTags)
+			r.With(middleware.RequestValidation(baseValidator, middleware.ValidationConfig{
+				Domain: core.BookDomainType,
+				Timeout: 30 * time.Second,
+				QueryRules: validator.LibrarySearchQueryRules(),
+			})).Get("/books/search", searchHandlers.HandleSearchUserBooks)
 
 			// Apply intensive rate limiting on uploads + exports
 			r.With(middleware.IntensiveRateLimiter).Post("/upload", bookHandlers.UploadCSV)
 			r.With(middleware.IntensiveRateLimiter).Get("/export", bookHandlers.HandleExportUserBooks)
+			r.With(middleware.IntensiveRateLimiter).Get("/backup", bookHandlers.HandleBackupUserBooks)
+			r.With(middleware.IntensiveRateLimiter).Post("/restore", bookHandlers.HandleRestoreUserBooks)
+
+			// Import job progress + cancellation
+			r.Get("/imports/{jobID}", bookHandlers.HandleGetImportJob)
+			r.Post("/imports/{jobID}/cancel", bookHandlers.HandleCancelImportJob)
+
+			// Metadata enrichment scans + proposals for incomplete books
+			r.With(middleware.IntensiveRateLimiter).Post("/enrichment/scan", bookHandlers.HandleStartEnrichmentScan)
+			r.Get("/enrichment/scan", bookHandlers.HandleGetEnrichmentScan)
+			r.Get("/enrichment/proposals", bookHandlers.HandleGetEnrichmentProposals)
+			r.Post("/enrichment/proposals/{proposalID}/accept", bookHandlers.HandleAcceptEnrichmentProposal)
+			r.Post("/enrichment/proposals/{proposalID}/reject", bookHandlers.HandleRejectEnrichmentProposal)
+
+			// Today's AI usage against the daily quota
+			r.Get("/ai-usage", bookHandlers.HandleGetAIUsage)
 		})
 
 		r.Route("/api/v1/books", func(r chi.Router) {
@@ -109,17 +130,46 @@ func (app *application) routes(
 			r.With(middleware.StandardRateLimiter).Get("/by-id/{bookID}", bookHandlers.HandleGetBookByID)
 
 			// More restrictive rate limiting for search
-			r.With(middleware.IntensiveRateLimiter).Get("/search", searchHandlers.HandleSearchBooks)
+			r.With(
+				middleware.IntensiveRateLimiter,
+				middleware.RequestValidation(baseValidator, middleware.ValidationConfig{
+					Domain: core.BookDomainType,
+					Timeout: 30 * time.Second,
+					QueryRules: validator.BookSearchQueryRules(),
+				}),
+			).Get("/search", searchHandlers.HandleSearchBooks)
 
 			// Standard rate limiting for summary + bookID
-			r.With(middleware.StandardRateLimiter).Get("/summary", bookHandlers.HandleGetGeminiBookSummary)
+			r.With(middleware.StandardRateLimiter).Get("/{bookID}/summary", bookHandlers.HandleGetBookSummary)
+			r.With(middleware.StandardRateLimiter).Get("/{bookID}/summary/stream", bookHandlers.HandleStreamBookSummary)
+			r.With(middleware.StandardRateLimiter).Put("/{bookID}/summary", bookHandlers.HandleUpdateBookSummary)
+			r.With(middleware.StandardRateLimiter).Post("/{bookID}/summary/accept", bookHandlers.HandleAcceptBookSummary)
 			r.With(middleware.StandardRateLimiter).Get("/by-title", bookHandlers.HandleGetBookIDByTitle)
 
+			r.With(middleware.StandardRateLimiter).Get("/{bookID}/export", bookHandlers.HandleExportBook)
 			r.With(middleware.StandardRateLimiter).Put("/{bookID}", bookHandlers.HandleUpdateBook)
 			r.With(middleware.StandardRateLimiter).Post("/add", bookHandlers.HandleInsertBook)
+			r.With(middleware.IntensiveRateLimiter).Post("/add-by-isbn", bookHandlers.HandleAddBookByISBN)
 			r.With(middleware.StandardRateLimiter).Delete("/{bookID}", bookHandlers.HandleDeleteBook)
 		})
 
+		r.Route("/api/v1/admin", func(r chi.Router) {
+			r.Use(middleware.VerifyJWT)
+			r.Use(middleware.RequireAdmin)
+			r.Use(middleware.StandardRateLimiter)
+
+			// Author name normalization rules
+			r.Get("/author-rules", bookHandlers.HandleListAuthorNameRules)
+			r.Post("/author-rules", bookHandlers.HandleCreateAuthorNameRule)
+			r.Delete("/author-rules/{ruleID}", bookHandlers.HandleDeleteAuthorNameRule)
+			r.With(middleware.IntensiveRateLimiter).Post("/author-rules/backfill", bookHandlers.HandleBackfillAuthorNames)
+			r.Get("/author-rules/backfill/{jobID}", bookHandlers.HandleGetAuthorNameBackfill)
+
+			// Duplicate author detection + merging
+			r.With(middleware.IntensiveRateLimiter).Get("/authors/duplicates", bookHandlers.HandleGetDuplicateAuthors)
+			r.Post("/authors/merge", bookHandlers.HandleMergeAuthors)
+		})
+
 		r.Route("/api/v1/pages", func(r chi.Router) {
 			r.Use(middleware.VerifyJWT)
 			r.Use(middleware.RequestValidation(baseValidator, middleware.ValidationConfig{
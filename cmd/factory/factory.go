@@ -40,6 +40,10 @@ type Factory struct {
     DeletionWorker        *workers.DeletionWorker
     CacheWorker           *workers.CacheWorker
     TokenCleanupWorker    *workers.TokenCleanupWorker
+    ImportWorker          *workers.ImportWorker
+    EnrichmentWorker      *workers.EnrichmentWorker
+    AuthorNameBackfillWorker *workers.AuthorNameBackfillWorker
+    SummaryProvider       bookservices.SummaryProvider
     CacheManager          *cache.CacheManager
     LibraryHandler        *library.LibraryHandler
     BaseValidator         *validator.BaseValidator
@@ -75,12 +79,24 @@ func NewFactory(
     )
 
     // Initialize book-related repositories
-    authorRepo, err := repository.NewAuthorRepository(db, log)
+    authorNameRules, err := repository.NewAuthorNameRuleRepository(db, log)
+    if err != nil {
+        log.Error("Error initializing author name rule repository", "error", err)
+        return nil, err
+    }
+
+    authorRepo, err := repository.NewAuthorRepository(db, log, authorNameRules)
     if err != nil {
         log.Error("Error initializing author repository", "error", err)
         return nil, err
     }
 
+    backfillJobRepo, err := repository.NewAuthorNameBackfillJobRepository(db, log)
+    if err != nil {
+        log.Error("Error initializing author name backfill job repository", "error", err)
+        return nil, err
+    }
+
     bookCache, err := repository.NewBookCache(ctx, db, log)
     if err != nil {
         log.Error("Error initializing book cache", "error", err)
@@ -123,6 +139,30 @@ func NewFactory(
         return nil, err
     }
 
+    importJobRepo, err := repository.NewImportJobRepository(db, log)
+    if err != nil {
+        log.Error("Error initializing import job repository", "error", err)
+        return nil, err
+    }
+
+    enrichmentRepo, err := repository.NewEnrichmentRepository(db, log)
+    if err != nil {
+        log.Error("Error initializing enrichment repository", "error", err)
+        return nil, err
+    }
+
+    bookSummaryRepo, err := repository.NewBookSummaryRepository(db, log)
+    if err != nil {
+        log.Error("Error initializing book summary repository", "error", err)
+        return nil, err
+    }
+
+    aiUsageRepo, err := repository.NewAIUsageRepository(db, log)
+    if err != nil {
+        log.Error("Error initializing AI usage repository", "error", err)
+        return nil, err
+    }
+
     // Initialize cache invalidation components
     bookCacheInvalidator := bookcache.NewBookCacheInvalidator(
         bookCache,
@@ -272,6 +312,29 @@ func NewFactory(
         return nil, err
     }
 
+    importService, err := bookservices.NewImportService(
+        log,
+        bookService,
+        bookCache,
+        transactionManager,
+    )
+    if err != nil {
+        log.Error("Error initializing import service", "error", err)
+        return nil, err
+    }
+
+    backupService, err := bookservices.NewBackupService(
+        log,
+        bookRepo,
+        bookDeleter,
+        bookService,
+        transactionManager,
+    )
+    if err != nil {
+        log.Error("Error initializing backup service", "error", err)
+        return nil, err
+    }
+
     bookCacheService := bookservices.NewBookCacheService(
         redisClient,
         log.With("service", "book_cache"),
@@ -286,11 +349,108 @@ func NewFactory(
         oauthService.GetConfig(),
     )
 
+    metadataProviders, err := bookservices.NewMetadataProviders(
+        log,
+        config.AppConfig.MetadataProviders,
+        config.AppConfig.MetadataProviderTimeout,
+        config.AppConfig.GoogleBooksAPIKey,
+    )
+    if err != nil {
+        log.Error("Error initializing metadata providers", "error", err)
+        return nil, err
+    }
+
+    metadataProviderChain, err := bookservices.NewMetadataProviderChain(log, metadataProviders...)
+    if err != nil {
+        log.Error("Error initializing metadata provider chain", "error", err)
+        return nil, err
+    }
+
+    metadataProvider, err := bookservices.NewCachedMetadataProvider(
+        log.With("service", "metadata_cache"),
+        metadataProviderChain,
+        bookCacheService,
+        redisClient.GetConfig().CacheConfig.MetadataSearch,
+    )
+    if err != nil {
+        log.Error("Error initializing metadata search cache", "error", err)
+        return nil, err
+    }
+
+    authorDedupeService, err := bookservices.NewAuthorDedupeService(
+        log.With("service", "author_dedupe"),
+        authorRepo,
+        transactionManager,
+    )
+    if err != nil {
+        log.Error("Error initializing author dedupe service", "error", err)
+        return nil, err
+    }
+
+    // Summaries are optional, the rest of the app runs without an LLM configured
+    summaryProvider, err := bookservices.NewSummaryProvider(
+        context.Background(),
+        log.With("service", "summary"),
+        bookservices.SummaryProviderConfig{
+            Provider:    config.AppConfig.SummaryProvider,
+            Model:       config.AppConfig.SummaryModel,
+            Temperature: config.AppConfig.SummaryTemperature,
+            Timeout:     config.AppConfig.SummaryTimeout,
+            BaseURL:     config.AppConfig.SummaryBaseURL,
+            APIKey:      config.AppConfig.SummaryAPIKey,
+        },
+    )
+    if err != nil {
+        log.Warn("Book summaries disabled", "provider", config.AppConfig.SummaryProvider, "error", err)
+    }
+
+    aiQuotaService, err := bookservices.NewAIQuotaService(
+        log.With("service", "ai_quota"),
+        aiUsageRepo,
+        bookservices.AIQuotaLimits{
+            DailyTokens:   config.AppConfig.AIDailyTokenLimit,
+            DailyRequests: config.AppConfig.AIDailyRequestLimit,
+        },
+    )
+    if err != nil {
+        log.Error("Error initializing AI quota service", "error", err)
+        return nil, err
+    }
+
+    // Without a provider saved summaries can still be read, edited and accepted
+    summaryService, err := bookservices.NewSummaryService(
+        log.With("service", "summary"),
+        bookRepo,
+        bookSummaryRepo,
+        bookUpdaterService,
+        aiQuotaService,
+        summaryProvider,
+    )
+    if err != nil {
+        log.Error("Error initializing summary service", "error", err)
+        return nil, err
+    }
+
+    enrichmentService, err := bookservices.NewEnrichmentService(
+        log.With("service", "enrichment"),
+        enrichmentRepo,
+        bookRepo,
+        bookUpdaterService,
+        metadataProvider,
+    )
+    if err != nil {
+        log.Error("Error initializing enrichment service", "error", err)
+        return nil, err
+    }
+
     bookHandlers, err := handlers.NewBookHandlers(
         db,
         log,
         bookModels,
         authorRepo,
+        authorNameRules,
+        backfillJobRepo,
+        authorDedupeService,
         bookRepo,
         formatRepo,
         genreRepo,
@@ -302,6 +462,14 @@ func NewFactory(
         bookService,
         bookCacheService,
         exportService,
+        importService,
+        importJobRepo,
+        backupService,
+        metadataProvider,
+        enrichmentRepo,
+        enrichmentService,
+        summaryService,
+        aiQuotaService,
         redisClient,
         cacheManager,
         cacheWorker,
@@ -315,6 +483,8 @@ func NewFactory(
         bookRepo,
         bookCache,
         authHandlers,
+        metadataProvider,
+        authorNameRules,
     )
     if err != nil {
         return nil, err
@@ -365,6 +535,31 @@ func NewFactory(
         log.With("worker", "deletion"),
     )
 
+    importWorker := workers.NewImportWorker(
+        5*time.Second,
+        importService,
+        importJobRepo,
+        bookCache,
+        cacheWorker,
+        log.With("worker", "import"),
+    )
+
+    enrichmentWorker := workers.NewEnrichmentWorker(
+        30*time.Second,
+        enrichmentService,
+        enrichmentRepo,
+        log.With("worker", "enrichment"),
+    )
+
+    authorNameBackfillWorker := workers.NewAuthorNameBackfillWorker(
+        10*time.Second,
+        authorRepo,
+        backfillJobRepo,
+        bookCache,
+        cacheWorker,
+        log.With("worker", "author_name_backfill"),
+    )
+
     homeService, err := home.NewHomeService(
         operationsManager,
         operationsFactory,
@@ -390,6 +585,10 @@ func NewFactory(
         DeletionWorker:        deletionWorker,
         CacheWorker:           cacheWorker,
         TokenCleanupWorker:    tokenCleanupWorker,
+        ImportWorker:          importWorker,
+        EnrichmentWorker:      enrichmentWorker,
+        AuthorNameBackfillWorker: authorNameBackfillWorker,
+        SummaryProvider:       summaryProvider,
         CacheManager:          cacheManager,
         LibraryHandler:        libraryHandler,
         BaseValidator:         baseValidator,
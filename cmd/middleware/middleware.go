@@ -105,6 +105,25 @@ func VerifyJWT(next http.Handler) http.Handler {
 	})
 }
 
+// RequireAdmin only lets through users listed in ADMIN_USER_IDS, use it after VerifyJWT
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if !config.AppConfig.AdminUserIDs[userID] {
+			logger.Warn("Non-admin user denied admin route", "userID", userID, "path", r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func GetUserID(ctx context.Context) (int, bool) {
     userID, ok := ctx.Value(core.UserIDKey).(int)
     if !ok {
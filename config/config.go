@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"crypto/rsa"
@@ -26,6 +28,18 @@ type Config struct {
 	DefaultBookCacheExpiration   time.Duration
 	UserDeletionMarkerExpiration time.Duration
 	AuthTokenExpiration          time.Duration
+	MetadataProviders            []string      // Search order, later providers are fallbacks
+	MetadataProviderTimeout      time.Duration
+	GoogleBooksAPIKey            string        // Used when the user's OAuth token is missing or revoked
+	AdminUserIDs                 map[int]bool  // Users allowed on /api/v1/admin routes
+	SummaryProvider              string        // "gemini" or "openai" (any OpenAI compatible server)
+	SummaryModel                 string
+	SummaryTemperature           float32
+	SummaryTimeout               time.Duration
+	SummaryBaseURL               string        // OpenAI compatible providers only, e.g. http://localhost:11434/v1
+	SummaryAPIKey                string
+	AIDailyTokenLimit            int           // Prompt + output tokens per user per UTC day, 0 for no limit
+	AIDailyRequestLimit          int           // 0 for no limit
 }
 
 var AppConfig Config
@@ -80,10 +94,101 @@ func InitConfig(logger *slog.Logger) {
 	AppConfig.UserDeletionMarkerExpiration = 7 * 24 * time.Hour
 	AppConfig.AuthTokenExpiration = 1 * time.Hour
 
-	// Log the entire AppConfig for debugging
+	// Metadata providers, e.g. METADATA_PROVIDERS=googlebooks,openlibrary
+	AppConfig.MetadataProviders = []string{"googlebooks", "openlibrary"}
+	if providers := os.Getenv("METADATA_PROVIDERS"); providers != "" {
+		AppConfig.MetadataProviders = strings.Split(providers, ",")
+	}
+	AppConfig.MetadataProviderTimeout = 10 * time.Second
+	if timeout, err := time.ParseDuration(os.Getenv("METADATA_PROVIDER_TIMEOUT")); err == nil && timeout > 0 {
+		AppConfig.MetadataProviderTimeout = timeout
+	}
+	AppConfig.GoogleBooksAPIKey = os.Getenv("GOOGLE_BOOKS_API_KEY")
+
+	// Admin users, e.g. ADMIN_USER_IDS=1,42
+	AppConfig.AdminUserIDs = make(map[int]bool)
+	for _, rawID := range strings.Split(os.Getenv("ADMIN_USER_IDS"), ",") {
+		if rawID = strings.TrimSpace(rawID); rawID == "" {
+			continue
+		}
+		userID, err := strconv.Atoi(rawID)
+		if err != nil {
+			logger.Error("Ignoring invalid admin user ID", "value", rawID)
+			continue
+		}
+		AppConfig.AdminUserIDs[userID] = true
+	}
+
+	// Book summary LLM, e.g. SUMMARY_PROVIDER=openai SUMMARY_BASE_URL=http://localhost:11434/v1 SUMMARY_MODEL=llama3.1
+	AppConfig.SummaryProvider = "gemini"
+	if provider := os.Getenv("SUMMARY_PROVIDER"); provider != "" {
+		AppConfig.SummaryProvider = strings.ToLower(provider)
+	}
+	AppConfig.SummaryModel = os.Getenv("SUMMARY_MODEL")
+	if AppConfig.SummaryModel == "" && AppConfig.SummaryProvider == "gemini" {
+		AppConfig.SummaryModel = "gemini-1.5-flash"
+	}
+	AppConfig.SummaryTemperature = 0.7
+	if temperature, err := strconv.ParseFloat(os.Getenv("SUMMARY_TEMPERATURE"), 32); err == nil && temperature >= 0 {
+		AppConfig.SummaryTemperature = float32(temperature)
+	}
+	AppConfig.SummaryTimeout = 10 * time.Second
+	if timeout, err := time.ParseDuration(os.Getenv("SUMMARY_TIMEOUT")); err == nil && timeout > 0 {
+		AppConfig.SummaryTimeout = timeout
+	}
+	AppConfig.SummaryBaseURL = os.Getenv("SUMMARY_BASE_URL")
+	AppConfig.SummaryAPIKey = os.Getenv("SUMMARY_API_KEY")
+	if AppConfig.SummaryAPIKey == "" && AppConfig.SummaryProvider == "gemini" {
+		AppConfig.SummaryAPIKey = os.Getenv("GOOGLE_GEMINI_API_KEY")
+	}
+
+	// Daily AI quotas per user, e.g. AI_DAILY_TOKEN_LIMIT=0 to lift the token cap
+	AppConfig.AIDailyTokenLimit = 50000
+	if limit, err := strconv.Atoi(os.Getenv("AI_DAILY_TOKEN_LIMIT")); err == nil && limit >= 0 {
+		AppConfig.AIDailyTokenLimit = limit
+	}
+	AppConfig.AIDailyRequestLimit = 50
+	if limit, err := strconv.Atoi(os.Getenv("AI_DAILY_REQUEST_LIMIT")); err == nil && limit >= 0 {
+		AppConfig.AIDailyRequestLimit = limit
+	}
+
+	// Log the entire AppConfig for debugging, secrets are redacted by LogValue
 	logger.Info("AppConfig initialized", "config", AppConfig)
 }
 
+// LogValue keeps the OAuth client secret, JWT keys and API keys out of the logs, only whether they're set is shown
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("googleRedirectURL", c.GoogleLoginConfig.RedirectURL),
+		slog.String("googleClientID", c.GoogleLoginConfig.ClientID),
+		slog.String("googleClientSecret", redactSecret(c.GoogleLoginConfig.ClientSecret)),
+		slog.Bool("jwtPrivateKeyLoaded", c.JWTPrivateKey != nil),
+		slog.Bool("jwtPublicKeyLoaded", c.JWTPublicKey != nil),
+		slog.Duration("defaultBookCacheExpiration", c.DefaultBookCacheExpiration),
+		slog.Duration("userDeletionMarkerExpiration", c.UserDeletionMarkerExpiration),
+		slog.Duration("authTokenExpiration", c.AuthTokenExpiration),
+		slog.Any("metadataProviders", c.MetadataProviders),
+		slog.Duration("metadataProviderTimeout", c.MetadataProviderTimeout),
+		slog.String("googleBooksAPIKey", redactSecret(c.GoogleBooksAPIKey)),
+		slog.Int("adminUsers", len(c.AdminUserIDs)),
+		slog.String("summaryProvider", c.SummaryProvider),
+		slog.String("summaryModel", c.SummaryModel),
+		slog.Float64("summaryTemperature", float64(c.SummaryTemperature)),
+		slog.Duration("summaryTimeout", c.SummaryTimeout),
+		slog.String("summaryBaseURL", c.SummaryBaseURL),
+		slog.String("summaryAPIKey", redactSecret(c.SummaryAPIKey)),
+		slog.Int("aiDailyTokenLimit", c.AIDailyTokenLimit),
+		slog.Int("aiDailyRequestLimit", c.AIDailyRequestLimit),
+	)
+}
+
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestConfigLogValueRedactsSecrets(t *testing.T) {
+	config := Config{
+		GoogleLoginConfig: oauth2.Config{ClientID: "client-id", ClientSecret: "oauth-secret"},
+		GoogleBooksAPIKey: "books-key",
+		SummaryProvider:   "gemini",
+		SummaryAPIKey:     "summary-key",
+	}
+
+	var output bytes.Buffer
+	slog.New(slog.NewJSONHandler(&output, nil)).Info("AppConfig initialized", "config", config)
+
+	for _, secret := range []string{"oauth-secret", "books-key", "summary-key"} {
+		if strings.Contains(output.String(), secret) {
+			t.Errorf("log output contains %q: %s", secret, output.String())
+		}
+	}
+	if !strings.Contains(output.String(), "client-id") || !strings.Contains(output.String(), "gemini") {
+		t.Errorf("log output is missing non-secret settings: %s", output.String())
+	}
+}
@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS tags_search_document_refresh ON tags;
+DROP TRIGGER IF EXISTS authors_search_document_refresh ON authors;
+DROP TRIGGER IF EXISTS book_tags_search_document_refresh ON book_tags;
+DROP TRIGGER IF EXISTS book_authors_search_document_refresh ON book_authors;
+DROP TRIGGER IF EXISTS books_search_document_refresh ON books;
+DROP FUNCTION IF EXISTS refresh_book_search_document();
+
+DROP INDEX IF EXISTS idx_books_search_document;
+ALTER TABLE books DROP COLUMN IF EXISTS search_document;
+
+DROP FUNCTION IF EXISTS book_search_document(INTEGER);
+DROP FUNCTION IF EXISTS rich_text_plain_text(TEXT);
@@ -0,0 +1,106 @@
+-- Full-text search over a user's own library, see BookRepository.SearchBooksByUserID.
+-- The 'simple' configuration is used throughout: libraries mix languages, and prefix
+-- matching covers most of what English stemming would.
+
+-- Flattens a Quill Delta document to plain text, rows written before Delta was adopted hold plain text
+CREATE OR REPLACE FUNCTION rich_text_plain_text(content TEXT) RETURNS TEXT AS $$
+DECLARE
+  delta JSONB;
+BEGIN
+  IF content IS NULL OR content = '' THEN
+    RETURN '';
+  END IF;
+
+  BEGIN
+    delta := content::jsonb;
+  EXCEPTION WHEN others THEN
+    RETURN content;
+  END;
+
+  IF jsonb_typeof(delta) IS DISTINCT FROM 'object' OR jsonb_typeof(delta->'ops') IS DISTINCT FROM 'array' THEN
+    RETURN '';
+  END IF;
+
+  RETURN COALESCE((
+    SELECT string_agg(op->>'insert', '')
+    FROM jsonb_array_elements(delta->'ops') AS op
+    WHERE jsonb_typeof(op->'insert') = 'string'
+  ), '');
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Titles and authors rank above subtitles, tags, and finally description and notes
+CREATE OR REPLACE FUNCTION book_search_document(target_book_id INTEGER) RETURNS TSVECTOR AS $$
+  SELECT
+    setweight(to_tsvector('simple', COALESCE(b.title, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE((
+      SELECT string_agg(a.name, ' ')
+      FROM book_authors ba
+      INNER JOIN authors a ON a.id = ba.author_id
+      WHERE ba.book_id = b.id
+    ), '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(b.subtitle, '')), 'B') ||
+    setweight(to_tsvector('simple', COALESCE((
+      SELECT string_agg(t.name, ' ')
+      FROM book_tags bt
+      INNER JOIN tags t ON t.id = bt.tag_id
+      WHERE bt.book_id = b.id
+    ), '')), 'C') ||
+    setweight(to_tsvector('simple', rich_text_plain_text(b.description::text)), 'D') ||
+    setweight(to_tsvector('simple', rich_text_plain_text(b.notes::text)), 'D')
+  FROM books b
+  WHERE b.id = target_book_id
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS search_document TSVECTOR;
+
+UPDATE books SET search_document = book_search_document(id);
+
+CREATE INDEX IF NOT EXISTS idx_books_search_document ON books USING GIN (search_document);
+
+-- Keep the document current. Authors and tags live in join tables and can be renamed,
+-- so those tables refresh every book they touch
+CREATE OR REPLACE FUNCTION refresh_book_search_document() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_TABLE_NAME = 'books' THEN
+    UPDATE books SET search_document = book_search_document(NEW.id) WHERE id = NEW.id;
+  ELSIF TG_TABLE_NAME = 'authors' THEN
+    UPDATE books SET search_document = book_search_document(id)
+    WHERE id IN (SELECT book_id FROM book_authors WHERE author_id = NEW.id);
+  ELSIF TG_TABLE_NAME = 'tags' THEN
+    UPDATE books SET search_document = book_search_document(id)
+    WHERE id IN (SELECT book_id FROM book_tags WHERE tag_id = NEW.id);
+  ELSIF TG_OP = 'DELETE' THEN
+    UPDATE books SET search_document = book_search_document(id) WHERE id = OLD.book_id;
+  ELSE
+    UPDATE books SET search_document = book_search_document(id) WHERE id = NEW.book_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Only fires for the listed columns, so the UPDATE of search_document doesn't retrigger it
+DROP TRIGGER IF EXISTS books_search_document_refresh ON books;
+CREATE TRIGGER books_search_document_refresh
+  AFTER INSERT OR UPDATE OF title, subtitle, description, notes ON books
+  FOR EACH ROW EXECUTE FUNCTION refresh_book_search_document();
+
+DROP TRIGGER IF EXISTS book_authors_search_document_refresh ON book_authors;
+CREATE TRIGGER book_authors_search_document_refresh
+  AFTER INSERT OR DELETE ON book_authors
+  FOR EACH ROW EXECUTE FUNCTION refresh_book_search_document();
+
+DROP TRIGGER IF EXISTS book_tags_search_document_refresh ON book_tags;
+CREATE TRIGGER book_tags_search_document_refresh
+  AFTER INSERT OR DELETE ON book_tags
+  FOR EACH ROW EXECUTE FUNCTION refresh_book_search_document();
+
+DROP TRIGGER IF EXISTS authors_search_document_refresh ON authors;
+CREATE TRIGGER authors_search_document_refresh
+  AFTER UPDATE OF name ON authors
+  FOR EACH ROW EXECUTE FUNCTION refresh_book_search_document();
+
+DROP TRIGGER IF EXISTS tags_search_document_refresh ON tags;
+CREATE TRIGGER tags_search_document_refresh
+  AFTER UPDATE OF name ON tags
+  FOR EACH ROW EXECUTE FUNCTION refresh_book_search_document();
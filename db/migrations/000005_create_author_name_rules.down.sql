@@ -0,0 +1 @@
+DROP TABLE IF EXISTS author_name_rules;
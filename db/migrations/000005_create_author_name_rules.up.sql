@@ -0,0 +1,18 @@
+-- Author-name normalization rules. A NULL user_id makes the rule global, user rules are applied on top of the global ones
+CREATE TABLE IF NOT EXISTS author_name_rules (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+  rule_type TEXT NOT NULL,
+  pattern TEXT NOT NULL DEFAULT '',
+  replacement TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_author_name_rules_user_id ON author_name_rules (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_author_name_rules_unique
+  ON author_name_rules (COALESCE(user_id, 0), rule_type, lower(pattern));
+
+-- Carried over from the hardcoded searchconfig mapping
+INSERT INTO author_name_rules (user_id, rule_type, pattern, replacement)
+VALUES (NULL, 'exact', 'Sandrovich Yabako', 'Yabako Sandrovich')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS book_enrichment_checks;
+DROP TABLE IF EXISTS book_enrichment_proposals;
+DROP TABLE IF EXISTS enrichment_scans;
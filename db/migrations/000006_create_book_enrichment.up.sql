@@ -0,0 +1,38 @@
+-- On-demand scans of a user's library for books with missing metadata, claimed by the enrichment worker
+CREATE TABLE IF NOT EXISTS enrichment_scans (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'pending',
+  checked_books INTEGER NOT NULL DEFAULT 0,
+  proposed_books INTEGER NOT NULL DEFAULT 0,
+  failure_reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  started_at TIMESTAMPTZ,
+  completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_enrichment_scans_user_id ON enrichment_scans (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_enrichment_scans_pending ON enrichment_scans (created_at) WHERE status = 'pending';
+
+-- Changes found by a scan, applied to the book only once the user accepts them
+CREATE TABLE IF NOT EXISTS book_enrichment_proposals (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,
+  changes JSONB NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  resolved_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_book_enrichment_proposals_pending
+  ON book_enrichment_proposals (user_id, book_id) WHERE status = 'pending';
+
+-- When each book was last looked up, so scans don't hit the providers for the same books every time
+CREATE TABLE IF NOT EXISTS book_enrichment_checks (
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+  checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, book_id)
+);
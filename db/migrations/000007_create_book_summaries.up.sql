@@ -0,0 +1,14 @@
+-- AI generated summaries, one per book and kind. Users can edit them, edited rows are flagged
+CREATE TABLE IF NOT EXISTS book_summaries (
+  id SERIAL PRIMARY KEY,
+  book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL,
+  content TEXT NOT NULL,
+  provider TEXT NOT NULL,
+  model TEXT NOT NULL,
+  edited BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  accepted_at TIMESTAMPTZ, -- Last copied into the book's notes
+  UNIQUE (book_id, kind)
+);
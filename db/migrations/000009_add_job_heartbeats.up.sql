@@ -0,0 +1,2 @@
+-- Running jobs are kept alive by their worker, so a restarting instance only fails jobs whose worker is gone
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMPTZ;
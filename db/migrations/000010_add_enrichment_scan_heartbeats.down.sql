@@ -0,0 +1 @@
+ALTER TABLE enrichment_scans DROP COLUMN IF EXISTS heartbeat_at;
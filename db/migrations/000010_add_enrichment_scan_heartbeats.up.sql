@@ -0,0 +1,2 @@
+-- Same lease as import jobs, see 000009
+ALTER TABLE enrichment_scans ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMPTZ;
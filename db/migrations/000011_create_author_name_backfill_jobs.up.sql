@@ -0,0 +1,15 @@
+-- Admin runs of the author name backfill, claimed by the backfill worker. Only one runs at a time
+CREATE TABLE IF NOT EXISTS author_name_backfill_jobs (
+  id SERIAL PRIMARY KEY,
+  requested_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+  status TEXT NOT NULL DEFAULT 'pending',
+  report JSONB,
+  failure_reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  started_at TIMESTAMPTZ,
+  heartbeat_at TIMESTAMPTZ,
+  completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_author_name_backfill_jobs_pending ON author_name_backfill_jobs (created_at) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS file_path TEXT NOT NULL DEFAULT '';
+ALTER TABLE import_jobs DROP COLUMN IF EXISTS file_data;
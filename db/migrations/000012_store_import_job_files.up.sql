@@ -0,0 +1,10 @@
+-- Uploads are stored on the job so whichever instance claims it can read the file. Pending jobs that still
+-- point at a file on one instance's disk can't be run anywhere else, so they're failed for a re-upload
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS file_data BYTEA;
+
+UPDATE import_jobs
+SET status = 'failed', failure_reason = 'import file is no longer available, please upload it again',
+  updated_at = NOW(), completed_at = NOW()
+WHERE status = 'pending';
+
+ALTER TABLE import_jobs DROP COLUMN IF EXISTS file_path;
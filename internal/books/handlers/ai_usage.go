@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/cmd/middleware"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
+)
+
+// HandleGetAIUsage reports the user's AI use today and what's left of their daily quota
+func (h *BookHandlers) HandleGetAIUsage(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	usage, err := h.aiQuotaService.GetUsage(request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Error retrieving AI usage", "userID", userID, "error", err)
+		http.Error(response, "Error retrieving AI usage", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: usage})
+}
+
+// Helper fn: 429 with the usage that hit the quota, so clients can show when it resets
+func (h *BookHandlers) writeAIQuotaExceeded(response http.ResponseWriter, quotaErr *services.AIQuotaExceededError) {
+	retryAfter := int(math.Ceil(time.Until(quotaErr.Usage.ResetsAt).Seconds()))
+	response.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+
+	h.sendJSONResponse(response, JSONResponse{
+		Data: map[string]interface{}{
+			"error": "Daily AI usage quota exceeded",
+			"usage": quotaErr.Usage,
+		},
+		StatusCode: http.StatusTooManyRequests,
+	})
+}
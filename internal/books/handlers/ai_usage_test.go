@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/services"
+	"github.com/lokeam/bravo-kilo/internal/shared/core"
+)
+
+func TestWriteBookSummaryErrorQuotaExceeded(t *testing.T) {
+	h := &BookHandlers{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	remainingTokens, remainingRequests := 0, 3
+	resetsAt := time.Now().Add(90 * time.Minute).UTC()
+	err := fmt.Errorf("generating summary: %w", &services.AIQuotaExceededError{Usage: &services.AIUsageReport{
+		Date:              resetsAt.AddDate(0, 0, -1).Format(time.DateOnly),
+		Requests:          7,
+		TotalTokens:       50100,
+		TokenLimit:        50000,
+		RequestLimit:      10,
+		RemainingTokens:   &remainingTokens,
+		RemainingRequests: &remainingRequests,
+		ResetsAt:          resetsAt,
+	}})
+
+	recorder := httptest.NewRecorder()
+	h.writeBookSummaryError(recorder, err)
+
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected a JSON payload, got %q", contentType)
+	}
+
+	retryAfter, convErr := strconv.Atoi(recorder.Header().Get("Retry-After"))
+	if convErr != nil {
+		t.Fatalf("expected Retry-After in seconds, got %q", recorder.Header().Get("Retry-After"))
+	}
+	if retryAfter < 89*60 || retryAfter > 90*60 {
+		t.Errorf("expected Retry-After of about 90 minutes, got %ds", retryAfter)
+	}
+
+	var payload struct {
+		Error string                 `json:"error"`
+		Usage services.AIUsageReport `json:"usage"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&payload); err != nil {
+		t.Fatalf("error decoding payload: %v", err)
+	}
+	if payload.Error == "" {
+		t.Error("expected an error message")
+	}
+	if payload.Usage.RemainingTokens == nil || *payload.Usage.RemainingTokens != 0 ||
+		payload.Usage.RemainingRequests == nil || *payload.Usage.RemainingRequests != 3 {
+		t.Errorf("unexpected remaining budget in %+v", payload.Usage)
+	}
+	if !payload.Usage.ResetsAt.Equal(resetsAt) {
+		t.Errorf("expected resetsAt %v, got %v", resetsAt, payload.Usage.ResetsAt)
+	}
+}
+
+func TestWriteBookSummaryErrorQuotaResetImminent(t *testing.T) {
+	h := &BookHandlers{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	recorder := httptest.NewRecorder()
+	h.writeBookSummaryError(recorder, &services.AIQuotaExceededError{Usage: &services.AIUsageReport{
+		ResetsAt: time.Now().Add(-time.Second),
+	}})
+
+	if recorder.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After to be at least 1s, got %q", recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestWriteBookSummaryErrorStatuses(t *testing.T) {
+	h := &BookHandlers{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	tests := []struct {
+		err        error
+		wantStatus int
+	}{
+		{services.ErrSummaryNotConfigured, http.StatusServiceUnavailable},
+		{fmt.Errorf("gemini: %w", services.ErrSummaryUnavailable), http.StatusBadGateway},
+		{fmt.Errorf("%w: text is required", services.ErrInvalidBookSummary), http.StatusBadRequest},
+		{services.ErrAIQuotaExceeded, http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		h.writeBookSummaryError(recorder, tt.err)
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%v: expected %d, got %d", tt.err, tt.wantStatus, recorder.Code)
+		}
+	}
+}
+
+type fakeAIUsageQuota struct {
+	services.AIQuotaService
+	report *services.AIUsageReport
+}
+
+func (q *fakeAIUsageQuota) GetUsage(ctx context.Context, userID int) (*services.AIUsageReport, error) {
+	return q.report, nil
+}
+
+func TestHandleGetAIUsage(t *testing.T) {
+	remainingTokens := 4000
+	h := &BookHandlers{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		aiQuotaService: &fakeAIUsageQuota{report: &services.AIUsageReport{
+			Date:            "2026-03-10",
+			Requests:        2,
+			TotalTokens:     1000,
+			TokenLimit:      5000,
+			RemainingTokens: &remainingTokens,
+		}},
+	}
+
+	recorder := httptest.NewRecorder()
+	h.HandleGetAIUsage(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/user/ai-usage", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a user, got %d", recorder.Code)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/user/ai-usage", nil)
+	request = request.WithContext(context.WithValue(request.Context(), core.UserIDKey, 1))
+	recorder = httptest.NewRecorder()
+	h.HandleGetAIUsage(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", recorder.Code)
+	}
+
+	var payload map[string]interface{}
+	if err := json.NewDecoder(recorder.Body).Decode(&payload); err != nil {
+		t.Fatalf("error decoding payload: %v", err)
+	}
+	// No request limit is configured, so there is no remaining count rather than a zero
+	if payload["remainingTokens"] != float64(4000) || payload["remainingRequests"] != nil || payload["requests"] != float64(2) {
+		t.Errorf("unexpected usage payload %v", payload)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
+)
+
+type authorMergeRequest struct {
+	TargetID  int   `json:"targetId"`
+	SourceIDs []int `json:"sourceIds"`
+}
+
+// HandleGetDuplicateAuthors lists groups of authors that look like one person, ?minSimilarity=0.9 tightens the match
+func (h *BookHandlers) HandleGetDuplicateAuthors(response http.ResponseWriter, request *http.Request) {
+	minSimilarity := services.DefaultAuthorSimilarity
+	if raw := request.URL.Query().Get("minSimilarity"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			http.Error(response, "minSimilarity must be a number between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		minSimilarity = parsed
+	}
+
+	groups, err := h.authorDedupeService.FindDuplicateAuthors(request.Context(), minSimilarity)
+	if err != nil {
+		h.logger.Error("Error finding duplicate authors", "error", err)
+		http.Error(response, "Error finding duplicate authors", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: map[string]interface{}{
+		"groups":        groups,
+		"minSimilarity": minSimilarity,
+	}})
+}
+
+// HandleMergeAuthors credits every book of the source authors to the target and deletes the sources
+func (h *BookHandlers) HandleMergeAuthors(response http.ResponseWriter, request *http.Request) {
+	var mergeRequest authorMergeRequest
+	if err := json.NewDecoder(request.Body).Decode(&mergeRequest); err != nil {
+		http.Error(response, "Error decoding request - invalid input", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.authorDedupeService.MergeAuthors(request.Context(), mergeRequest.TargetID, mergeRequest.SourceIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAuthorMerge):
+			http.Error(response, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrAuthorNotFound):
+			http.Error(response, err.Error(), http.StatusNotFound)
+		default:
+			h.logger.Error("Error merging authors", "error", err, "targetID", mergeRequest.TargetID)
+			http.Error(response, "Error merging authors", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Author lists of everyone who owned a book by a merged author are stale
+	for _, userID := range result.AffectedUserIDs {
+		h.invalidateBookCaches(request.Context(), 0, userID)
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: result})
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lokeam/bravo-kilo/cmd/middleware"
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+type authorNameRuleRequest struct {
+	UserID      *int                          `json:"userId"` // Omit for a global rule
+	Type        repository.AuthorNameRuleType `json:"type"`
+	Pattern     string                        `json:"pattern"`
+	Replacement string                        `json:"replacement"`
+}
+
+// HandleListAuthorNameRules returns every global and per-user author name rule
+func (h *BookHandlers) HandleListAuthorNameRules(response http.ResponseWriter, request *http.Request) {
+	rules, err := h.authorNameRules.ListRules(request.Context())
+	if err != nil {
+		http.Error(response, "Error fetching author name rules", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: map[string]interface{}{"rules": rules}})
+}
+
+// HandleCreateAuthorNameRule adds a rule. A user's rule changes how their books are shown straight away,
+// a global rule applies to new author inserts, and existing authors only change when the backfill is run
+func (h *BookHandlers) HandleCreateAuthorNameRule(response http.ResponseWriter, request *http.Request) {
+	var ruleRequest authorNameRuleRequest
+	if err := json.NewDecoder(request.Body).Decode(&ruleRequest); err != nil {
+		http.Error(response, "Error decoding request - invalid input", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.authorNameRules.InsertRule(request.Context(), repository.AuthorNameRule{
+		UserID:      ruleRequest.UserID,
+		Type:        ruleRequest.Type,
+		Pattern:     ruleRequest.Pattern,
+		Replacement: ruleRequest.Replacement,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidAuthorNameRule) {
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(response, "Error creating author name rule", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Author name rule created", "ruleID", rule.ID, "type", rule.Type)
+	h.sendJSONResponse(response, JSONResponse{Data: rule, StatusCode: http.StatusCreated})
+}
+
+func (h *BookHandlers) HandleDeleteAuthorNameRule(response http.ResponseWriter, request *http.Request) {
+	ruleID, err := strconv.Atoi(chi.URLParam(request, "ruleID"))
+	if err != nil || ruleID <= 0 {
+		http.Error(response, "Invalid rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authorNameRules.DeleteRule(request.Context(), ruleID); err != nil {
+		if errors.Is(err, repository.ErrAuthorNameRuleNotFound) {
+			http.Error(response, "Author name rule not found", http.StatusNotFound)
+			return
+		}
+		http.Error(response, "Error deleting author name rule", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Author name rule deleted", "ruleID", ruleID)
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// HandleBackfillAuthorNames queues a rewrite of existing authors with the global rules, ?dryRun=true only reports.
+// Returns the queued or running backfill instead when there already is one
+func (h *BookHandlers) HandleBackfillAuthorNames(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(request.URL.Query().Get("dryRun"))
+
+	job, created, err := h.backfillJobRepo.CreateJob(request.Context(), userID, dryRun)
+	if err != nil {
+		h.logger.Error("Error queueing author name backfill", "error", err)
+		http.Error(response, "Error starting author name backfill", http.StatusInternalServerError)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if created {
+		h.logger.Info("Author name backfill queued", "jobID", job.ID, "dryRun", dryRun, "requestedBy", userID)
+		statusCode = http.StatusAccepted
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: job, StatusCode: statusCode})
+}
+
+// HandleGetAuthorNameBackfill reports a backfill's status, with its report once it has finished
+func (h *BookHandlers) HandleGetAuthorNameBackfill(response http.ResponseWriter, request *http.Request) {
+	jobID, err := strconv.Atoi(chi.URLParam(request, "jobID"))
+	if err != nil || jobID <= 0 {
+		http.Error(response, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.backfillJobRepo.GetJobByID(request.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAuthorNameBackfillJobNotFound) {
+			http.Error(response, "Author name backfill not found", http.StatusNotFound)
+			return
+		}
+		http.Error(response, "Error retrieving author name backfill", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: job})
+}
+
+// Helper fn: the global rules plus the user's own. Author rows are shared, so they're stored with the global
+// rules alone and the user's rules are applied to their books on the way out
+func (h *BookHandlers) userAuthorNames(ctx context.Context, userID int) *repository.AuthorNameNormalizer {
+	normalizer, err := h.authorNameRules.GetNormalizer(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Error loading author name rules, showing stored author names", "error", err, "userID", userID)
+	}
+	return normalizer
+}
+
+// Helper fn: copy of grouped book lists with the user's author names, groups hold either a book slice
+// or a map with the books under "bookList"
+func groupedBooksWithAuthorNames(normalizer *repository.AuthorNameNormalizer, groups map[string]interface{}) map[string]interface{} {
+	named := make(map[string]interface{}, len(groups))
+	for key, value := range groups {
+		switch group := value.(type) {
+		case []repository.Book:
+			named[key] = normalizer.NormalizeBookAuthors(group)
+		case map[string]interface{}:
+			books, ok := group["bookList"].([]repository.Book)
+			if !ok {
+				named[key] = group
+				continue
+			}
+			namedGroup := make(map[string]interface{}, len(group))
+			for field, fieldValue := range group {
+				namedGroup[field] = fieldValue
+			}
+			namedGroup["bookList"] = normalizer.NormalizeBookAuthors(books)
+			named[key] = namedGroup
+		default:
+			named[key] = value
+		}
+	}
+	return named
+}
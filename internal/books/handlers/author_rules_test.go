@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+func TestGroupedBooksWithAuthorNamesLeavesCachedGroupsAlone(t *testing.T) {
+	normalizer := repository.NewAuthorNameNormalizer([]repository.AuthorNameRule{
+		{Type: repository.AuthorNameRuleInvertLastFirst},
+	})
+
+	stored := []repository.Book{{ID: 1, Title: "The Dispossessed", Authors: []string{"Le Guin, Ursula K."}}}
+	groups := map[string]interface{}{
+		"allTags": []string{"favourites"},
+		"0":       map[string]interface{}{"tagImgs": []string{""}, "bookList": stored},
+		"1":       stored,
+	}
+
+	named := groupedBooksWithAuthorNames(normalizer, groups)
+
+	want := []string{"Ursula K. Le Guin"}
+	if got := named["0"].(map[string]interface{})["bookList"].([]repository.Book)[0].Authors; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v in the nested book list, got %v", want, got)
+	}
+	if got := named["1"].([]repository.Book)[0].Authors; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v in the book slice, got %v", want, got)
+	}
+	if !reflect.DeepEqual(named["allTags"], groups["allTags"]) {
+		t.Errorf("non-book values should pass through, got %v", named["allTags"])
+	}
+
+	if stored[0].Authors[0] != "Le Guin, Ursula K." {
+		t.Errorf("the cached books were renamed in place: %v", stored[0].Authors)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/cmd/middleware"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
+)
+
+const (
+	maxRestoreMemory     = 50 << 20  // Restores are read in memory up to this size, larger archives spill to temp files
+	maxRestoreUploadSize = 512 << 20 // Whole request body, a backup's books.json alone may reach 256MB uncompressed
+)
+
+// HandleBackupUserBooks streams a zip archive of the user's whole library
+func (h *BookHandlers) HandleBackupUserBooks(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Large libraries stream for longer than the server's write timeout
+	if err := http.NewResponseController(response).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Unable to clear write deadline for backup", "error", err)
+	}
+
+	response.Header().Set("Content-Type", "application/zip")
+	response.Header().Set("Content-Disposition", "attachment; filename=library-backup.zip")
+
+	stream := &streamResponseWriter{ResponseWriter: response}
+	if err := h.backupService.WriteBackup(request.Context(), userID, stream); err != nil {
+		h.logger.Error("Error generating backup", "userID", userID, "error", err)
+		failStreamedResponse(stream, "Error generating backup")
+		return
+	}
+}
+
+// HandleRestoreUserBooks restores a backup archive, mode=merge (default) keeps the current library, mode=replace clears it first
+func (h *BookHandlers) HandleRestoreUserBooks(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Uploading and restoring a large library in one transaction outlasts the server's timeouts
+	controller := http.NewResponseController(response)
+	if err := controller.SetReadDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Unable to clear read deadline for restore", "error", err)
+	}
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Unable to clear write deadline for restore", "error", err)
+	}
+
+	request.Body = http.MaxBytesReader(response, request.Body, maxRestoreUploadSize)
+	if err := request.ParseMultipartForm(maxRestoreMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(response, "Backup archive is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(response, "Invalid restore upload", http.StatusBadRequest)
+		return
+	}
+
+	mode, err := services.ParseRestoreMode(request.FormValue("mode"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, fileHeader, err := request.FormFile("file")
+	if err != nil {
+		http.Error(response, "Invalid file upload", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	report, err := h.backupService.RestoreBackup(request.Context(), userID, file, fileHeader.Size, mode)
+	if errors.Is(err, services.ErrInvalidBackup) {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Restore failed", "userID", userID, "mode", mode, "error", err)
+		http.Error(response, "Unable to restore backup", http.StatusInternalServerError)
+		return
+	}
+
+	h.invalidateBookCaches(request.Context(), 0, userID)
+
+	h.sendJSONResponse(response, JSONResponse{
+		Data:       report,
+		StatusCode: http.StatusOK,
+	})
+}
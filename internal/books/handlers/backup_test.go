@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/shared/core"
+)
+
+func TestHandleRestoreUserBooksRejectsOversizedUpload(t *testing.T) {
+	h := &BookHandlers{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	// Stream the archive instead of allocating it
+	var header bytes.Buffer
+	form := multipart.NewWriter(&header)
+	form.CreateFormFile("file", "library-backup.zip")
+	body := io.MultiReader(
+		&header,
+		io.LimitReader(zeroReader{}, maxRestoreUploadSize+1),
+		strings.NewReader("\r\n--"+form.Boundary()+"--\r\n"),
+	)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/books/restore", body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	request = request.WithContext(context.WithValue(request.Context(), core.UserIDKey, 1))
+
+	recorder := httptest.NewRecorder()
+	h.HandleRestoreUserBooks(recorder, request)
+
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", recorder.Code)
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
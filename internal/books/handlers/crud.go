@@ -107,7 +107,7 @@ func (h *BookHandlers) HandleGetAllUserBooks(response http.ResponseWriter, reque
 
 				h.sendJSONResponse(response, JSONResponse{
 						Data: map[string]interface{}{
-								"books": books,
+								"books": h.userAuthorNames(request.Context(), userID).NormalizeBookAuthors(books),
 								"source": "cache",
 						},
 				})
@@ -170,10 +170,10 @@ func (h *BookHandlers) HandleGetAllUserBooks(response http.ResponseWriter, reque
 		}
 	}
 
-	// Send response
+	// Send response, the cache keeps stored author names so rule changes show up on the next read
 	h.sendJSONResponse(response, JSONResponse{
 			Data: map[string]interface{}{
-					"books": books,
+					"books": h.userAuthorNames(request.Context(), userID).NormalizeBookAuthors(books),
 					"source": "db",
 			},
 	})
@@ -194,7 +194,7 @@ func (h *BookHandlers) GetAllUserBooksDomain(ctx context.Context, userID int) ([
 	// Reverse normalize book data
 	h.bookService.ReverseNormalizeBookData(&books)
 
-	return books, nil
+	return h.userAuthorNames(ctx, userID).NormalizeBookAuthors(books), nil
 }
 
 
@@ -343,10 +343,12 @@ func (h *BookHandlers) HandleGetBookByID(response http.ResponseWriter, request *
 				// Apply title casing
 				caser := cases.Title(language.Und)
 				book.Title = caser.String(book.Title)
+				book.Authors = h.userAuthorNames(request.Context(), userID).NormalizeAll(book.Authors)
 
 				h.sendJSONResponse(response, JSONResponse{
 					Data: map[string]interface{}{
 						"book":       book,
+						"summaries":  h.listBookSummaries(request.Context(), bookID),
 						"source":      "cache",
 					},
 				})
@@ -380,10 +382,12 @@ func (h *BookHandlers) HandleGetBookByID(response http.ResponseWriter, request *
 	}
 
 	// Send response
+	book.Authors = h.userAuthorNames(request.Context(), userID).NormalizeAll(book.Authors)
 	h.sendJSONResponse(response, JSONResponse{
 		Data: map[string]interface{}{
-			"book":   book,
-			"source": "db",
+			"book":      book,
+			"summaries": h.listBookSummaries(request.Context(), bookID),
+			"source":    "db",
 		},
 	})
 }
@@ -454,44 +458,8 @@ func (h *BookHandlers) HandleInsertBook(response http.ResponseWriter, request *h
 			return
 	}
 
-	// Invalidate L1 caches after inserting a book
-	h.BookCache.InvalidateCaches(bookID, userID)
-
-	// Prepare cache keys for Redis invalidation
-	cacheKeys := []string{
-		fmt.Sprintf("%s%d", redis.PrefixBook, userID),
-		fmt.Sprintf("%s%d", redis.PrefixBookAuthor, userID),
-		fmt.Sprintf("%s%d", redis.PrefixBookFormat, userID),
-		fmt.Sprintf("%s%d", redis.PrefixBookGenre, userID),
-		fmt.Sprintf("%s%d", redis.PrefixBookTag, userID),
-		fmt.Sprintf("%s%d", redis.PrefixBookHomepage, userID),
-	}
-
-	// Attempt immediate cache invalidation
-	ctx, cancel := context.WithTimeout(request.Context(), h.redisClient.GetConfig().TimeoutConfig.Write)
-	defer cancel()
-
-	if err := h.redisClient.Delete(ctx, cacheKeys...); err != nil {
-		// If immediate invalidation fails, queue for async retry
-		h.logger.Warn("Immediate cache invalidation failed, queueing for retry",
-				"error", err,
-				"userID", userID,
-				"bookID", bookID,
-		)
-
-		if queueErr := h.cacheWorker.EnqueueInvalidationJob(request.Context(), workers.CacheInvalidationJob{
-				Keys:      cacheKeys,
-				UserID:    userID,
-				BookID:    bookID,
-				Timestamp: time.Now(),
-		}); queueErr != nil {
-				h.logger.Error("Failed to queue cache invalidation job",
-						"error", queueErr,
-						"userID", userID,
-						"bookID", bookID,
-				)
-		}
-	}
+	// Invalidate L1 + L2 caches after inserting a book
+	h.invalidateBookCaches(request.Context(), bookID, userID)
 
 	// Send response back to FE
 	response.Header().Set("Content-Type", "application/json")
@@ -501,41 +469,11 @@ func (h *BookHandlers) HandleInsertBook(response http.ResponseWriter, request *h
 
 // Helper fns for validation
 func validateISBN10(fl validator.FieldLevel) bool {
-	isbn := fl.Field().String()
-	if len(isbn) != 10 {
-		return false
-	}
-	sum := 0
-	for i := 0; i < 9; i++ {
-		digit := int(isbn[i] - '0')
-		if digit < 0 || digit > 9 {
-			return false
-		}
-		sum += digit * (10 - i)
-	}
-	checksum := (11 - sum%11) % 11
-	return (checksum == 10 && isbn[9] == 'X') || (int(isbn[9]-'0') == checksum)
+	return utils.IsValidISBN10(fl.Field().String())
 }
 
 func validateISBN13(fl validator.FieldLevel) bool {
-	isbn := fl.Field().String()
-	if len(isbn) != 13 {
-		return false
-	}
-	sum := 0
-	for i := 0; i < 12; i++ {
-		digit := int(isbn[i] - '0')
-		if digit < 0 || digit > 9 {
-			return false
-		}
-		if i%2 == 0 {
-			sum += digit
-		} else {
-			sum += 3 * digit
-		}
-	}
-	checksum := (10 - sum%10) % 10
-	return int(isbn[12]-'0') == checksum
+	return utils.IsValidISBN13(fl.Field().String())
 }
 
 // Update Book
@@ -639,6 +577,11 @@ func (h *BookHandlers) HandleGetBooksByFormat(response http.ResponseWriter, requ
 		if err := json.Unmarshal([]byte(cachedData), &booksByFormat); err == nil {
 			h.logger.Info("Crud.go - HandleGetBooksByFormat - Cache hit: returning books from cache")
 
+			authorNames := h.userAuthorNames(request.Context(), userID)
+			for format, books := range booksByFormat {
+				booksByFormat[format] = authorNames.NormalizeBookAuthors(books)
+			}
+
 			h.sendJSONResponse(response, JSONResponse{
 				Data: map[string]interface{}{
 					"booksByFormat": booksByFormat,
@@ -679,6 +622,11 @@ func (h *BookHandlers) HandleGetBooksByFormat(response http.ResponseWriter, requ
 	}
 
 	// Send response
+	authorNames := h.userAuthorNames(request.Context(), userID)
+	for format, books := range booksByFormat {
+		booksByFormat[format] = authorNames.NormalizeBookAuthors(books)
+	}
+
 	h.sendJSONResponse(response, JSONResponse{
 		Data: map[string]interface{}{
 			"booksByFormat": booksByFormat,
@@ -733,6 +681,11 @@ func (h *BookHandlers) HandleGetBooksByGenres(response http.ResponseWriter, requ
 		if err := json.Unmarshal([]byte(cachedData), &booksByGenres); err == nil {
 			h.logger.Info("Crud.go - HandleGetBooksByGenres - Cache hit: returning books from cache")
 
+			authorNames := h.userAuthorNames(request.Context(), userID)
+			for genre, books := range booksByGenres {
+				booksByGenres[genre] = authorNames.NormalizeBookAuthors(books)
+			}
+
 			h.sendJSONResponse(response, JSONResponse{
 				Data: map[string]interface{}{
 					"booksByGenres": booksByGenres,
@@ -765,10 +718,10 @@ func (h *BookHandlers) HandleGetBooksByGenres(response http.ResponseWriter, requ
 		}
 	}
 
-	// Send response
+	// Send response, the repository caches its result so the books are copied rather than renamed in place
 	h.sendJSONResponse(response, JSONResponse{
 		Data: map[string]interface{}{
-			"booksByGenres": booksByGenres,
+			"booksByGenres": groupedBooksWithAuthorNames(h.userAuthorNames(request.Context(), userID), booksByGenres),
 			"source":        "db",
 		},
 	})
@@ -787,7 +740,7 @@ func (h *BookHandlers) GetBooksByGenreDomain(ctx context.Context, userID int) (m
 		return nil, fmt.Errorf("error fetching books by genre: %w", err)
 	}
 
-	return booksByGenre, nil
+	return groupedBooksWithAuthorNames(h.userAuthorNames(ctx, userID), booksByGenre), nil
 }
 
 
@@ -814,6 +767,11 @@ func (h *BookHandlers) HandleGetBooksByTags(response http.ResponseWriter, reques
 			if err := json.Unmarshal([]byte(cachedData), &booksByTags); err == nil {
 					h.logger.Info("Crud.go - HandleGetBooksByTags - Cache hit: returning books from cache")
 
+					authorNames := h.userAuthorNames(request.Context(), userID)
+					for tag, books := range booksByTags {
+						booksByTags[tag] = authorNames.NormalizeBookAuthors(books)
+					}
+
 					h.sendJSONResponse(response, JSONResponse{
 							Data: map[string]interface{}{
 									"booksByTags": booksByTags,
@@ -850,7 +808,7 @@ func (h *BookHandlers) HandleGetBooksByTags(response http.ResponseWriter, reques
 	// Send response
 	h.sendJSONResponse(response, JSONResponse{
 		Data: map[string]interface{}{
-			"booksByTags": booksByTags,
+			"booksByTags": groupedBooksWithAuthorNames(h.userAuthorNames(request.Context(), userID), booksByTags),
 			"source":      "db",
 		},
 	})
@@ -868,7 +826,7 @@ func (h *BookHandlers) GetBooksByTagsDomain(ctx context.Context, userID int) (ma
 
 	// Type assertion to ensure correct type
 	result := make(map[string][]repository.Book)
-	for tag, books := range booksByTags {
+	for tag, books := range groupedBooksWithAuthorNames(h.userAuthorNames(ctx, userID), booksByTags) {
 			if booksSlice, ok := books.([]repository.Book); ok {
 					result[tag] = booksSlice
 			} else {
@@ -1009,6 +967,40 @@ func (h *BookHandlers) HandleGetHomepageData(response http.ResponseWriter, reque
 	})
 }
 
+// Helper fn to invalidate L1 caches and user's Redis book keys, queueing a retry on failure
+func (h *BookHandlers) invalidateBookCaches(ctx context.Context, bookID int, userID int) {
+	h.BookCache.InvalidateCaches(bookID, userID)
+
+	// Prepare cache keys for Redis invalidation
+	cacheKeys := redis.UserBookCacheKeys(userID)
+
+	// Attempt immediate cache invalidation
+	deleteCtx, cancel := context.WithTimeout(ctx, h.redisClient.GetConfig().TimeoutConfig.Write)
+	defer cancel()
+
+	if err := h.redisClient.Delete(deleteCtx, cacheKeys...); err != nil {
+		// If immediate invalidation fails, queue for async retry
+		h.logger.Warn("Immediate cache invalidation failed, queueing for retry",
+				"error", err,
+				"userID", userID,
+				"bookID", bookID,
+		)
+
+		if queueErr := h.cacheWorker.EnqueueInvalidationJob(ctx, workers.CacheInvalidationJob{
+				Keys:      cacheKeys,
+				UserID:    userID,
+				BookID:    bookID,
+				Timestamp: time.Now(),
+		}); queueErr != nil {
+				h.logger.Error("Failed to queue cache invalidation job",
+						"error", queueErr,
+						"userID", userID,
+						"bookID", bookID,
+				)
+		}
+	}
+}
+
 // Helper fn to send consistent JSON responses
 func (h *BookHandlers) sendJSONResponse(w http.ResponseWriter, response JSONResponse) {
 	// Set content type
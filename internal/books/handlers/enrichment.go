@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lokeam/bravo-kilo/cmd/middleware"
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
+)
+
+type enrichmentAcceptRequest struct {
+	Fields []string `json:"fields"` // Omit to accept every change in the proposal
+}
+
+// HandleStartEnrichmentScan queues a scan of the user's books for missing metadata.
+// Returns the queued or running scan instead when there already is one
+func (h *BookHandlers) HandleStartEnrichmentScan(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	scan, created, err := h.enrichmentRepo.CreateScan(request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Error queueing enrichment scan", "userID", userID, "error", err)
+		http.Error(response, "Error starting metadata scan", http.StatusInternalServerError)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if created {
+		h.logger.Info("Enrichment scan queued", "userID", userID, "scanID", scan.ID)
+		statusCode = http.StatusAccepted
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: scan, StatusCode: statusCode})
+}
+
+// HandleGetEnrichmentScan reports the user's most recent scan
+func (h *BookHandlers) HandleGetEnrichmentScan(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	scan, err := h.enrichmentRepo.GetLatestScan(request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrEnrichmentScanNotFound) {
+			http.Error(response, "No metadata scan found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Error retrieving enrichment scan", "userID", userID, "error", err)
+		http.Error(response, "Error retrieving metadata scan", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: scan})
+}
+
+// HandleGetEnrichmentProposals lists the user's proposals, pending ones unless ?status= says otherwise
+func (h *BookHandlers) HandleGetEnrichmentProposals(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status := repository.EnrichmentProposalPending
+	if raw := request.URL.Query().Get("status"); raw != "" {
+		status = repository.EnrichmentProposalStatus(raw)
+		switch status {
+		case repository.EnrichmentProposalPending, repository.EnrichmentProposalAccepted, repository.EnrichmentProposalRejected:
+		default:
+			http.Error(response, "status must be pending, accepted or rejected", http.StatusBadRequest)
+			return
+		}
+	}
+
+	proposals, err := h.enrichmentRepo.ListProposals(request.Context(), userID, status)
+	if err != nil {
+		h.logger.Error("Error retrieving enrichment proposals", "userID", userID, "error", err)
+		http.Error(response, "Error retrieving metadata proposals", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: map[string]interface{}{"proposals": proposals}})
+}
+
+// HandleAcceptEnrichmentProposal applies a proposal to its book. The body may list the fields to take, e.g. {"fields": ["pageCount"]}
+func (h *BookHandlers) HandleAcceptEnrichmentProposal(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	proposalID, err := strconv.Atoi(chi.URLParam(request, "proposalID"))
+	if err != nil || proposalID <= 0 {
+		http.Error(response, "Invalid proposal ID", http.StatusBadRequest)
+		return
+	}
+
+	var acceptRequest enrichmentAcceptRequest
+	if err := json.NewDecoder(request.Body).Decode(&acceptRequest); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(response, "Error decoding request - invalid input", http.StatusBadRequest)
+		return
+	}
+
+	proposal, err := h.enrichmentService.AcceptProposal(request.Context(), userID, proposalID, acceptRequest.Fields)
+	if err != nil {
+		h.writeEnrichmentProposalError(response, proposalID, err)
+		return
+	}
+
+	h.invalidateBookCaches(request.Context(), proposal.BookID, userID)
+
+	h.logger.Info("Enrichment proposal accepted", "userID", userID, "proposalID", proposalID, "bookID", proposal.BookID)
+	h.sendJSONResponse(response, JSONResponse{Data: proposal})
+}
+
+func (h *BookHandlers) HandleRejectEnrichmentProposal(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	proposalID, err := strconv.Atoi(chi.URLParam(request, "proposalID"))
+	if err != nil || proposalID <= 0 {
+		http.Error(response, "Invalid proposal ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.enrichmentService.RejectProposal(request.Context(), userID, proposalID); err != nil {
+		h.writeEnrichmentProposalError(response, proposalID, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BookHandlers) writeEnrichmentProposalError(response http.ResponseWriter, proposalID int, err error) {
+	switch {
+	case errors.Is(err, repository.ErrEnrichmentProposalNotFound):
+		http.Error(response, "Metadata proposal not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrEnrichmentProposalResolved):
+		http.Error(response, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidEnrichmentFields):
+		http.Error(response, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.Error("Error resolving enrichment proposal", "proposalID", proposalID, "error", err)
+		http.Error(response, "Error updating metadata proposal", http.StatusInternalServerError)
+	}
+}
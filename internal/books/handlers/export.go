@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"bytes"
 	"net/http"
+	"time"
 
 	"github.com/lokeam/bravo-kilo/config"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
 	"github.com/lokeam/bravo-kilo/internal/shared/jwt"
 )
 
-// HandleExportUserBooks exports a user's books as a CSV file
+// HandleExportUserBooks exports a user's books as CSV, JSON, NDJSON, Markdown, Calibre OPF, BibTeX or RIS,
+// columns= picks which fields the tabular formats include
 func (h *BookHandlers) HandleExportUserBooks(response http.ResponseWriter, request *http.Request) {
 	userID, err := jwt.ExtractUserIDFromJWT(request, config.AppConfig.JWTPublicKey)
 	if err != nil {
@@ -16,12 +20,71 @@ func (h *BookHandlers) HandleExportUserBooks(response http.ResponseWriter, reque
 		return
 	}
 
-	response.Header().Set("Content-Type", "text/csv")
-	response.Header().Set("Content-Disposition", "attachment; filename=books.csv")
+	format, err := services.ParseExportFormat(request.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	columns, err := services.ParseExportColumns(request.URL.Query().Get("columns"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Large libraries stream for longer than the server's write timeout
+	if err := http.NewResponseController(response).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Unable to clear write deadline for export", "error", err)
+	}
+
+	response.Header().Set("Content-Type", format.ContentType())
+	response.Header().Set("Content-Disposition", "attachment; filename="+format.FileName())
+
+	stream := &streamResponseWriter{ResponseWriter: response}
+	if err := h.exportService.GenerateBookExport(request.Context(), userID, format, stream, columns); err != nil {
+		h.logger.Error("Error generating export for user books", "userID", userID, "format", format, "error", err)
+		failStreamedResponse(stream, "Error generating export")
+		return
+	}
+}
+
+// HandleExportBook exports a single owned book in any export format, OPF is served as a bare metadata.opf
+func (h *BookHandlers) HandleExportBook(response http.ResponseWriter, request *http.Request) {
+	_, bookID, err := h.ValidateBookOwnership(request)
+	if err != nil {
+		h.logger.Error("Book ownership validation failed", "error", err)
+		http.Error(response, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	format, err := services.ParseExportFormat(request.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	columns, err := services.ParseExportColumns(request.URL.Query().Get("columns"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	book, err := h.bookRepo.GetBookByID(bookID)
+	if err != nil {
+		h.logger.Error("Error fetching book for export", "bookID", bookID, "error", err)
+		http.Error(response, "Error fetching book", http.StatusInternalServerError)
+		return
+	}
 
-	if err := h.exportService.GenerateBookCSV(userID, response); err != nil {
-		h.logger.Error("Error generating CSV for user books", "userID", userID, "error", err)
-		http.Error(response, "Error generating CSV", http.StatusInternalServerError)
+	// Render before writing headers so a failure can still be reported as an error status
+	var buffer bytes.Buffer
+	if err := h.exportService.ExportBook(*book, format, &buffer, columns); err != nil {
+		h.logger.Error("Error generating book export", "bookID", bookID, "format", format, "error", err)
+		http.Error(response, "Error generating export", http.StatusInternalServerError)
 		return
 	}
+
+	response.Header().Set("Content-Type", format.BookContentType())
+	response.Header().Set("Content-Disposition", "attachment; filename="+format.BookFileName(*book))
+	response.Write(buffer.Bytes())
 }
@@ -21,6 +21,9 @@ import (
 // Handlers struct to hold the logger, models, and new components
 type BookHandlers struct {
 	authorRepo              repository.AuthorRepository
+	authorNameRules         repository.AuthorNameRuleRepository
+	backfillJobRepo         repository.AuthorNameBackfillJobRepository
+	authorDedupeService     services.AuthorDedupeService
 	bookRepo                repository.BookRepository
 	formatRepo              repository.FormatRepository
 	genreRepo               repository.GenreRepository
@@ -31,6 +34,14 @@ type BookHandlers struct {
 	bookService             services.BookService
 	bookCacheService        services.BookCacheService
 	exportService           services.ExportService
+	importService           services.ImportService
+	importJobRepo           repository.ImportJobRepository
+	backupService           services.BackupService
+	metadataProvider        services.MetadataProvider
+	enrichmentRepo          repository.EnrichmentRepository
+	enrichmentService       services.EnrichmentService
+	summaryService          services.SummaryService
+	aiQuotaService          services.AIQuotaService
 	exportLimiter           *rate.Limiter
 	logger                  *slog.Logger
 	bookModels              books.Models
@@ -60,6 +71,9 @@ func NewBookHandlers(
 	logger *slog.Logger,
 	bookModels books.Models,
 	authorRepo repository.AuthorRepository,
+	authorNameRules repository.AuthorNameRuleRepository,
+	backfillJobRepo repository.AuthorNameBackfillJobRepository,
+	authorDedupeService services.AuthorDedupeService,
 	bookRepo repository.BookRepository,
 	formatRepo repository.FormatRepository,
 	genreRepo repository.GenreRepository,
@@ -71,6 +85,14 @@ func NewBookHandlers(
 	bookService services.BookService,
 	bookCacheService services.BookCacheService,
 	exportService services.ExportService,
+	importService services.ImportService,
+	importJobRepo repository.ImportJobRepository,
+	backupService services.BackupService,
+	metadataProvider services.MetadataProvider,
+	enrichmentRepo repository.EnrichmentRepository,
+	enrichmentService services.EnrichmentService,
+	summaryService services.SummaryService,
+	aiQuotaService services.AIQuotaService,
 	redisClient *rueidis.Client,
 	cacheManager *cache.CacheManager,
 	cacheWorker *workers.CacheWorker,
@@ -83,6 +105,18 @@ func NewBookHandlers(
 		return nil, fmt.Errorf("authorRepo cannot be nil")
 	}
 
+	if authorNameRules == nil {
+		return nil, fmt.Errorf("authorNameRules cannot be nil")
+	}
+
+	if backfillJobRepo == nil {
+		return nil, fmt.Errorf("backfillJobRepo cannot be nil")
+	}
+
+	if authorDedupeService == nil {
+		return nil, fmt.Errorf("authorDedupeService cannot be nil")
+	}
+
 	if bookRepo == nil {
 		return nil, fmt.Errorf("bookRepo cannot be nil")
 	}
@@ -99,6 +133,30 @@ func NewBookHandlers(
 		return nil, fmt.Errorf("exportService cannot be nil")
 	}
 
+	if importService == nil {
+		return nil, fmt.Errorf("importService cannot be nil")
+	}
+
+	if importJobRepo == nil {
+		return nil, fmt.Errorf("importJobRepo cannot be nil")
+	}
+
+	if backupService == nil {
+		return nil, fmt.Errorf("backupService cannot be nil")
+	}
+
+	if metadataProvider == nil {
+		return nil, fmt.Errorf("metadataProvider cannot be nil")
+	}
+
+	if enrichmentRepo == nil || enrichmentService == nil {
+		return nil, fmt.Errorf("enrichmentRepo and enrichmentService cannot be nil")
+	}
+
+	if summaryService == nil || aiQuotaService == nil {
+		return nil, fmt.Errorf("summaryService and aiQuotaService cannot be nil")
+	}
+
 	if BookCache == nil {
 		return nil, fmt.Errorf("bookCache cannot be nil")
 	}
@@ -139,6 +197,9 @@ func NewBookHandlers(
 		logger:            logger,
 		bookModels:        bookModels,
 		authorRepo:        authorRepo,
+		authorNameRules:   authorNameRules,
+		backfillJobRepo:   backfillJobRepo,
+		authorDedupeService: authorDedupeService,
 		bookRepo:          bookRepo,
 		formatRepo:        formatRepo,
 		genreRepo:         genreRepo,
@@ -149,6 +210,14 @@ func NewBookHandlers(
 		bookCacheService:  bookCacheService,
 		bookUpdater:       bookUpdater,
 		exportService:     exportService,
+		importService:     importService,
+		importJobRepo:     importJobRepo,
+		backupService:     backupService,
+		metadataProvider:  metadataProvider,
+		enrichmentRepo:    enrichmentRepo,
+		enrichmentService: enrichmentService,
+		summaryService:    summaryService,
+		aiQuotaService:    aiQuotaService,
 		exportLimiter:     rate.NewLimiter(rate.Limit(1), 3),
 		validate:          validate,
 		sanitizer:         sanitizer,
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lokeam/bravo-kilo/cmd/middleware"
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// HandleGetImportJob reports progress for one of the user's import jobs
+func (h *BookHandlers) HandleGetImportJob(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	jobID := chi.URLParam(request, "jobID")
+	if _, err := uuid.Parse(jobID); err != nil {
+		http.Error(response, "Invalid import job ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.importJobRepo.GetJobByID(request.Context(), jobID, userID)
+	if err != nil {
+		h.writeImportJobError(response, jobID, err)
+		return
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: job})
+}
+
+// HandleCancelImportJob stops a pending or running import job
+func (h *BookHandlers) HandleCancelImportJob(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	jobID := chi.URLParam(request, "jobID")
+	if _, err := uuid.Parse(jobID); err != nil {
+		http.Error(response, "Invalid import job ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.importJobRepo.RequestCancel(request.Context(), jobID, userID)
+	if err != nil {
+		h.writeImportJobError(response, jobID, err)
+		return
+	}
+
+	h.logger.Info("Import job cancel requested", "userID", userID, "jobID", jobID, "status", job.Status)
+
+	// Running jobs stop at the worker's next progress check
+	h.sendJSONResponse(response, JSONResponse{
+		Data:       job,
+		StatusCode: http.StatusAccepted,
+	})
+}
+
+func (h *BookHandlers) writeImportJobError(response http.ResponseWriter, jobID string, err error) {
+	if errors.Is(err, repository.ErrImportJobNotFound) {
+		http.Error(response, "Import job not found", http.StatusNotFound)
+		return
+	}
+
+	h.logger.Error("Error retrieving import job", "jobID", jobID, "error", err)
+	http.Error(response, "Error retrieving import job", http.StatusInternalServerError)
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/lokeam/bravo-kilo/cmd/middleware"
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
+	"github.com/lokeam/bravo-kilo/internal/shared/utils"
+)
+
+// Barcode scans are almost always of a book in hand
+var defaultAddByISBNFormats = []string{"physical"}
+
+type addByISBNRequest struct {
+	ISBN    string   `json:"isbn"`
+	Formats []string `json:"formats"`
+}
+
+// HandleAddBookByISBN looks an ISBN up with the metadata providers and adds the book in one call.
+// Books the user already owns, under either ISBN form or the same title and author, are reported and not added again
+func (h *BookHandlers) HandleAddBookByISBN(response http.ResponseWriter, request *http.Request) {
+	userID, ok := middleware.GetUserID(request.Context())
+	if !ok {
+		http.Error(response, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var addRequest addByISBNRequest
+	if err := json.NewDecoder(request.Body).Decode(&addRequest); err != nil {
+		h.logger.Error("Error decoding add by ISBN request", "error", err)
+		http.Error(response, "Error decoding request - invalid input", http.StatusBadRequest)
+		return
+	}
+
+	isbn10, isbn13, err := parseRequestISBN(addRequest.ISBN)
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	libraryMatcher, err := h.BookCache.GetLibraryMatcher(userID)
+	if err != nil {
+		h.logger.Error("Error retrieving user's library matcher", "error", err)
+		http.Error(response, "Error checking user's library", http.StatusInternalServerError)
+		return
+	}
+
+	if bookID, owned := libraryMatcher.Match(repository.Book{ISBN10: isbn10, ISBN13: isbn13}); owned {
+		h.sendAddByISBNResponse(response, http.StatusOK, bookID, true, nil)
+		return
+	}
+
+	book, err := h.lookupISBN(request, userID, isbn13, isbn10)
+	if err != nil {
+		if errors.Is(err, services.ErrMetadataNotFound) {
+			http.Error(response, "No book found for this ISBN", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Error looking up ISBN", "isbn", isbn13, "error", err)
+		http.Error(response, "Book lookup is currently unavailable", http.StatusBadGateway)
+		return
+	}
+
+	// Keep the scanned ISBN, providers sometimes answer with another edition's
+	if book.ISBN13 == "" || repository.NormalizeISBN13(book.ISBN13) != isbn13 {
+		book.ISBN13 = isbn13
+		book.ISBN10 = isbn10
+	}
+
+	// Same book under a different ISBN, e.g. another printing
+	if bookID, owned := libraryMatcher.Match(*book); owned {
+		h.sendAddByISBNResponse(response, http.StatusOK, bookID, true, book)
+		return
+	}
+
+	book.Formats = addRequest.Formats
+	if len(book.Formats) == 0 {
+		book.Formats = defaultAddByISBNFormats
+	}
+
+	bookID, err := h.bookService.CreateBookEntry(request.Context(), *book, userID)
+	if err != nil {
+		h.logger.Error("Error inserting book from ISBN lookup", "isbn", isbn13, "error", err)
+		http.Error(response, "Error inserting book", http.StatusInternalServerError)
+		return
+	}
+
+	// Invalidate L1 + L2 caches after inserting a book
+	h.invalidateBookCaches(request.Context(), bookID, userID)
+
+	book.ID = bookID
+	book.IsInLibrary = true
+	h.sendAddByISBNResponse(response, http.StatusCreated, bookID, false, book)
+}
+
+// Helper fn: try the ISBN-13 first, then the ISBN-10 some older catalogue records only carry.
+// Authors come back the way the user sees them in their library
+func (h *BookHandlers) lookupISBN(request *http.Request, userID int, isbn13 string, isbn10 string) (*repository.Book, error) {
+	book, err := h.metadataProvider.LookupISBN(request.Context(), isbn13)
+	if errors.Is(err, services.ErrMetadataNotFound) && isbn10 != "" {
+		book, err = h.metadataProvider.LookupISBN(request.Context(), isbn10)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	normalizer, err := h.authorNameRules.GetNormalizer(request.Context(), userID)
+	if err != nil {
+		h.logger.Warn("Error loading author name rules, keeping provider's author names", "error", err)
+	}
+	book.Authors = normalizer.NormalizeAll(book.Authors)
+	return book, nil
+}
+
+// Helper fn: accepts either ISBN form, hyphens and spaces allowed, and returns both forms.
+// 979 ISBN-13s have no ISBN-10, so isbn10 may be empty
+func parseRequestISBN(raw string) (string, string, error) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(raw)))
+
+	switch len(isbn) {
+	case 10:
+		if !utils.IsValidISBN10(isbn) {
+			return "", "", errors.New("Invalid ISBN-10")
+		}
+	case 13:
+		if !utils.IsValidISBN13(isbn) {
+			return "", "", errors.New("Invalid ISBN-13")
+		}
+	default:
+		return "", "", errors.New("ISBN must be 10 or 13 characters")
+	}
+
+	isbn13 := repository.NormalizeISBN13(isbn)
+	return repository.ISBN13ToISBN10(isbn13), isbn13, nil
+}
+
+func (h *BookHandlers) sendAddByISBNResponse(response http.ResponseWriter, statusCode int, bookID int, alreadyOwned bool, book *repository.Book) {
+	data := map[string]interface{}{
+		"bookId":       bookID,
+		"alreadyOwned": alreadyOwned,
+	}
+	if book != nil {
+		data["book"] = book
+	}
+
+	h.sendJSONResponse(response, JSONResponse{
+		Data:       data,
+		StatusCode: statusCode,
+	})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
+	"github.com/lokeam/bravo-kilo/internal/shared/core"
+)
+
+type fakeISBNBookCache struct {
+	repository.BookCache
+	matcher *repository.LibraryMatcher
+}
+
+func (c *fakeISBNBookCache) GetLibraryMatcher(userID int) (*repository.LibraryMatcher, error) {
+	return c.matcher, nil
+}
+
+// Answers lookups from books keyed by ISBN, anything else is not found
+type fakeISBNMetadataProvider struct {
+	services.MetadataProvider
+	books   map[string]repository.Book
+	lookups []string
+}
+
+func (p *fakeISBNMetadataProvider) LookupISBN(ctx context.Context, isbn string) (*repository.Book, error) {
+	p.lookups = append(p.lookups, isbn)
+	book, ok := p.books[isbn]
+	if !ok {
+		return nil, services.ErrMetadataNotFound
+	}
+	return &book, nil
+}
+
+type fakeISBNAuthorNameRules struct {
+	repository.AuthorNameRuleRepository
+}
+
+func (r *fakeISBNAuthorNameRules) GetNormalizer(ctx context.Context, userID int) (*repository.AuthorNameNormalizer, error) {
+	return repository.NewAuthorNameNormalizer(nil), nil
+}
+
+func TestParseRequestISBN(t *testing.T) {
+	tests := []struct {
+		raw        string
+		wantISBN10 string
+		wantISBN13 string
+		wantErr    bool
+	}{
+		{raw: "9780807083055", wantISBN10: "0807083054", wantISBN13: "9780807083055"},
+		{raw: " 978-0-8070-8305-5 ", wantISBN10: "0807083054", wantISBN13: "9780807083055"},
+		{raw: "0-8044-2957-x", wantISBN10: "080442957X", wantISBN13: "9780804429573"},
+		{raw: "979-10-90636-07-1", wantISBN13: "9791090636071"}, // 979 ISBNs have no ISBN-10
+		{raw: "9780807083056", wantErr: true},
+		{raw: "0807083055", wantErr: true},
+		{raw: "97808070830", wantErr: true},
+		{raw: "kindred", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		isbn10, isbn13, err := parseRequestISBN(tt.raw)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("parseRequestISBN(%q) error = %v, want error %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if isbn10 != tt.wantISBN10 || isbn13 != tt.wantISBN13 {
+			t.Errorf("parseRequestISBN(%q) = (%q, %q), want (%q, %q)", tt.raw, isbn10, isbn13, tt.wantISBN10, tt.wantISBN13)
+		}
+	}
+}
+
+func TestHandleAddBookByISBN(t *testing.T) {
+	library := []repository.LibraryMatchEntry{
+		{BookID: 1, Title: "Kindred", ISBN13: "9780807083055", Authors: []string{"Octavia E. Butler"}},
+		{BookID: 2, Title: "The Dispossessed", Authors: []string{"Ursula K. Le Guin"}},
+	}
+
+	tests := []struct {
+		name         string
+		isbn         string
+		books        map[string]repository.Book
+		wantStatus   int
+		wantBookID   float64
+		wantLookups  []string
+		wantResponse bool
+	}{
+		{
+			name:       "invalid ISBN",
+			isbn:       "9780807083056",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "owned under the other ISBN form, no lookup needed",
+			isbn:         "0-8070-8305-4",
+			wantStatus:   http.StatusOK,
+			wantBookID:   1,
+			wantResponse: true,
+		},
+		{
+			name: "owned under another edition's ISBN, found after the lookup",
+			isbn: "978-0-06-051275-0",
+			books: map[string]repository.Book{
+				"9780060512750": {Title: "The Dispossessed: An Ambiguous Utopia", Authors: []string{"Ursula K. Le Guin"}},
+			},
+			wantStatus:   http.StatusOK,
+			wantBookID:   2,
+			wantLookups:  []string{"9780060512750"},
+			wantResponse: true,
+		},
+		{
+			name: "ISBN-10 is tried when the ISBN-13 isn't found",
+			isbn: "9780060512750",
+			books: map[string]repository.Book{
+				"006051275X": {Title: "The Dispossessed", Authors: []string{"Ursula K. Le Guin"}},
+			},
+			wantStatus:   http.StatusOK,
+			wantBookID:   2,
+			wantLookups:  []string{"9780060512750", "006051275X"},
+			wantResponse: true,
+		},
+		{
+			name:        "979 ISBNs have no ISBN-10 to fall back to",
+			isbn:        "979-10-90636-07-1",
+			wantStatus:  http.StatusNotFound,
+			wantLookups: []string{"9791090636071"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeISBNMetadataProvider{books: tt.books}
+			h := &BookHandlers{
+				logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+				BookCache:        &fakeISBNBookCache{matcher: repository.NewLibraryMatcher(library)},
+				metadataProvider: provider,
+				authorNameRules:  &fakeISBNAuthorNameRules{},
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/books/isbn", strings.NewReader(`{"isbn":"`+tt.isbn+`"}`))
+			request = request.WithContext(context.WithValue(request.Context(), core.UserIDKey, 1))
+			recorder := httptest.NewRecorder()
+			h.HandleAddBookByISBN(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, recorder.Code, recorder.Body.String())
+			}
+			if !reflect.DeepEqual(provider.lookups, tt.wantLookups) {
+				t.Errorf("expected lookups %v, got %v", tt.wantLookups, provider.lookups)
+			}
+			if !tt.wantResponse {
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+			if response["bookId"] != tt.wantBookID || response["alreadyOwned"] != true {
+				t.Errorf("expected book %v already owned, got %v", tt.wantBookID, response)
+			}
+			// The looked up book is returned so the client can show what matched
+			if _, ok := response["book"]; ok != (len(tt.wantLookups) > 0) {
+				t.Errorf("unexpected book in the response: %v", response)
+			}
+		})
+	}
+}
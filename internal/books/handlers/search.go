@@ -1,28 +1,32 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	authhandlers "github.com/lokeam/bravo-kilo/internal/auth/handlers"
 	"github.com/lokeam/bravo-kilo/internal/books/repository"
-	searchconfig "github.com/lokeam/bravo-kilo/internal/searchconfig"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
 	"github.com/lokeam/bravo-kilo/internal/shared/core"
-	"github.com/lokeam/bravo-kilo/internal/shared/utils"
-	"golang.org/x/oauth2"
 )
 
-
+const (
+	defaultBookSearchPageSize    = 35
+	defaultLibrarySearchPageSize = 20
+)
 
 type SearchHandlers struct {
-	logger        *slog.Logger
-	bookRepo      repository.BookRepository
-	bookCache     repository.BookCache
-	authHandlers  *authhandlers.AuthHandlers
+	logger           *slog.Logger
+	bookRepo         repository.BookRepository
+	bookCache        repository.BookCache
+	authHandlers     *authhandlers.AuthHandlers
+	metadataProvider services.MetadataProvider
+	authorNameRules  repository.AuthorNameRuleRepository
 }
 
 func NewSearchHandlers(
@@ -30,6 +34,8 @@ func NewSearchHandlers(
 	bookRepo repository.BookRepository,
 	bookCache repository.BookCache,
 	authHandlers *authhandlers.AuthHandlers,
+	metadataProvider services.MetadataProvider,
+	authorNameRules repository.AuthorNameRuleRepository,
 	) (*SearchHandlers, error) {
 	if logger == nil {
 		return nil, fmt.Errorf("logger cannot be nil")
@@ -43,11 +49,21 @@ func NewSearchHandlers(
 		return nil, fmt.Errorf("failed to initialize bookCache")
 	}
 
+	if metadataProvider == nil {
+		return nil, fmt.Errorf("failed to initialize metadataProvider")
+	}
+
+	if authorNameRules == nil {
+		return nil, fmt.Errorf("failed to initialize authorNameRules")
+	}
+
 	return &SearchHandlers{
 		logger:   logger,
 		bookRepo: bookRepo,
 		bookCache: bookCache,
 		authHandlers: authHandlers,
+		metadataProvider: metadataProvider,
+		authorNameRules: authorNameRules,
 	}, nil
 }
 
@@ -72,115 +88,16 @@ func checkEmptyFields(book repository.Book) (bool, []string) {
 	return hasEmptyFields, emptyFields
 }
 
-// Format Google Books Response
-func (h *SearchHandlers) formatGoogleBooksResponse(response http.ResponseWriter, booksData interface{}) []repository.Book {
-	var gBooksResponse []repository.Book
-
-	// Ensure that the data is correctly cast to the expected format
-	dataMap, ok := booksData.(map[string]interface{})
-	if !ok {
-		h.logger.Error("Invalid books data format")
-		http.Error(response, "Invalid books data format", http.StatusInternalServerError)
-		return nil
-	}
-
-	items, ok := dataMap["items"].([]interface{})
-	if !ok {
-		h.logger.Warn("No items in books data")
-		return gBooksResponse // Return an empty slice if no items are found
-	}
-
-	for _, item := range items {
-		volumeInfo, ok := item.(map[string]interface{})["volumeInfo"].(map[string]interface{})
-		if !ok {
-			h.logger.Warn("Invalid volumeInfo format", "item", item)
-			continue // Skip items with invalid format
-		}
-
-		// Use utility functions to safely retrieve string and integer values with defaults
-		formattedBook := repository.Book{
-			Title:       utils.GetStringValOrDefault(volumeInfo, "title", ""),
-			Subtitle:    utils.GetStringValOrDefault(volumeInfo, "subtitle", ""),
-			Description: utils.StringToRichText(utils.GetStringValOrDefault(volumeInfo, "description", "")),
-			Language:    utils.GetStringValOrDefault(volumeInfo, "language", ""),
-			PageCount:   utils.GetIntValOrDefault(volumeInfo, "pageCount", 0),
-			PublishDate: utils.GetStringValOrDefault(volumeInfo, "publishedDate", ""),
-		}
-
-		// Handle image link, selecting the largest available thumbnail
-		if imageLinks, ok := volumeInfo["imageLinks"].(map[string]interface{}); ok {
-			if largeThumbnail, ok := imageLinks["thumbnail"].(string); ok {
-					formattedBook.ImageLink = utils.CleanImageLink(largeThumbnail)
-			} else if smallThumbnail, ok := imageLinks["smallThumbnail"].(string); ok {
-					formattedBook.ImageLink = utils.CleanImageLink(smallThumbnail)
-			} else {
-					formattedBook.ImageLink = "" // Handle cases where no image link is available
-			}
-		}
-
-
-		// Handle ISBN numbers
-		if industryIdentifiers, ok := volumeInfo["industryIdentifiers"].([]interface{}); ok {
-			for _, id := range industryIdentifiers {
-				if identifier, ok := id.(map[string]interface{}); ok {
-					if utils.GetStringValOrDefault(identifier, "type", "") == "ISBN_13" {
-						formattedBook.ISBN13 = utils.GetStringValOrDefault(identifier, "identifier", "")
-					}
-					if utils.GetStringValOrDefault(identifier, "type", "") == "ISBN_10" {
-						formattedBook.ISBN10 = utils.GetStringValOrDefault(identifier, "identifier", "")
-					}
-				}
-			}
-		}
-
-		// Handle genres, ensuring it's always an array
-		formattedBook.Genres = []string{}
-		if categories, ok := volumeInfo["categories"].([]interface{}); ok {
-			for _, category := range categories {
-				if categoryStr, ok := category.(string); ok {
-					formattedBook.Genres = append(formattedBook.Genres, categoryStr)
-				}
-			}
-		}
-
-		// Handle authors, ensuring it's always an array
-		formattedBook.Authors = []string{}
-		if authors, ok := volumeInfo["authors"].([]interface{}); ok {
-			for _, author := range authors {
-				if authorStr, ok := author.(string); ok {
-					formattedBook.Authors = append(
-						formattedBook.Authors,
-						// Normalize author name based on searchconfig listing
-						h.normalizeAuthorName(authorStr),
-					)
-				}
-			}
-		}
-
-		// Check for empty fields
-		hasEmptyFields, emptyFields := checkEmptyFields(formattedBook)
-		formattedBook.HasEmptyFields = hasEmptyFields
-		formattedBook.EmptyFields = emptyFields
-
-		gBooksResponse = append(gBooksResponse, formattedBook)
-	}
-
-	h.logger.Info("Formatted books", "books", gBooksResponse)
-	return gBooksResponse
-}
-
-// Process Google Books API Search
+// Search external catalogues through the configured metadata providers
 func (h *SearchHandlers) HandleSearchBooks(response http.ResponseWriter, request *http.Request) {
-	query := request.URL.Query().Get("query")
-	if query == "" {
-			http.Error(response, "Query parameter required in request", http.StatusBadRequest)
+	searchQuery, page, err := parseBookSearchQuery(request.URL.Query())
+	if err != nil {
+			http.Error(response, err.Error(), http.StatusBadRequest)
 			return
 	}
 
-	// Debug - Set headers to prevent caching
-	response.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-	response.Header().Set("Pragma", "no-cache")
-	response.Header().Set("Expires", "0")
+	// Provider results are cached server side, but the IsInLibrary overlay makes each response user specific
+	response.Header().Set("Cache-Control", "private, no-cache")
 
 
 	// Get userID from context
@@ -191,113 +108,133 @@ func (h *SearchHandlers) HandleSearchBooks(response http.ResponseWriter, request
 			return
 	}
 
-	// Get user's access token
+	// Google Books searches as the user when we have their token, other providers don't need it
+	ctx := request.Context()
 	accessToken, err := h.authHandlers.GetUserAccessToken(request)
 	if err != nil {
-		h.logger.Error("Error retrieving user access token", "error", err)
-		if err == authhandlers.ErrNoRefreshToken {
-				http.Error(response, "No valid refresh token found", http.StatusUnauthorized)
-		} else {
-				http.Error(response, "Error retrieving access token", http.StatusInternalServerError)
-		}
+		h.logger.Warn("No user access token for book search, continuing without it", "error", err)
+	} else {
+		ctx = services.WithMetadataAccessToken(ctx, accessToken)
+	}
+
+	searchResult, err := h.metadataProvider.Search(ctx, searchQuery)
+	if err != nil {
+		h.logger.Error("Every metadata provider failed", "error", err)
+		http.Error(response, "Book search is currently unavailable", http.StatusBadGateway)
 		return
 	}
 
-	// Use the access token to call the Google Books API
-	client := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(accessToken))
-	googleBooksURL := fmt.Sprintf("https://www.googleapis.com/books/v1/volumes?q=%s&maxResults=35", url.QueryEscape(query))
+	// Show author names the way the user sees them in their library
+	authorNames, err := h.authorNameRules.GetNormalizer(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Error loading author name rules, keeping provider's author names", "error", err)
+	}
+
+	formattedBooks := searchResult.Books
+	for i := range formattedBooks {
+		formattedBook := &formattedBooks[i]
+		formattedBook.Authors = authorNames.NormalizeAll(formattedBook.Authors)
 
-	h.logger.Info("Requesting Google Books API", "url", googleBooksURL)
+		formattedBook.HasEmptyFields, formattedBook.EmptyFields = checkEmptyFields(*formattedBook)
+	}
 
-	booksResponse, err := client.Get(googleBooksURL)
+	// Index of the user's books by ISBN and title, cached until their library changes
+	libraryMatcher, err := h.bookCache.GetLibraryMatcher(userID)
 	if err != nil {
-			h.logger.Error("Error calling Google Books API", "error", err)
-			http.Error(response, "Error calling Google Books API", http.StatusInternalServerError)
+			h.logger.Error("Error retrieving user's library matcher", "error", err)
+			http.Error(response, "Error checking user's library", http.StatusInternalServerError)
 			return
 	}
-	defer booksResponse.Body.Close()
 
-	if booksResponse.StatusCode != http.StatusOK {
-			var errorResponse map[string]interface{}
-			json.NewDecoder(booksResponse.Body).Decode(&errorResponse)
-			h.logger.Error("Google Books API responded with non-OK status", "status", booksResponse.StatusCode, "body", errorResponse)
-			http.Error(response, "Google Books API error", booksResponse.StatusCode)
-			return
+	// Check each book against the user's library, the matched ID lets the UI link to the local copy
+	for i := range formattedBooks {
+		formattedBook := &formattedBooks[i]
+		formattedBook.LibraryBookID, formattedBook.IsInLibrary = libraryMatcher.Match(*formattedBook)
 	}
 
-	// Decode the Google Books API response
-	var booksData interface{}
-	if err := json.NewDecoder(booksResponse.Body).Decode(&booksData); err != nil {
-			h.logger.Error("Error decoding Google Books API response", "error", err)
-			http.Error(response, "Error decoding response", http.StatusInternalServerError)
-			return
+	// h.logger.Info("===================")
+	// h.logger.Info("Showing formattedBooks, post check, about to send:", "formattedBooks", formattedBooks)
+
+	dbResponse := map[string]interface{}{
+		"books": formattedBooks,
+		"isSearchPage": true,
+		"provider": searchResult.Provider,
+		"totalItems": searchResult.TotalItems,
+		"page": page,
+		"pageSize": searchQuery.MaxResults,
 	}
 
-	// Format the books response
-	formattedBooks := h.formatGoogleBooksResponse(response, booksData)
-	// h.logger.Info("---------------")
-	// h.logger.Info("Showing formattedBooks, pre-check:", "formattedBooks", formattedBooks)
+	// Provider totals are estimates, an empty page ends the search even when the total says otherwise
+	if len(searchResult.Books) > 0 && searchQuery.StartIndex+searchQuery.MaxResults < searchResult.TotalItems {
+		dbResponse["nextPageToken"] = services.EncodeSearchPageToken(searchQuery, page+1)
+	}
 
-	// Create hash sets for user's existing library data
-	isbn10Set, err := h.bookCache.GetAllBooksISBN10(userID)
-	if err != nil {
-			h.logger.Error("Error retrieving user's ISBN10", "error", err)
-			http.Error(response, "Error retrieving user's ISBN10", http.StatusInternalServerError)
-			return
+	response.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(response).Encode(dbResponse); err != nil {
+			h.logger.Error("Error encoding response", "error", err)
+			http.Error(response, "Error encoding response", http.StatusInternalServerError)
 	}
+}
 
-	isbn13Set, err := h.bookCache.GetAllBooksISBN13(userID)
-	if err != nil {
-			h.logger.Error("Error retrieving user's ISBN13", "error", err)
-			http.Error(response, "Error retrieving user's ISBN13", http.StatusInternalServerError)
+// Search the user's own library, ranked full-text matches with highlighted snippets
+func (h *SearchHandlers) HandleSearchUserBooks(response http.ResponseWriter, request *http.Request) {
+	userID, ok := request.Context().Value(core.UserIDKey).(int)
+	if !ok {
+			h.logger.Error("Failed to get userID from context")
+			http.Error(response, "Unauthorized", http.StatusUnauthorized)
 			return
 	}
 
-	// Debug - temporarily remove book title check
-	// titleSet, err := h.models.Book.GetAllBooksTitles(userID)
-	// if err != nil {
-	// 		h.logger.Error("Error retrieving user's book titles", "error", err)
-	// 		http.Error(response, "Error retrieving user's book titles", http.StatusInternalServerError)
-	// 		return
-	// }
-
-    // Fetch the user's book publish dates as a slice of BookInfo structs
-    bookList, err := h.bookCache.GetAllBooksPublishDate(userID)
-    if err != nil {
-        h.logger.Error("Error retrieving user's book publish dates", "error", err)
-        http.Error(response, "Error retrieving user's book publish dates", http.StatusInternalServerError)
-        return
-    }
-
-	// Check bookMap
-	h.logger.Info("======================================")
-	fmt.Println("Checking bookmap: ", bookList)
-
-    // Helper function to check if a book is in the user's library
-  bookExistsInLibrary := func(title, publishDate string) bool {
-		for _, book := range bookList {
-			if book.Title == title && book.PublishDate == publishDate {
-				return true
-			}
-		}
-		return false
+	params := request.URL.Query()
+	searchParams := repository.LibrarySearchParams{
+		Query:    strings.TrimSpace(params.Get("q")),
+		Format:   params.Get("format"),
+		Genre:    params.Get("genre"),
+		Language: params.Get("language"),
+		Tag:      params.Get("tag"),
+		Limit:    defaultLibrarySearchPageSize,
+	}
+	if searchParams.Query == "" && searchParams.Format == "" && searchParams.Genre == "" &&
+		searchParams.Language == "" && searchParams.Tag == "" {
+			http.Error(response, "Query or filter parameter required in request", http.StatusBadRequest)
+			return
+	}
+	if searchParams.Query != "" && !repository.HasLibrarySearchTerms(searchParams.Query) {
+			http.Error(response, "Query must contain letters or numbers", http.StatusBadRequest)
+			return
 	}
 
-	// Check each book against the user's library
-	for i := range formattedBooks {
-		formattedBook := &formattedBooks[i]
-		isInLibrary := (isbn10Set.Has(formattedBook.ISBN10) || isbn13Set.Has(formattedBook.ISBN13)) &&
-				bookExistsInLibrary(formattedBook.Title, formattedBook.PublishDate)
+	// Formats are checked by validator.LibrarySearchQueryRules
+	if pageSize, err := strconv.Atoi(params.Get("pageSize")); err == nil && pageSize > 0 {
+		searchParams.Limit = pageSize
+	}
+	page := 1
+	if requestedPage, err := strconv.Atoi(params.Get("page")); err == nil && requestedPage > 0 {
+		page = requestedPage
+	}
+	searchParams.Offset = (page - 1) * searchParams.Limit
 
-		formattedBook.IsInLibrary = isInLibrary
+	searchResult, err := h.bookRepo.SearchBooksByUserID(request.Context(), userID, searchParams)
+	if err != nil {
+			h.logger.Error("Error searching user's library", "error", err, "userID", userID)
+			http.Error(response, "Error searching library", http.StatusInternalServerError)
+			return
 	}
 
-	// h.logger.Info("===================")
-	// h.logger.Info("Showing formattedBooks, post check, about to send:", "formattedBooks", formattedBooks)
+	// Stored author names carry the global rules only, the user's own apply on the way out
+	authorNames, err := h.authorNameRules.GetNormalizer(request.Context(), userID)
+	if err != nil {
+		h.logger.Warn("Error loading author name rules, showing stored author names", "error", err)
+	}
+	for i := range searchResult.Hits {
+		searchResult.Hits[i].Book.Authors = authorNames.NormalizeAll(searchResult.Hits[i].Book.Authors)
+	}
 
 	dbResponse := map[string]interface{}{
-		"books": formattedBooks,
-		"isSearchPage": true,
+		"hits": searchResult.Hits,
+		"totalItems": searchResult.TotalItems,
+		"page": page,
+		"pageSize": searchParams.Limit,
 	}
 
 	response.Header().Set("Content-Type", "application/json")
@@ -307,9 +244,45 @@ func (h *SearchHandlers) HandleSearchBooks(response http.ResponseWriter, request
 	}
 }
 
-func (h *SearchHandlers) normalizeAuthorName(author string) string {
-	if correctName, exists := searchconfig.BookDomainAuthorNameMappings[author]; exists {
-		return correctName
+// Helper fn: builds the provider query from the request, query may mix free text with intitle:/inauthor:/isbn: terms.
+// Parameter formats are checked by validator.BookSearchQueryRules before we get here
+func parseBookSearchQuery(params url.Values) (services.MetadataSearchQuery, int, error) {
+	searchQuery := services.MetadataSearchQuery{
+		MaxResults: defaultBookSearchPageSize,
+		OrderBy:    params.Get("orderBy"),
+		Language:   strings.ToLower(params.Get("lang")),
+		PrintType:  params.Get("printType"),
 	}
-	return author
+
+	searchQuery.AddSearchText(params.Get("query"))
+	if title := params.Get("intitle"); title != "" {
+		searchQuery.Title = title
+	}
+	if author := params.Get("inauthor"); author != "" {
+		searchQuery.Author = author
+	}
+	if isbn := params.Get("isbn"); isbn != "" {
+		searchQuery.ISBN = strings.ToUpper(strings.ReplaceAll(isbn, "-", ""))
+	}
+	if searchQuery.IsEmpty() {
+		return searchQuery, 0, fmt.Errorf("Query parameter required in request")
+	}
+
+	if pageSize, err := strconv.Atoi(params.Get("pageSize")); err == nil && pageSize > 0 {
+		searchQuery.MaxResults = pageSize
+	}
+
+	page := 1
+	if pageToken := params.Get("pageToken"); pageToken != "" {
+		tokenPage, err := services.DecodeSearchPageToken(searchQuery, pageToken)
+		if err != nil {
+			return searchQuery, 0, fmt.Errorf("Invalid page token, it does not match this search")
+		}
+		page = tokenPage
+	} else if requestedPage, err := strconv.Atoi(params.Get("page")); err == nil && requestedPage > 0 {
+		page = requestedPage
+	}
+
+	searchQuery.StartIndex = (page - 1) * searchQuery.MaxResults
+	return searchQuery, page, nil
 }
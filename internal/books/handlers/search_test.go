@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/shared/core"
+)
+
+func TestHandleSearchUserBooksRejectsQueriesWithoutTerms(t *testing.T) {
+	// Rejected before the repository is reached, so none is needed
+	h := &SearchHandlers{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	tests := []struct {
+		name  string
+		query url.Values
+	}{
+		{name: "no query or filter", query: url.Values{}},
+		{name: "punctuation only", query: url.Values{"q": {"!!! ---"}}},
+		{name: "punctuation with a filter", query: url.Values{"q": {"&|"}, "format": {"physical"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/user/books/search?"+tt.query.Encode(), nil)
+			request = request.WithContext(context.WithValue(request.Context(), core.UserIDKey, 1))
+
+			recorder := httptest.NewRecorder()
+			h.HandleSearchUserBooks(recorder, request)
+
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", recorder.Code)
+			}
+		})
+	}
+}
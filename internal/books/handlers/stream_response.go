@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// streamResponseWriter notes whether a streamed download has sent anything yet, after that its status
+// and headers are already on the wire
+type streamResponseWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *streamResponseWriter) WriteHeader(statusCode int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *streamResponseWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends the headers even when nothing was written yet
+func (w *streamResponseWriter) Flush() {
+	w.started = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the connection underneath
+func (w *streamResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Helper fn: report a failed download. Until something is sent that's an error status, after that the connection
+// is aborted so the client sees a broken transfer instead of a truncated file that looks complete
+func failStreamedResponse(response *streamResponseWriter, message string) {
+	if !response.started {
+		response.Header().Del("Content-Disposition")
+		http.Error(response, message, http.StatusInternalServerError)
+		return
+	}
+
+	panic(http.ErrAbortHandler)
+}
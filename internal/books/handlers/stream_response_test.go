@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFailStreamedResponseBeforeStart(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Disposition", "attachment; filename=books.csv")
+
+	failStreamedResponse(&streamResponseWriter{ResponseWriter: recorder}, "Error generating export")
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Content-Disposition") != "" {
+		t.Error("error response should not be offered as a download")
+	}
+}
+
+func TestFailStreamedResponseAfterFlushAborts(t *testing.T) {
+	stream := &streamResponseWriter{ResponseWriter: httptest.NewRecorder()}
+	stream.Flush()
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler panic, got %v", recovered)
+		}
+	}()
+	failStreamedResponse(stream, "Error generating export")
+	t.Error("expected failStreamedResponse to abort")
+}
+
+func TestFailStreamedResponseBreaksTransfer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream := &streamResponseWriter{ResponseWriter: w}
+		io.WriteString(stream, "title,authors\nThe Dispossessed,Ursula K. Le Guin\n")
+		stream.Flush()
+		failStreamedResponse(stream, "Error generating export")
+	}))
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected request error: %v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("expected the stream's 200, got %d", response.StatusCode)
+	}
+	if _, err := io.ReadAll(response.Body); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected the client to see a broken transfer, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
+)
+
+// Longer than the server's WriteTimeout, a streamed summary can outlast it
+const summaryStreamWriteTimeout = 2 * time.Minute
+
+// HandleGetBookSummary returns the saved summary of one of the user's books, ?kind=synopsis|themes|discussion-questions.
+// One is generated when there is none yet or ?regenerate=true. The prompt is built from the stored book, clients can't send their own
+func (h *BookHandlers) HandleGetBookSummary(response http.ResponseWriter, request *http.Request) {
+	userID, bookID, err := h.ValidateBookOwnership(request)
+	if err != nil {
+		h.logger.Error("Book ownership validation failed", "error", err)
+		http.Error(response, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	kind, err := services.ParseSummaryKind(request.URL.Query().Get("kind"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.summaryService.GetBookSummary(request.Context(), userID, bookID, kind, summaryRegenerate(request))
+	if err != nil {
+		h.logger.Error("Error generating book summary", "bookID", bookID, "kind", kind, "error", err)
+		h.writeBookSummaryError(response, err)
+		return
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: summary})
+}
+
+// HandleStreamBookSummary is HandleGetBookSummary over Server-Sent Events. Text arrives as "chunk" events,
+// then a "done" event carries the whole summary. The stream only opens with the first event, so failures before it
+// (e.g. an exhausted AI quota) get a normal status code, later ones are sent as an "error" event
+func (h *BookHandlers) HandleStreamBookSummary(response http.ResponseWriter, request *http.Request) {
+	userID, bookID, err := h.ValidateBookOwnership(request)
+	if err != nil {
+		h.logger.Error("Book ownership validation failed", "error", err)
+		http.Error(response, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	kind, err := services.ParseSummaryKind(request.URL.Query().Get("kind"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	controller := http.NewResponseController(response)
+	if err := controller.SetWriteDeadline(time.Now().Add(summaryStreamWriteTimeout)); err != nil {
+		h.logger.Warn("Unable to extend write deadline for summary stream", "error", err)
+	}
+
+	streamOpen := false
+	sendEvent := func(event string, data interface{}) error {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if !streamOpen {
+			response.Header().Set("Content-Type", "text/event-stream")
+			response.Header().Set("Cache-Control", "no-cache")
+			response.Header().Set("Connection", "keep-alive")
+			response.Header().Set("X-Accel-Buffering", "no") // Stop nginx holding chunks back
+			response.WriteHeader(http.StatusOK)
+			streamOpen = true
+		}
+		if _, err := fmt.Fprintf(response, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+
+	summary, err := h.summaryService.StreamBookSummary(request.Context(), userID, bookID, kind, summaryRegenerate(request), func(text string) error {
+		return sendEvent("chunk", map[string]string{"text": text})
+	})
+	if err != nil {
+		if request.Context().Err() != nil {
+			h.logger.Info("Summary stream closed by client", "bookID", bookID, "kind", kind)
+			return
+		}
+		h.logger.Error("Error streaming book summary", "bookID", bookID, "kind", kind, "error", err)
+		if !streamOpen {
+			h.writeBookSummaryError(response, err)
+			return
+		}
+		_, message := bookSummaryErrorStatus(err)
+		sendEvent("error", map[string]string{"error": message})
+		return
+	}
+
+	sendEvent("done", summary)
+}
+
+type updateBookSummaryRequest struct {
+	Text string `json:"text"`
+}
+
+// HandleUpdateBookSummary replaces the text of a saved summary with the user's own edit, ?kind= as for HandleGetBookSummary
+func (h *BookHandlers) HandleUpdateBookSummary(response http.ResponseWriter, request *http.Request) {
+	_, bookID, err := h.ValidateBookOwnership(request)
+	if err != nil {
+		h.logger.Error("Book ownership validation failed", "error", err)
+		http.Error(response, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	kind, err := services.ParseSummaryKind(request.URL.Query().Get("kind"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var updateRequest updateBookSummaryRequest
+	if err := json.NewDecoder(request.Body).Decode(&updateRequest); err != nil {
+		http.Error(response, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.summaryService.EditBookSummary(request.Context(), bookID, kind, updateRequest.Text)
+	if err != nil {
+		h.logger.Error("Error updating book summary", "bookID", bookID, "kind", kind, "error", err)
+		h.writeBookSummaryError(response, err)
+		return
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: summary})
+}
+
+// HandleAcceptBookSummary appends a saved summary to the book's notes, ?kind= as for HandleGetBookSummary
+func (h *BookHandlers) HandleAcceptBookSummary(response http.ResponseWriter, request *http.Request) {
+	userID, bookID, err := h.ValidateBookOwnership(request)
+	if err != nil {
+		h.logger.Error("Book ownership validation failed", "error", err)
+		http.Error(response, "Unauthorized access", http.StatusUnauthorized)
+		return
+	}
+
+	kind, err := services.ParseSummaryKind(request.URL.Query().Get("kind"))
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.summaryService.AcceptBookSummary(request.Context(), userID, bookID, kind)
+	if err != nil {
+		h.logger.Error("Error accepting book summary", "bookID", bookID, "kind", kind, "error", err)
+		h.writeBookSummaryError(response, err)
+		return
+	}
+
+	// The notes changed, drop the cached book as well as the user's lists
+	h.invalidateBookCaches(request.Context(), bookID, userID)
+	if err := h.bookCacheService.InvalidateCache(request.Context(), userID, bookID); err != nil {
+		h.logger.Error("Error invalidating book detail cache", "bookID", bookID, "error", err)
+	}
+
+	h.sendJSONResponse(response, JSONResponse{Data: summary})
+}
+
+// Helper fn: saved summaries shown alongside a book, a failure here shouldn't fail the book itself
+func (h *BookHandlers) listBookSummaries(ctx context.Context, bookID int) []repository.BookSummary {
+	summaries, err := h.summaryService.ListBookSummaries(ctx, bookID)
+	if err != nil {
+		h.logger.Error("Error fetching book summaries", "bookID", bookID, "error", err)
+		return []repository.BookSummary{}
+	}
+	return summaries
+}
+
+func summaryRegenerate(request *http.Request) bool {
+	regenerate, _ := strconv.ParseBool(request.URL.Query().Get("regenerate"))
+	return regenerate
+}
+
+func bookSummaryErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, repository.ErrBookSummaryNotFound):
+		return http.StatusNotFound, "Summary not found"
+	case errors.Is(err, services.ErrSummaryNotConfigured):
+		return http.StatusServiceUnavailable, "Book summaries are not configured"
+	case errors.Is(err, services.ErrAIQuotaExceeded):
+		return http.StatusTooManyRequests, "Daily AI usage quota exceeded"
+	case errors.Is(err, services.ErrInvalidBookSummary):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrSummaryUnavailable), errors.Is(err, services.ErrSummaryEmpty):
+		return http.StatusBadGateway, "Error generating summary"
+	default:
+		return http.StatusInternalServerError, "Error processing summary"
+	}
+}
+
+func (h *BookHandlers) writeBookSummaryError(response http.ResponseWriter, err error) {
+	var quotaErr *services.AIQuotaExceededError
+	if errors.As(err, &quotaErr) {
+		h.writeAIQuotaExceeded(response, quotaErr)
+		return
+	}
+
+	status, message := bookSummaryErrorStatus(err)
+	http.Error(response, message, status)
+}
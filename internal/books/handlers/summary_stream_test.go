@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lokeam/bravo-kilo/config"
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
+	"github.com/lokeam/bravo-kilo/internal/shared/jwt"
+	"github.com/lokeam/bravo-kilo/internal/shared/types"
+)
+
+type fakeSummaryOwnerRepo struct {
+	repository.BookRepository
+}
+
+func (r *fakeSummaryOwnerRepo) IsUserBookOwner(userID, bookID int) (bool, error) {
+	return true, nil
+}
+
+// Sends its chunks, then fails with err or returns the summary
+type fakeStreamSummaryService struct {
+	services.SummaryService
+	chunks []string
+	err    error
+}
+
+func (s *fakeStreamSummaryService) StreamBookSummary(ctx context.Context, userID int, bookID int, kind services.SummaryKind, regenerate bool, onChunk func(text string) error) (*repository.BookSummary, error) {
+	for _, chunk := range s.chunks {
+		if err := onChunk(chunk); err != nil {
+			return nil, err
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &repository.BookSummary{BookID: bookID, Kind: string(kind), Text: "Dana travels back in time.", Provider: "fake"}, nil
+}
+
+// Helper fn: a request for book 9 from a signed-in user, signed with a throwaway key
+func newTestSummaryStreamRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	jwt.InitLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	previousKey := config.AppConfig.JWTPublicKey
+	config.AppConfig.JWTPublicKey = &key.PublicKey
+	t.Cleanup(func() { config.AppConfig.JWTPublicKey = previousKey })
+
+	token, err := jwt.SignToken(&types.Claims{UserID: 1}, key)
+	if err != nil {
+		t.Fatalf("unexpected error signing token: %v", err)
+	}
+
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("bookID", "9")
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/books/9/summary/stream?kind=synopsis", nil)
+	request.AddCookie(&http.Cookie{Name: "token", Value: token})
+	return request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, routeContext))
+}
+
+func TestHandleStreamBookSummary(t *testing.T) {
+	tests := []struct {
+		name            string
+		service         *fakeStreamSummaryService
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "chunks then the saved summary",
+			service:         &fakeStreamSummaryService{chunks: []string{"Dana travels ", "back in time."}},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/event-stream",
+			wantBody: "event: chunk\ndata: {\"text\":\"Dana travels \"}\n\n" +
+				"event: chunk\ndata: {\"text\":\"back in time.\"}\n\n" +
+				"event: done\ndata: {\"id\":0,\"bookId\":9,\"kind\":\"synopsis\",\"text\":\"Dana travels back in time.\",\"provider\":\"fake\",\"model\":\"\",\"edited\":false,\"createdAt\":\"0001-01-01T00:00:00Z\",\"updatedAt\":\"0001-01-01T00:00:00Z\"}\n\n",
+		},
+		{
+			name:            "failure before the first chunk keeps its status code",
+			service:         &fakeStreamSummaryService{err: services.ErrSummaryUnavailable},
+			wantStatus:      http.StatusBadGateway,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "Error generating summary\n",
+		},
+		{
+			name:            "failure mid-stream is sent as an event",
+			service:         &fakeStreamSummaryService{chunks: []string{"Dana "}, err: errors.New("connection reset")},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/event-stream",
+			wantBody: "event: chunk\ndata: {\"text\":\"Dana \"}\n\n" +
+				"event: error\ndata: {\"error\":\"Error processing summary\"}\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &BookHandlers{
+				logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+				bookRepo:       &fakeSummaryOwnerRepo{},
+				summaryService: tt.service,
+			}
+
+			recorder := httptest.NewRecorder()
+			h.HandleStreamBookSummary(recorder, newTestSummaryStreamRequest(t))
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, recorder.Code, recorder.Body.String())
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != tt.wantContentType {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantContentType, contentType)
+			}
+			if recorder.Body.String() != tt.wantBody {
+				t.Errorf("unexpected body\nwant %q\n got %q", tt.wantBody, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -1,20 +1,28 @@
 package handlers
 
 import (
-	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/lokeam/bravo-kilo/cmd/middleware"
+	"github.com/lokeam/bravo-kilo/internal/books/services"
 	"github.com/lokeam/bravo-kilo/internal/shared/utils"
 )
 
+const (
+	maxImportFileSize   = 10 << 20                  // Uploads are stored on the import job, so they're kept small
+	maxImportUploadSize = maxImportFileSize + 1<<20 // Whole request body, room for the multipart framing and mapping
+)
+
 // File handling
 func (h *BookHandlers) UploadCSV(response http.ResponseWriter, request *http.Request) {
 	// Check auth
@@ -25,7 +33,8 @@ func (h *BookHandlers) UploadCSV(response http.ResponseWriter, request *http.Req
 	}
 
 	// Parse multipart form, cap size@10MB
-	err := request.ParseMultipartForm(10 << 20)
+	request.Body = http.MaxBytesReader(response, request.Body, maxImportUploadSize)
+	err := request.ParseMultipartForm(maxImportFileSize)
 	if err != nil {
 		http.Error(response, "File too large", http.StatusBadRequest)
 		return
@@ -39,20 +48,43 @@ func (h *BookHandlers) UploadCSV(response http.ResponseWriter, request *http.Req
 	}
 	defer file.Close()
 
+	// Optional header mapping, validated now so a bad mapping fails the upload rather than the job
+	var columnMapping json.RawMessage
+	var importOpts services.ImportOptions
+	if rawMapping := request.FormValue("mapping"); rawMapping != "" {
+		mapping, err := services.ParseImportColumnMapping([]byte(rawMapping))
+		if err != nil {
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+		columnMapping = json.RawMessage(rawMapping)
+		importOpts.Mapping = mapping
+	}
+
+	dryRun := false
+	if rawDryRun := request.URL.Query().Get("dryRun"); rawDryRun != "" {
+		dryRun, err = strconv.ParseBool(rawDryRun)
+		if err != nil {
+			http.Error(response, "Invalid dryRun parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Validate Content-Type header
 	contentType := fileHeader.Header.Get("Content-Type")
-	if contentType != "text/csv" {
+	if !isAllowedImportContentType(contentType) {
 		http.Error(response, "Invalid content type", http.StatusBadRequest)
 		return
 	}
 
 	// Validate file type using magic numbers
 	buf := make([]byte, 512)
-	if _, err := file.Read(buf); err != nil {
+	bytesRead, err := file.Read(buf)
+	if err != nil {
 		http.Error(response, "Error reading file", http.StatusInternalServerError)
 		return
 	}
-	if !isCSV(buf) {
+	if !isCSV(buf[:bytesRead]) {
 		http.Error(response, "Invalid file type", http.StatusBadRequest)
 		return
 	}
@@ -60,54 +92,73 @@ func (h *BookHandlers) UploadCSV(response http.ResponseWriter, request *http.Req
 	// Reset file reader
 	file.Seek(0, 0)
 
-	// Sanitize and store file
-	safeFileName := fmt.Sprintf("%d_%s", userID, sanitizeFileName(fileHeader.Filename))
+	// Previews run synchronously against the upload and are never queued or saved
+	if dryRun {
+		importOpts.DryRun = true
+		report, err := h.importService.ImportBooksCSV(request.Context(), userID, file, importOpts, nil)
+		if errors.Is(err, services.ErrImportNoTitleColumn) {
+			http.Error(response, "No column in the header row maps to title", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			h.logger.Error("Import preview failed", "userID", userID, "error", err)
+			http.Error(response, "Unable to preview import", http.StatusInternalServerError)
+			return
+		}
 
-	// Only upload to secure directory
-	uploadDir := os.Getenv("UPLOAD_DIR")
-	if uploadDir == "" {
-		http.Error(response, "Upload directory is not configured", http.StatusInternalServerError)
+		h.sendJSONResponse(response, JSONResponse{
+			Data:       report,
+			StatusCode: http.StatusOK,
+		})
 		return
 	}
 
-	destination := filepath.Join(uploadDir, safeFileName)
-
-	// Ensure destination directory exists
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		http.Error(response, "Unable to create directory", http.StatusInternalServerError)
+	// Stored on the job rather than on disk, the worker that claims it may run on another instance
+	fileData, err := io.ReadAll(io.LimitReader(file, maxImportFileSize+1))
+	if err != nil {
+		http.Error(response, "Error reading file", http.StatusInternalServerError)
 		return
 	}
-
-	// Ensure file is not saved outside the intended directory
-	if !strings.HasPrefix(destination, uploadDir) {
-		http.Error(response, "Invalid file path", http.StatusBadGateway)
+	if len(fileData) > maxImportFileSize {
+		http.Error(response, "File too large", http.StatusBadRequest)
 		return
 	}
 
-	// Create or truncate file at path if its not already there
-	outFile, err := os.Create(destination)
+	// Queue import job, rows are processed in the background
+	job, err := h.importJobRepo.CreateJob(request.Context(), userID, fileData, columnMapping)
 	if err != nil {
-		http.Error(response, "Unable to save file", http.StatusInternalServerError)
+		h.logger.Error("Unable to create import job", "userID", userID, "error", err)
+		http.Error(response, "Unable to queue import", http.StatusInternalServerError)
 		return
 	}
-	defer outFile.Close()
 
-	// Copy content
-	if _, err := io.Copy(outFile, file); err != nil {
-		http.Error(response, "Unable to save file", http.StatusInternalServerError)
-		return
-	}
+	h.logger.Info("Import job queued", "userID", userID, "jobID", job.ID, "filename", sanitizeFileName(fileHeader.Filename), "bytes", len(fileData))
+	h.sendJSONResponse(response, JSONResponse{
+		Data:       job,
+		StatusCode: http.StatusAccepted,
+	})
+}
 
-	// Log upload event
-	h.logger.Info("File uploaded successfully", "userID", userID, "filename", safeFileName)
+// Goodreads exports CSV, LibraryThing exports TSV, Windows browsers label CSV as Excel
+var allowedImportContentTypes = map[string]bool{
+	"text/csv":                  true,
+	"text/tab-separated-values": true,
+	"text/plain":                true,
+	"application/vnd.ms-excel":  true,
+}
 
-	// Call ParseAndProcessCSV, update request body to pass file path
-	request.Body = io.NopCloser(strings.NewReader(destination))
-	h.ParseAndProcessCSV(destination, response)
+func isAllowedImportContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return allowedImportContentTypes[mediaType]
 }
 
+// Content sniffing reports CSV as plain text
 func isCSV(data []byte) bool {
-	return http.DetectContentType(data) == "text/csv"
+	contentType := http.DetectContentType(data)
+	return strings.HasPrefix(contentType, "text/plain") || strings.HasPrefix(contentType, "text/csv")
 }
 
 func sanitizeFileName(filename string) string {
@@ -125,80 +176,6 @@ func sanitizeFileName(filename string) string {
 	return sanitized
 }
 
-// ParseAndProcessCSV
-func (h *BookHandlers) ParseAndProcessCSV(filePath string, response http.ResponseWriter) {
-
-	// Open the uploaded file
-	file, err := os.Open(filePath)
-	if err != nil {
-		h.logger.Error("Unable to open file", "error", err)
-		http.Error(response, "Unable to open file", http.StatusInternalServerError)
-		return
-	}
-	defer file.Close()
-
-	// Create csv reader
-	reader := csv.NewReader(file)
-	maxLength := 250
-	expectedColumms := 14
-
-	// Process each record in file
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			h.logger.Error("Error reading file", "error", err)
-			http.Error(response, "Error reading file", http.StatusInternalServerError)
-		}
-
-		// Validate number of columns
-		if len(record) != expectedColumms {
-			h.logger.Error("Incorrect number of columns in record", "record", record);
-			http.Error(response, "Invalid CSV format: incorrect number of columns", http.StatusBadRequest)
-			return
-		}
-
-		// Sanitize, truncate, validate each field
-		for i, field := range record {
-			// Protect against CSV injection
-			field = utils.ProtectAgainstCSVInjection(field)
-
-			// Image url check here at designated column
-			// if i == imageURLColumnIndex { // replace with the actual index of the image URL column
-			// 	if err := h.ParseImageURL(field, allowedDomains); err != nil {
-			// 		h.logger.Error("Invalid URL", "error", err)
-			// 		http.Error(response, fmt.Sprintf("Error in record %d: %v", i, err), http.StatusBadRequest)
-			// 		return
-			// 	}
-			// }
-
-			sanitizedField := utils.SanitizeChars(field)
-			truncatedField := utils.TruncateField(sanitizedField, maxLength)
-
-			if err := utils.ValidateFieldLength(truncatedField, maxLength); err != nil {
-				h.logger.Error("Error in record %d: %v", "record", err)
-				http.Error(response, fmt.Sprintf("Error in record %d: %v", i, err), http.StatusBadRequest)
-				return
-			}
-			record[i] = truncatedField
-		}
-
-		// Todo: create handler to validate + insert record into db
-	}
-
-	// Delete file after successful processing
-	if err := os.Remove(filePath); err != nil {
-		h.logger.Error("Failed to delete after processing", "filePath", filePath)
-	}
-
-	h.logger.Info("File processed and deleted successfully", "filePath", filePath)
-	response.WriteHeader(http.StatusOK)
-	json.NewEncoder(response).Encode(map[string]string{"message": "File processed successfully"})
-}
-
-
 func (h *BookHandlers) ParseImageURL(urlStr string, allowedDomains []string) error {
 	// Parse the URL
 	parsedURL, err := url.Parse(urlStr)
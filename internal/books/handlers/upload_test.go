@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/core"
+)
+
+type fakeUploadImportJobRepo struct {
+	repository.ImportJobRepository
+	fileData      []byte
+	columnMapping json.RawMessage
+	created       int
+}
+
+func (r *fakeUploadImportJobRepo) CreateJob(ctx context.Context, userID int, fileData []byte, columnMapping json.RawMessage) (*repository.ImportJob, error) {
+	r.created++
+	r.fileData = fileData
+	r.columnMapping = columnMapping
+	return &repository.ImportJob{ID: "job-1", UserID: userID, Status: repository.ImportJobPending}, nil
+}
+
+func newTestUploadRequest(t *testing.T, contents io.Reader, mapping string) *http.Request {
+	t.Helper()
+
+	var header bytes.Buffer
+	form := multipart.NewWriter(&header)
+	if mapping != "" {
+		form.WriteField("mapping", mapping)
+	}
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", `form-data; name="file"; filename="../library.csv"`)
+	partHeader.Set("Content-Type", "text/csv")
+	form.CreatePart(partHeader)
+
+	body := io.MultiReader(&header, contents, strings.NewReader("\r\n--"+form.Boundary()+"--\r\n"))
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/upload", body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	return request.WithContext(context.WithValue(request.Context(), core.UserIDKey, 1))
+}
+
+func TestUploadCSVStoresFileOnImportJob(t *testing.T) {
+	// Nothing may be written to the local disk, the worker claiming the job can be on another instance
+	uploadDir := t.TempDir()
+	t.Setenv("UPLOAD_DIR", uploadDir)
+
+	jobRepo := &fakeUploadImportJobRepo{}
+	h := &BookHandlers{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), importJobRepo: jobRepo}
+
+	csv := "Name,Authors\nKindred,Octavia E. Butler\n"
+	recorder := httptest.NewRecorder()
+	h.UploadCSV(recorder, newTestUploadRequest(t, strings.NewReader(csv), `{"columns":{"Name":"title"}}`))
+
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if string(jobRepo.fileData) != csv {
+		t.Errorf("expected the upload stored on the job, got %q", jobRepo.fileData)
+	}
+	if string(jobRepo.columnMapping) != `{"columns":{"Name":"title"}}` {
+		t.Errorf("expected the column mapping stored on the job, got %s", jobRepo.columnMapping)
+	}
+	if entries, _ := os.ReadDir(uploadDir); len(entries) != 0 {
+		t.Errorf("expected nothing written to disk, found %d entries", len(entries))
+	}
+}
+
+func TestUploadCSVRejectsOversizedFile(t *testing.T) {
+	jobRepo := &fakeUploadImportJobRepo{}
+	h := &BookHandlers{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), importJobRepo: jobRepo}
+
+	rows := strings.Repeat("Kindred,Octavia E. Butler\n", maxImportFileSize/26+1)
+	recorder := httptest.NewRecorder()
+	h.UploadCSV(recorder, newTestUploadRequest(t, strings.NewReader("Title,Authors\n"+rows), ""))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", recorder.Code)
+	}
+	if jobRepo.created != 0 {
+		t.Errorf("expected no job for an oversized upload")
+	}
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/dbconfig"
+)
+
+var ErrAIUsageLimitReached = errors.New("daily AI usage limit reached")
+
+// AIUsage is one user's AI spend for one UTC day
+type AIUsage struct {
+	UserID       int       `json:"userId"`
+	Day          time.Time `json:"day"`
+	Requests     int       `json:"requests"`
+	PromptTokens int       `json:"promptTokens"`
+	OutputTokens int       `json:"outputTokens"`
+}
+
+type AIUsageRepository interface {
+	// GetDailyUsage returns zero usage for a day with no requests yet
+	GetDailyUsage(ctx context.Context, userID int, day time.Time) (*AIUsage, error)
+	// ReserveDailyRequest counts one more request for the day, unless the user already reached either limit
+	// (zero means no limit), in which case it returns ErrAIUsageLimitReached
+	ReserveDailyRequest(ctx context.Context, userID int, day time.Time, requestLimit int, tokenLimit int) (*AIUsage, error)
+	// AddDailyTokens adds the tokens a reserved request used
+	AddDailyTokens(ctx context.Context, userID int, day time.Time, promptTokens int, outputTokens int) error
+}
+
+type AIUsageRepositoryImpl struct {
+	DB     *sql.DB
+	Logger *slog.Logger
+}
+
+func NewAIUsageRepository(db *sql.DB, logger *slog.Logger) (AIUsageRepository, error) {
+	if db == nil || logger == nil {
+		return nil, fmt.Errorf("ai usage repository, database or logger is nil")
+	}
+
+	return &AIUsageRepositoryImpl{
+		DB:     db,
+		Logger: logger,
+	}, nil
+}
+
+func (r *AIUsageRepositoryImpl) GetDailyUsage(ctx context.Context, userID int, day time.Time) (*AIUsage, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		SELECT requests, prompt_tokens, output_tokens
+		FROM ai_usage
+		WHERE user_id = $1 AND usage_date = $2`
+
+	usage := &AIUsage{UserID: userID, Day: day}
+	err := r.DB.QueryRowContext(ctx, query, userID, day.Format(time.DateOnly)).Scan(&usage.Requests, &usage.PromptTokens, &usage.OutputTokens)
+	if err != nil && err != sql.ErrNoRows {
+		r.Logger.Error("Error fetching AI usage", "error", err, "userID", userID)
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// The limit check and the increment are one statement, so concurrent requests can't all pass on the last
+// request left. Conflicting upserts wait on the row lock and re-check against the row's latest values
+func (r *AIUsageRepositoryImpl) ReserveDailyRequest(ctx context.Context, userID int, day time.Time, requestLimit int, tokenLimit int) (*AIUsage, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO ai_usage (user_id, usage_date, requests)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (user_id, usage_date) DO UPDATE
+		SET requests = ai_usage.requests + 1, updated_at = NOW()
+		WHERE ($3 = 0 OR ai_usage.requests < $3)
+			AND ($4 = 0 OR ai_usage.prompt_tokens + ai_usage.output_tokens < $4)
+		RETURNING requests, prompt_tokens, output_tokens`
+
+	usage := &AIUsage{UserID: userID, Day: day}
+	err := r.DB.QueryRowContext(ctx, query, userID, day.Format(time.DateOnly), requestLimit, tokenLimit).
+		Scan(&usage.Requests, &usage.PromptTokens, &usage.OutputTokens)
+	if err == sql.ErrNoRows {
+		return nil, ErrAIUsageLimitReached
+	}
+	if err != nil {
+		r.Logger.Error("Error reserving AI request", "error", err, "userID", userID)
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func (r *AIUsageRepositoryImpl) AddDailyTokens(ctx context.Context, userID int, day time.Time, promptTokens int, outputTokens int) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO ai_usage (user_id, usage_date, prompt_tokens, output_tokens)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, usage_date) DO UPDATE
+		SET prompt_tokens = ai_usage.prompt_tokens + EXCLUDED.prompt_tokens,
+			output_tokens = ai_usage.output_tokens + EXCLUDED.output_tokens,
+			updated_at = NOW()`
+
+	if _, err := r.DB.ExecContext(ctx, query, userID, day.Format(time.DateOnly), promptTokens, outputTokens); err != nil {
+		r.Logger.Error("Error recording AI usage", "error", err, "userID", userID)
+		return err
+	}
+
+	return nil
+}
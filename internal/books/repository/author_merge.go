@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/lokeam/bravo-kilo/internal/dbconfig"
+)
+
+var ErrAuthorNotFound = errors.New("author not found")
+
+// AuthorSummary is an author row with the number of books credited to it, across every user
+type AuthorSummary struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	BookCount int    `json:"bookCount"`
+}
+
+// ListAuthorSummaries returns every author that is credited on at least one book
+func (r *AuthorRepositoryImpl) ListAuthorSummaries(ctx context.Context) ([]AuthorSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+	SELECT a.id, a.name, COUNT(DISTINCT ba.book_id)
+	FROM authors a
+	INNER JOIN book_authors ba ON a.id = ba.author_id
+	GROUP BY a.id, a.name
+	ORDER BY a.id`
+
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		r.Logger.Error("Error listing authors", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuthorSummaries(rows)
+}
+
+// GetAuthorSummaries returns the requested authors, ErrAuthorNotFound if any of them doesn't exist.
+// Rows are locked until tx ends, so a concurrent merge can't delete them underneath the caller
+func (r *AuthorRepositoryImpl) GetAuthorSummaries(ctx context.Context, tx *sql.Tx, authorIDs []int) ([]AuthorSummary, error) {
+	query := `
+	SELECT a.id, a.name, (SELECT COUNT(DISTINCT ba.book_id) FROM book_authors ba WHERE ba.author_id = a.id)
+	FROM authors a
+	WHERE a.id = ANY($1)
+	ORDER BY a.id
+	FOR UPDATE OF a`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(authorIDs))
+	if err != nil {
+		r.Logger.Error("Error fetching authors", "error", err, "authorIDs", authorIDs)
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors, err := scanAuthorSummaries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[int]bool, len(authors))
+	for _, author := range authors {
+		found[author.ID] = true
+	}
+	for _, authorID := range authorIDs {
+		if !found[authorID] {
+			return nil, fmt.Errorf("%w: %d", ErrAuthorNotFound, authorID)
+		}
+	}
+
+	return authors, nil
+}
+
+// GetAuthorBookOwners returns the users who have a book credited to any of the authors
+func (r *AuthorRepositoryImpl) GetAuthorBookOwners(ctx context.Context, tx *sql.Tx, authorIDs []int) ([]int, error) {
+	query := `
+	SELECT COALESCE(ARRAY_AGG(DISTINCT ub.user_id), '{}')
+	FROM book_authors ba
+	INNER JOIN user_books ub ON ba.book_id = ub.book_id
+	WHERE ba.author_id = ANY($1)`
+
+	var ownerIDs []int64
+	if err := tx.QueryRowContext(ctx, query, pq.Array(authorIDs)).Scan(pq.Array(&ownerIDs)); err != nil {
+		r.Logger.Error("Error fetching author book owners", "error", err, "authorIDs", authorIDs)
+		return nil, err
+	}
+
+	userIDs := make([]int, len(ownerIDs))
+	for i, ownerID := range ownerIDs {
+		userIDs[i] = int(ownerID)
+	}
+	return userIDs, nil
+}
+
+// MergeAuthors moves every book from sourceID to targetID and deletes sourceID.
+// Books credited to both keep a single credit
+func (r *AuthorRepositoryImpl) MergeAuthors(ctx context.Context, tx *sql.Tx, sourceID, targetID int) error {
+	if sourceID == targetID {
+		return fmt.Errorf("cannot merge author %d into itself", sourceID)
+	}
+
+	// Insert + delete rather than UPDATE, the search document triggers fire on book_authors inserts and deletes
+	statements := []string{
+		`INSERT INTO book_authors (book_id, author_id)
+		SELECT ba.book_id, $2 FROM book_authors ba
+		WHERE ba.author_id = $1
+			AND NOT EXISTS (SELECT 1 FROM book_authors existing WHERE existing.book_id = ba.book_id AND existing.author_id = $2)`,
+		`DELETE FROM book_authors WHERE author_id = $1`,
+		`DELETE FROM authors WHERE id = $1`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement, sourceID, targetID); err != nil {
+			r.Logger.Error("Error merging authors", "error", err, "sourceID", sourceID, "targetID", targetID)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanAuthorSummaries(rows *sql.Rows) ([]AuthorSummary, error) {
+	authors := []AuthorSummary{}
+	for rows.Next() {
+		var author AuthorSummary
+		if err := rows.Scan(&author.ID, &author.Name, &author.BookCount); err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+	return authors, rows.Err()
+}
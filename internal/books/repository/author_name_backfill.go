@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lokeam/bravo-kilo/internal/dbconfig"
+)
+
+const authorNameBackfillBatchSize = 500
+
+// AuthorNameBackfillReport summarizes one pass of the global rules over the authors table
+type AuthorNameBackfillReport struct {
+	DryRun          bool               `json:"dryRun"`
+	Scanned         int                `json:"scanned"`
+	Renamed         int                `json:"renamed"`
+	Merged          int                `json:"merged"`
+	Changes         []AuthorNameChange `json:"changes"`
+	AffectedUserIDs []int              `json:"-"` // Users whose cached author lists are now stale
+}
+
+type AuthorNameChange struct {
+	AuthorID   int    `json:"authorId"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	MergedInto int    `json:"mergedInto,omitempty"`
+}
+
+// BackfillAuthorNames rewrites existing authors with the global rules. Authors are shared between users, so a
+// user's own rules never apply here. When the new name already exists the two authors are merged.
+// Each author is changed in its own transaction, a failure part way leaves earlier changes in place and
+// returns the report so far with the error. A dry run can't see merges between two authors that only
+// collide after both are renamed
+func (r *AuthorRepositoryImpl) BackfillAuthorNames(ctx context.Context, dryRun bool) (*AuthorNameBackfillReport, error) {
+	normalizer, err := r.nameRules.GetNormalizer(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AuthorNameBackfillReport{DryRun: dryRun, Changes: []AuthorNameChange{}}
+	affectedUsers := make(map[int]bool)
+
+	// Earlier renames stay applied on failure, so their users' caches still need dropping
+	finish := func() {
+		if dryRun {
+			return
+		}
+		for userID := range affectedUsers {
+			report.AffectedUserIDs = append(report.AffectedUserIDs, userID)
+		}
+	}
+
+	lastID := 0
+	for {
+		batch, err := r.authorsAfter(ctx, lastID)
+		if err != nil {
+			finish()
+			return report, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, author := range batch {
+			lastID = author.AuthorID
+			report.Scanned++
+
+			normalized := normalizer.Normalize(author.From)
+			if normalized == author.From || normalized == "" {
+				continue
+			}
+
+			change := AuthorNameChange{AuthorID: author.AuthorID, From: author.From, To: normalized}
+			userIDs, err := r.renameAuthor(ctx, &change, dryRun)
+			if err != nil {
+				r.Logger.Error("Error rewriting author name", "error", err, "authorID", author.AuthorID, "from", author.From, "to", normalized)
+				finish()
+				return report, fmt.Errorf("error rewriting author %d: %w", author.AuthorID, err)
+			}
+
+			if change.MergedInto > 0 {
+				report.Merged++
+			} else {
+				report.Renamed++
+			}
+			report.Changes = append(report.Changes, change)
+			for _, userID := range userIDs {
+				affectedUsers[userID] = true
+			}
+		}
+	}
+
+	finish()
+
+	r.Logger.Info("Author name backfill finished",
+		"dryRun", dryRun,
+		"scanned", report.Scanned,
+		"renamed", report.Renamed,
+		"merged", report.Merged,
+	)
+	return report, nil
+}
+
+// Helper fn: next batch of authors by ID, keyset paged so renames don't shift the window
+func (r *AuthorRepositoryImpl) authorsAfter(ctx context.Context, lastID int) ([]AuthorNameChange, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(queryCtx, `SELECT id, name FROM authors WHERE id > $1 ORDER BY id LIMIT $2`, lastID, authorNameBackfillBatchSize)
+	if err != nil {
+		r.Logger.Error("Error fetching authors for backfill", "error", err, "afterID", lastID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var authors []AuthorNameChange
+	for rows.Next() {
+		var author AuthorNameChange
+		if err := rows.Scan(&author.AuthorID, &author.From); err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+	return authors, rows.Err()
+}
+
+// Helper fn: rename one author, or merge it into the author that already has the new name.
+// Returns the users who own the author's books
+func (r *AuthorRepositoryImpl) renameAuthor(ctx context.Context, change *AuthorNameChange, dryRun bool) ([]int, error) {
+	txCtx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(txCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	userIDs, err := r.GetAuthorBookOwners(txCtx, tx, []int{change.AuthorID})
+	if err != nil {
+		return nil, err
+	}
+
+	var targetID int
+	err = tx.QueryRowContext(txCtx, `SELECT id FROM authors WHERE name = $1 AND id <> $2 ORDER BY id LIMIT 1`, change.To, change.AuthorID).Scan(&targetID)
+	switch {
+	case err == sql.ErrNoRows:
+		if !dryRun {
+			_, err = tx.ExecContext(txCtx, `UPDATE authors SET name = $1 WHERE id = $2`, change.To, change.AuthorID)
+		} else {
+			err = nil
+		}
+	case err == nil:
+		change.MergedInto = targetID
+		if !dryRun {
+			err = r.MergeAuthors(txCtx, tx, change.AuthorID, targetID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return userIDs, nil
+	}
+	return userIDs, tx.Commit()
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/dbconfig"
+)
+
+var ErrAuthorNameBackfillJobNotFound = errors.New("author name backfill job not found")
+
+type AuthorNameBackfillJobStatus string
+
+const (
+	AuthorNameBackfillPending   AuthorNameBackfillJobStatus = "pending"
+	AuthorNameBackfillRunning   AuthorNameBackfillJobStatus = "running"
+	AuthorNameBackfillCompleted AuthorNameBackfillJobStatus = "completed"
+	AuthorNameBackfillFailed    AuthorNameBackfillJobStatus = "failed"
+)
+
+type AuthorNameBackfillJob struct {
+	ID            int                         `json:"id"`
+	DryRun        bool                        `json:"dryRun"`
+	Status        AuthorNameBackfillJobStatus `json:"status"`
+	Report        *AuthorNameBackfillReport   `json:"report,omitempty"`
+	FailureReason string                      `json:"failureReason,omitempty"`
+	CreatedAt     time.Time                   `json:"createdAt"`
+	StartedAt     *time.Time                  `json:"startedAt,omitempty"`
+	CompletedAt   *time.Time                  `json:"completedAt,omitempty"`
+}
+
+type AuthorNameBackfillJobRepository interface {
+	CreateJob(ctx context.Context, requestedBy int, dryRun bool) (*AuthorNameBackfillJob, bool, error)
+	GetJobByID(ctx context.Context, jobID int) (*AuthorNameBackfillJob, error)
+	ClaimNextPendingJob(ctx context.Context) (*AuthorNameBackfillJob, error)
+	FinishJob(ctx context.Context, job *AuthorNameBackfillJob) error
+	Heartbeat(ctx context.Context, jobID int) error
+	FailInterruptedJobs(ctx context.Context, staleAfter time.Duration) (int64, error)
+}
+
+type AuthorNameBackfillJobRepositoryImpl struct {
+	DB     *sql.DB
+	Logger *slog.Logger
+}
+
+func NewAuthorNameBackfillJobRepository(db *sql.DB, logger *slog.Logger) (AuthorNameBackfillJobRepository, error) {
+	if db == nil || logger == nil {
+		return nil, fmt.Errorf("author name backfill job repository, database or logger is nil")
+	}
+
+	return &AuthorNameBackfillJobRepositoryImpl{
+		DB:     db,
+		Logger: logger,
+	}, nil
+}
+
+const authorNameBackfillJobColumns = `
+	id, dry_run, status, report, failure_reason, created_at, started_at, completed_at`
+
+// CreateJob queues a backfill, or returns the one already queued or running. Authors are shared, so two
+// backfills never run side by side. The bool reports whether a new job was created
+func (r *AuthorNameBackfillJobRepositoryImpl) CreateJob(ctx context.Context, requestedBy int, dryRun bool) (*AuthorNameBackfillJob, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `SELECT` + authorNameBackfillJobColumns + ` FROM author_name_backfill_jobs
+		WHERE status IN ($1, $2)
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	job, err := scanAuthorNameBackfillJob(r.DB.QueryRowContext(ctx, query, AuthorNameBackfillPending, AuthorNameBackfillRunning))
+	if err == nil {
+		return job, false, nil
+	}
+	if err != sql.ErrNoRows {
+		r.Logger.Error("Error checking for active author name backfill", "error", err)
+		return nil, false, err
+	}
+
+	query = `INSERT INTO author_name_backfill_jobs (requested_by, dry_run, status) VALUES ($1, $2, $3)
+		RETURNING` + authorNameBackfillJobColumns
+
+	job, err = scanAuthorNameBackfillJob(r.DB.QueryRowContext(ctx, query, requestedBy, dryRun, AuthorNameBackfillPending))
+	if err != nil {
+		r.Logger.Error("Error creating author name backfill job", "error", err, "requestedBy", requestedBy)
+		return nil, false, err
+	}
+
+	return job, true, nil
+}
+
+func (r *AuthorNameBackfillJobRepositoryImpl) GetJobByID(ctx context.Context, jobID int) (*AuthorNameBackfillJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `SELECT` + authorNameBackfillJobColumns + ` FROM author_name_backfill_jobs WHERE id = $1`
+
+	job, err := scanAuthorNameBackfillJob(r.DB.QueryRowContext(ctx, query, jobID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAuthorNameBackfillJobNotFound
+	}
+	if err != nil {
+		r.Logger.Error("Error fetching author name backfill job", "error", err, "jobID", jobID)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ClaimNextPendingJob marks the oldest pending backfill as running, returns nil when the queue is empty
+func (r *AuthorNameBackfillJobRepositoryImpl) ClaimNextPendingJob(ctx context.Context) (*AuthorNameBackfillJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE author_name_backfill_jobs
+		SET status = $1, started_at = NOW(), heartbeat_at = NOW()
+		WHERE id = (
+			SELECT id FROM author_name_backfill_jobs
+			WHERE status = $2
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING` + authorNameBackfillJobColumns
+
+	job, err := scanAuthorNameBackfillJob(r.DB.QueryRowContext(ctx, query, AuthorNameBackfillRunning, AuthorNameBackfillPending))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.Logger.Error("Error claiming author name backfill job", "error", err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *AuthorNameBackfillJobRepositoryImpl) FinishJob(ctx context.Context, job *AuthorNameBackfillJob) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	var report []byte
+	if job.Report != nil {
+		var err error
+		if report, err = json.Marshal(job.Report); err != nil {
+			return fmt.Errorf("error encoding backfill report: %w", err)
+		}
+	}
+
+	query := `
+		UPDATE author_name_backfill_jobs
+		SET status = $1, report = $2, failure_reason = $3, completed_at = NOW()
+		WHERE id = $4`
+
+	_, err := r.DB.ExecContext(ctx, query, job.Status, report, job.FailureReason, job.ID)
+	if err != nil {
+		r.Logger.Error("Error finishing author name backfill job", "error", err, "jobID", job.ID, "status", job.Status)
+		return err
+	}
+
+	return nil
+}
+
+// Heartbeat tells other instances the worker running the backfill is still alive
+func (r *AuthorNameBackfillJobRepositoryImpl) Heartbeat(ctx context.Context, jobID int) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `UPDATE author_name_backfill_jobs SET heartbeat_at = NOW() WHERE id = $1 AND status = $2`
+	if _, err := r.DB.ExecContext(ctx, query, jobID, AuthorNameBackfillRunning); err != nil {
+		r.Logger.Error("Error recording author name backfill heartbeat", "error", err, "jobID", jobID)
+		return err
+	}
+
+	return nil
+}
+
+// FailInterruptedJobs fails running backfills without a heartbeat for staleAfter
+func (r *AuthorNameBackfillJobRepositoryImpl) FailInterruptedJobs(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE author_name_backfill_jobs
+		SET status = $1, failure_reason = 'backfill interrupted by server restart', completed_at = NOW()
+		WHERE status = $2 AND COALESCE(heartbeat_at, started_at, created_at) < NOW() - $3 * INTERVAL '1 second'`
+
+	result, err := r.DB.ExecContext(ctx, query, AuthorNameBackfillFailed, AuthorNameBackfillRunning, staleAfter.Seconds())
+	if err != nil {
+		r.Logger.Error("Error failing interrupted author name backfills", "error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanAuthorNameBackfillJob(row *sql.Row) (*AuthorNameBackfillJob, error) {
+	var job AuthorNameBackfillJob
+	var report []byte
+	var startedAt, completedAt sql.NullTime
+
+	if err := row.Scan(
+		&job.ID,
+		&job.DryRun,
+		&job.Status,
+		&report,
+		&job.FailureReason,
+		&job.CreatedAt,
+		&startedAt,
+		&completedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(report) > 0 {
+		job.Report = &AuthorNameBackfillReport{}
+		if err := json.Unmarshal(report, job.Report); err != nil {
+			return nil, fmt.Errorf("error decoding backfill report: %w", err)
+		}
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return &job, nil
+}
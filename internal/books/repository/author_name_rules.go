@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/lokeam/bravo-kilo/internal/dbconfig"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrAuthorNameRuleNotFound = errors.New("author name rule not found")
+	ErrInvalidAuthorNameRule  = errors.New("invalid author name rule")
+)
+
+type AuthorNameRuleType string
+
+const (
+	AuthorNameRuleExact           AuthorNameRuleType = "exact"             // Pattern is replaced by Replacement, case-insensitive
+	AuthorNameRuleInvertLastFirst AuthorNameRuleType = "invert_last_first" // "Le Guin, Ursula K." becomes "Ursula K. Le Guin"
+	AuthorNameRuleSpaceInitials   AuthorNameRuleType = "space_initials"    // "J.R.R. Tolkien" becomes "J. R. R. Tolkien"
+	AuthorNameRuleFoldDiacritics  AuthorNameRuleType = "fold_diacritics"   // "Gabriel García Márquez" becomes "Gabriel Garcia Marquez"
+)
+
+// Name suffixes that follow a comma without the name being in "Last, First" order
+var authorNameSuffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true, "phd": true, "md": true,
+}
+
+var authorInitialsPattern = regexp.MustCompile(`\.(\p{Lu})`)
+
+// AuthorNameRule is one normalization rule, a nil UserID makes it global. Author rows are shared by every
+// user, so only global rules change what's stored. A user's rules change how names are shown to them
+type AuthorNameRule struct {
+	ID          int                `json:"id"`
+	UserID      *int               `json:"userId,omitempty"`
+	Type        AuthorNameRuleType `json:"type"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Replacement string             `json:"replacement,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt"`
+}
+
+// Validate checks the rule type and, for exact mappings, that both names are present
+func (r AuthorNameRule) Validate() error {
+	switch r.Type {
+	case AuthorNameRuleExact:
+		if strings.TrimSpace(r.Pattern) == "" || strings.TrimSpace(r.Replacement) == "" {
+			return fmt.Errorf("%w: exact rules need a pattern and a replacement", ErrInvalidAuthorNameRule)
+		}
+	case AuthorNameRuleInvertLastFirst, AuthorNameRuleSpaceInitials, AuthorNameRuleFoldDiacritics:
+		if r.Pattern != "" || r.Replacement != "" {
+			return fmt.Errorf("%w: %s rules take no pattern or replacement", ErrInvalidAuthorNameRule, r.Type)
+		}
+	default:
+		return fmt.Errorf("%w: unknown rule type %q", ErrInvalidAuthorNameRule, r.Type)
+	}
+	return nil
+}
+
+// AuthorNameNormalizer applies a rule set to author names. Whitespace is always collapsed, then the enabled
+// transforms run, and exact mappings are checked against both the raw and the transformed name.
+// A nil normalizer only collapses whitespace
+type AuthorNameNormalizer struct {
+	mappings        map[string]string // lowercased pattern -> replacement
+	invertLastFirst bool
+	spaceInitials   bool
+	foldDiacritics  bool
+}
+
+// NewAuthorNameNormalizer builds a normalizer from rules in priority order, later exact mappings win,
+// so pass global rules before a user's own
+func NewAuthorNameNormalizer(rules []AuthorNameRule) *AuthorNameNormalizer {
+	normalizer := &AuthorNameNormalizer{mappings: make(map[string]string)}
+
+	for _, rule := range rules {
+		switch rule.Type {
+		case AuthorNameRuleInvertLastFirst:
+			normalizer.invertLastFirst = true
+		case AuthorNameRuleSpaceInitials:
+			normalizer.spaceInitials = true
+		case AuthorNameRuleFoldDiacritics:
+			normalizer.foldDiacritics = true
+		}
+	}
+
+	// Replacements go through the transforms too, otherwise normalizing a mapped name again could change it
+	for _, rule := range rules {
+		if rule.Type == AuthorNameRuleExact {
+			normalizer.mappings[strings.ToLower(collapseAuthorName(rule.Pattern))] = normalizer.transform(collapseAuthorName(rule.Replacement))
+		}
+	}
+
+	return normalizer
+}
+
+// Normalize returns the canonical form of name. Running it twice gives the same result as running it once
+func (n *AuthorNameNormalizer) Normalize(name string) string {
+	name = collapseAuthorName(name)
+	if n == nil || name == "" {
+		return name
+	}
+
+	if replacement, ok := n.mappings[strings.ToLower(name)]; ok {
+		return replacement
+	}
+
+	name = n.transform(name)
+	if replacement, ok := n.mappings[strings.ToLower(name)]; ok {
+		return replacement
+	}
+	return name
+}
+
+func (n *AuthorNameNormalizer) transform(name string) string {
+	if n.invertLastFirst {
+		name = invertAuthorName(name)
+	}
+	if n.spaceInitials {
+		name = spaceAuthorInitials(name)
+	}
+	if n.foldDiacritics {
+		name = foldAuthorDiacritics(name)
+	}
+	return name
+}
+
+// NormalizeAll returns the names normalized, minus blanks and names that normalize to a duplicate
+func (n *AuthorNameNormalizer) NormalizeAll(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+
+	for _, name := range names {
+		name = n.Normalize(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
+
+// NormalizeBookAuthors returns a copy of books with every author list normalized, the books passed in
+// may be shared with a cache so they're left untouched
+func (n *AuthorNameNormalizer) NormalizeBookAuthors(books []Book) []Book {
+	normalized := make([]Book, len(books))
+	for i, book := range books {
+		book.Authors = n.NormalizeAll(book.Authors)
+		normalized[i] = book
+	}
+	return normalized
+}
+
+func collapseAuthorName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Helper fn: "Last, First" and "Last, First, Jr." flip, "First Last, Jr." is already in order
+func invertAuthorName(name string) string {
+	parts := strings.Split(name, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	switch {
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "" && !IsAuthorNameSuffix(parts[1]):
+		return parts[1] + " " + parts[0]
+	case len(parts) == 3 && parts[0] != "" && parts[1] != "" && IsAuthorNameSuffix(parts[2]):
+		return parts[1] + " " + parts[0] + ", " + parts[2]
+	}
+	return name
+}
+
+// IsAuthorNameSuffix reports whether part is a suffix like "Jr." or "PhD", case and full stops ignored
+func IsAuthorNameSuffix(part string) bool {
+	return authorNameSuffixes[strings.ToLower(strings.ReplaceAll(part, ".", ""))]
+}
+
+// Helper fn: a space after each initial's full stop, "J.R.R.Tolkien" included
+func spaceAuthorInitials(name string) string {
+	return authorInitialsPattern.ReplaceAllString(name, ". $1")
+}
+
+func foldAuthorDiacritics(name string) string {
+	var folded strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		if !unicode.Is(unicode.Mn, r) {
+			folded.WriteRune(r)
+		}
+	}
+	return norm.NFC.String(folded.String())
+}
+
+type AuthorNameRuleRepository interface {
+	GetNormalizer(ctx context.Context, userID int) (*AuthorNameNormalizer, error)
+	ListRules(ctx context.Context) ([]AuthorNameRule, error)
+	InsertRule(ctx context.Context, rule AuthorNameRule) (*AuthorNameRule, error)
+	DeleteRule(ctx context.Context, ruleID int) error
+}
+
+type AuthorNameRuleRepositoryImpl struct {
+	DB          *sql.DB
+	Logger      *slog.Logger
+	normalizers sync.Map // userID (0 for global only) -> *authorNameNormalizerEntry
+}
+
+type authorNameNormalizerEntry struct {
+	normalizer *AuthorNameNormalizer
+	expireTime time.Time
+}
+
+// Rules are edited rarely, the TTL only bounds staleness on other instances
+const authorNameNormalizerTTL = ShortTTL
+
+func NewAuthorNameRuleRepository(db *sql.DB, logger *slog.Logger) (AuthorNameRuleRepository, error) {
+	if db == nil || logger == nil {
+		return nil, fmt.Errorf("author name rule repository, database or logger is nil")
+	}
+
+	return &AuthorNameRuleRepositoryImpl{
+		DB:     db,
+		Logger: logger,
+	}, nil
+}
+
+// GetNormalizer returns the global rules plus the user's own, userID 0 gets the global rules alone
+func (r *AuthorNameRuleRepositoryImpl) GetNormalizer(ctx context.Context, userID int) (*AuthorNameNormalizer, error) {
+	if cached, found := r.normalizers.Load(userID); found {
+		if entry := cached.(*authorNameNormalizerEntry); time.Now().Before(entry.expireTime) {
+			return entry.normalizer, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	// Global rules sort first so the user's exact mappings override them
+	query := `
+		SELECT id, user_id, rule_type, pattern, replacement, created_at
+		FROM author_name_rules
+		WHERE user_id IS NULL OR user_id = $1
+		ORDER BY user_id NULLS FIRST, id`
+
+	rules, err := r.queryRules(ctx, query, userID)
+	if err != nil {
+		r.Logger.Error("Error fetching author name rules", "error", err, "userID", userID)
+		return nil, err
+	}
+
+	normalizer := NewAuthorNameNormalizer(rules)
+	r.normalizers.Store(userID, &authorNameNormalizerEntry{
+		normalizer: normalizer,
+		expireTime: time.Now().Add(authorNameNormalizerTTL),
+	})
+	return normalizer, nil
+}
+
+func (r *AuthorNameRuleRepositoryImpl) ListRules(ctx context.Context) ([]AuthorNameRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, rule_type, pattern, replacement, created_at
+		FROM author_name_rules
+		ORDER BY user_id NULLS FIRST, id`
+
+	rules, err := r.queryRules(ctx, query)
+	if err != nil {
+		r.Logger.Error("Error listing author name rules", "error", err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+// InsertRule validates and stores a rule, re-adding an existing rule returns ErrInvalidAuthorNameRule
+func (r *AuthorNameRuleRepositoryImpl) InsertRule(ctx context.Context, rule AuthorNameRule) (*AuthorNameRule, error) {
+	rule.Pattern = collapseAuthorName(rule.Pattern)
+	rule.Replacement = collapseAuthorName(rule.Replacement)
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO author_name_rules (user_id, rule_type, pattern, replacement)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+		RETURNING id, created_at`
+
+	err := r.DB.QueryRowContext(ctx, query, rule.UserID, rule.Type, rule.Pattern, rule.Replacement).Scan(&rule.ID, &rule.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: rule already exists", ErrInvalidAuthorNameRule)
+	}
+	if err != nil {
+		r.Logger.Error("Error inserting author name rule", "error", err, "type", rule.Type)
+		return nil, err
+	}
+
+	r.invalidateNormalizers()
+	return &rule, nil
+}
+
+func (r *AuthorNameRuleRepositoryImpl) DeleteRule(ctx context.Context, ruleID int) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM author_name_rules WHERE id = $1`, ruleID)
+	if err != nil {
+		r.Logger.Error("Error deleting author name rule", "error", err, "ruleID", ruleID)
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrAuthorNameRuleNotFound
+	}
+
+	r.invalidateNormalizers()
+	return nil
+}
+
+// Helper fn: a global rule change affects every user's normalizer, so drop them all
+func (r *AuthorNameRuleRepositoryImpl) invalidateNormalizers() {
+	r.normalizers.Range(func(key, _ interface{}) bool {
+		r.normalizers.Delete(key)
+		return true
+	})
+}
+
+func (r *AuthorNameRuleRepositoryImpl) queryRules(ctx context.Context, query string, args ...interface{}) ([]AuthorNameRule, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []AuthorNameRule{}
+	for rows.Next() {
+		var rule AuthorNameRule
+		var userID sql.NullInt64
+		if err := rows.Scan(&rule.ID, &userID, &rule.Type, &rule.Pattern, &rule.Replacement, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			rule.UserID = &id
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var allAuthorNameTransforms = []AuthorNameRule{
+	{Type: AuthorNameRuleInvertLastFirst},
+	{Type: AuthorNameRuleSpaceInitials},
+	{Type: AuthorNameRuleFoldDiacritics},
+}
+
+func TestAuthorNameNormalizerNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []AuthorNameRule
+		input string
+		want  string
+	}{
+		{name: "nil normalizer collapses whitespace", input: "  Octavia   E. Butler ", want: "Octavia E. Butler"},
+		{name: "last, first is inverted", rules: allAuthorNameTransforms, input: "Le Guin,  Ursula K.", want: "Ursula K. Le Guin"},
+		{name: "suffix stays at the end", rules: allAuthorNameTransforms, input: "Vonnegut, Kurt, Jr.", want: "Kurt Vonnegut, Jr."},
+		{name: "name already in order keeps its suffix", rules: allAuthorNameTransforms, input: "Martin Luther King, Jr.", want: "Martin Luther King, Jr."},
+		{name: "lowercase suffix without full stop", rules: allAuthorNameTransforms, input: "Henry Louis Gates, jr", want: "Henry Louis Gates, jr"},
+		{name: "three parts without a suffix are left alone", rules: allAuthorNameTransforms, input: "Smith, John, Extra", want: "Smith, John, Extra"},
+		{name: "initials are spaced", rules: allAuthorNameTransforms, input: "J.R.R.Tolkien", want: "J. R. R. Tolkien"},
+		{name: "inverted initials", rules: allAuthorNameTransforms, input: "Tolkien, J.R.R.", want: "J. R. R. Tolkien"},
+		{name: "diacritics are folded", rules: allAuthorNameTransforms, input: "García Márquez, Gabriel", want: "Gabriel Garcia Marquez"},
+		{
+			name:  "disabled transforms leave the name alone",
+			rules: []AuthorNameRule{{Type: AuthorNameRuleSpaceInitials}},
+			input: "García Márquez, Gabriel",
+			want:  "García Márquez, Gabriel",
+		},
+		{
+			name:  "exact mapping is case-insensitive and its replacement is transformed",
+			rules: append([]AuthorNameRule{{Type: AuthorNameRuleExact, Pattern: "tolkien", Replacement: "Tolkien, J.R.R."}}, allAuthorNameTransforms...),
+			input: "TOLKIEN",
+			want:  "J. R. R. Tolkien",
+		},
+		{
+			name:  "exact mapping matches the transformed name",
+			rules: append([]AuthorNameRule{{Type: AuthorNameRuleExact, Pattern: "Ursula K. Le Guin", Replacement: "Ursula Le Guin"}}, allAuthorNameTransforms...),
+			input: "Le Guin, Ursula K.",
+			want:  "Ursula Le Guin",
+		},
+		{
+			name: "later exact mappings win",
+			rules: []AuthorNameRule{
+				{Type: AuthorNameRuleExact, Pattern: "Anon", Replacement: "Anonymous"},
+				{Type: AuthorNameRuleExact, Pattern: "anon", Replacement: "Unknown Author"},
+			},
+			input: "Anon",
+			want:  "Unknown Author",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var normalizer *AuthorNameNormalizer
+			if tt.rules != nil {
+				normalizer = NewAuthorNameNormalizer(tt.rules)
+			}
+
+			got := normalizer.Normalize(tt.input)
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if again := normalizer.Normalize(got); again != got {
+				t.Errorf("Normalize is not idempotent: %q became %q", got, again)
+			}
+		})
+	}
+}
+
+func TestAuthorNameNormalizerNormalizeAll(t *testing.T) {
+	normalizer := NewAuthorNameNormalizer(allAuthorNameTransforms)
+
+	got := normalizer.NormalizeAll([]string{"Le Guin, Ursula K.", " ", "ursula k. le guin", "Tolkien, J.R.R.", "J. R. R. Tolkien"})
+	want := []string{"Ursula K. Le Guin", "J. R. R. Tolkien"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestAuthorNameRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    AuthorNameRule
+		wantErr bool
+	}{
+		{name: "exact", rule: AuthorNameRule{Type: AuthorNameRuleExact, Pattern: "Anon", Replacement: "Anonymous"}},
+		{name: "exact without replacement", rule: AuthorNameRule{Type: AuthorNameRuleExact, Pattern: "Anon", Replacement: " "}, wantErr: true},
+		{name: "transform", rule: AuthorNameRule{Type: AuthorNameRuleFoldDiacritics}},
+		{name: "transform with pattern", rule: AuthorNameRule{Type: AuthorNameRuleInvertLastFirst, Pattern: "Anon"}, wantErr: true},
+		{name: "unknown type", rule: AuthorNameRule{Type: "uppercase"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidAuthorNameRule) {
+				t.Errorf("expected ErrInvalidAuthorNameRule, got %v", err)
+			}
+		})
+	}
+}
@@ -34,6 +34,11 @@ type AuthorRepository interface {
 	GetAuthorsListWithBookCount(ctx context.Context, userID int) (map[string]interface{}, error)
 	GetBooksByAuthor(authorName string) ([]Book, error)
 	BatchInsertAuthors(ctx context.Context, tx *sql.Tx, bookID int, authors []string) error
+	ListAuthorSummaries(ctx context.Context) ([]AuthorSummary, error)
+	GetAuthorSummaries(ctx context.Context, tx *sql.Tx, authorIDs []int) ([]AuthorSummary, error)
+	GetAuthorBookOwners(ctx context.Context, tx *sql.Tx, authorIDs []int) ([]int, error)
+	MergeAuthors(ctx context.Context, tx *sql.Tx, sourceID, targetID int) error
+	BackfillAuthorNames(ctx context.Context, dryRun bool) (*AuthorNameBackfillReport, error)
 }
 
 type AuthorRepositoryImpl struct {
@@ -42,16 +47,22 @@ type AuthorRepositoryImpl struct {
 	getAllBooksByAuthorsStmt        *sql.Stmt
 	getAuthorsForBooksStmt          *sql.Stmt
 	getAuthorsListWithBookCountStmt  *sql.Stmt
+	nameRules                       AuthorNameRuleRepository
 }
 
-func NewAuthorRepository(db *sql.DB, logger *slog.Logger) (AuthorRepository, error) {
+func NewAuthorRepository(db *sql.DB, logger *slog.Logger, nameRules AuthorNameRuleRepository) (AuthorRepository, error) {
 	if db == nil || logger == nil {
 		return nil, fmt.Errorf("new author repository, database or logger is nil")
 	}
 
+	if nameRules == nil {
+		return nil, fmt.Errorf("new author repository, author name rules are nil")
+	}
+
 	return &AuthorRepositoryImpl{
-		DB:      db,
-		Logger: logger,
+		DB:        db,
+		Logger:    logger,
+		nameRules: nameRules,
 	}, nil
 }
 
@@ -113,6 +124,12 @@ func (r *AuthorRepositoryImpl) InitPreparedStatements() error {
 func (r *AuthorRepositoryImpl) InsertAuthor(ctx context.Context, tx *sql.Tx, author string) (int, error) {
 	var authorID int
 
+	// Store the canonical spelling, so "Le Guin, Ursula K." and "Ursula K. Le Guin" share a row
+	author = r.normalizeAuthorName(ctx, author)
+	if author == "" {
+		return 0, fmt.Errorf("author name is empty")
+	}
+
 	// Check if author already exists
 	err := tx.QueryRowContext(ctx, `SELECT id FROM authors WHERE name = $1`, author).Scan(&authorID)
 	if err != nil {
@@ -132,6 +149,26 @@ func (r *AuthorRepositoryImpl) InsertAuthor(ctx context.Context, tx *sql.Tx, aut
 	return authorID, nil
 }
 
+// Helper fn: normalize with the global rules. Author rows are shared by every user, so one user's rules
+// can't decide another's spelling. A rule lookup failure shouldn't block adding a book, so the name is
+// stored with whitespace collapsed only
+func (r *AuthorRepositoryImpl) normalizeAuthorName(ctx context.Context, author string) string {
+	normalizer, err := r.nameRules.GetNormalizer(ctx, 0)
+	if err != nil {
+		r.Logger.Warn("Error loading author name rules, storing name as given", "error", err, "author", author)
+	}
+	return normalizer.Normalize(author)
+}
+
+// Helper fn: the global rules plus the user's own, for grouping their books by the names they see
+func (r *AuthorRepositoryImpl) userAuthorNames(ctx context.Context, userID int) *AuthorNameNormalizer {
+	normalizer, err := r.nameRules.GetNormalizer(ctx, userID)
+	if err != nil {
+		r.Logger.Warn("Error loading author name rules, grouping by stored names", "error", err, "userID", userID)
+	}
+	return normalizer
+}
+
 
 func (b *AuthorRepositoryImpl) AssociateBookWithAuthor(ctx context.Context, tx *sql.Tx, bookID, authorID int) error {
 	statement := `INSERT INTO book_authors (book_id, author_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
@@ -179,6 +216,10 @@ func (r *AuthorRepositoryImpl) GetAllBooksByAuthors(userID int) (map[string]inte
 	// Store authors and books
 	authors := []string{}
 	booksByAuthor := map[string][]Book{}
+	groupedBooks := map[string]map[int]bool{}
+
+	// Group under the names the user sees, two stored spellings may be one author to them
+	authorNames := r.userAuthorNames(ctx, userID)
 
 	for rows.Next() {
 		var book Book
@@ -235,15 +276,20 @@ func (r *AuthorRepositoryImpl) GetAllBooksByAuthors(userID int) (map[string]inte
 		book.IsInLibrary = true
 
 		// Populate the Authors field directly
+		authorName = authorNames.Normalize(authorName)
 		book.Authors = []string{authorName}
 
 		// Add author to the list if not already present
 		if _, found := booksByAuthor[authorName]; !found {
 			authors = append(authors, authorName)
+			groupedBooks[authorName] = map[int]bool{}
 		}
 
-		// Add book to the author's list
-		booksByAuthor[authorName] = append(booksByAuthor[authorName], book)
+		// Add book to the author's list, once even when it credits both spellings
+		if !groupedBooks[authorName][book.ID] {
+			groupedBooks[authorName][book.ID] = true
+			booksByAuthor[authorName] = append(booksByAuthor[authorName], book)
+		}
 	}
 
 	// Check for row errors
@@ -519,6 +565,11 @@ func (r *AuthorRepositoryImpl) GetAuthorsListWithBookCount(ctx context.Context,
 	defer rows.Close()
 
 	var booksByAuthor []map[string]interface{}
+	authorIndex := map[string]int{}
+
+	// Counts are merged under the names the user sees. A book crediting two spellings of one author counts twice,
+	// rare enough not to warrant per-book rows here
+	authorNames := r.userAuthorNames(ctx, userID)
 
 	for rows.Next() {
 		var authorName string
@@ -528,12 +579,24 @@ func (r *AuthorRepositoryImpl) GetAuthorsListWithBookCount(ctx context.Context,
 				return nil, err
 		}
 
+		authorName = authorNames.Normalize(authorName)
+		if index, found := authorIndex[authorName]; found {
+			booksByAuthor[index]["count"] = booksByAuthor[index]["count"].(int) + count
+			continue
+		}
+
+		authorIndex[authorName] = len(booksByAuthor)
 		booksByAuthor = append(booksByAuthor, map[string]interface{}{
 			"label": authorName,
 			"count": count,
 		})
 	}
 
+	// Merged counts can change the order the query sorted by
+	sort.SliceStable(booksByAuthor, func(i, j int) bool {
+		return booksByAuthor[i]["count"].(int) > booksByAuthor[j]["count"].(int)
+	})
+
 	if err = rows.Err(); err != nil {
 			r.Logger.Error("Error iterating rows", "error", err)
 			return nil, err
@@ -566,6 +629,7 @@ func (r *AuthorRepositoryImpl) BatchInsertAuthors(ctx context.Context, tx *sql.T
 			var authorID int
 			// Log author processing step
 			r.Logger.Info("Processing author", "index", i, "author", author)
+			author = r.normalizeAuthorName(ctx, author)
 
 			// Skip invalid author names
 			if author == "" {
@@ -41,8 +41,30 @@ type Book struct {
 	ISBN10          string              `json:"isbn10"`
 	ISBN13          string              `json:"isbn13"`
 	IsInLibrary     bool                `json:"isInLibrary"`
+	LibraryBookID   int                 `json:"libraryBookId,omitempty"` // Local copy of a search result, see LibraryMatcher
 	HasEmptyFields  bool                `json:"hasEmptyFields"`
 	EmptyFields     []string            `json:"emptyFields"`
+
+	// Catalogue details reported by metadata providers, not stored with the book
+	Publisher        string           `json:"publisher,omitempty"`
+	AverageRating    float64          `json:"averageRating,omitempty"`
+	RatingsCount     int              `json:"ratingsCount,omitempty"`
+	MaturityRating   string           `json:"maturityRating,omitempty"`
+	PrintType        string           `json:"printType,omitempty"`
+	Dimensions       *BookDimensions  `json:"dimensions,omitempty"`
+	OtherIdentifiers []BookIdentifier `json:"otherIdentifiers,omitempty"` // ISSN, OCLC etc, ISBNs have their own fields
+}
+
+// BookDimensions as printed by the publisher, e.g. "24.00 cm"
+type BookDimensions struct {
+	Height    string `json:"height,omitempty"`
+	Width     string `json:"width,omitempty"`
+	Thickness string `json:"thickness,omitempty"`
+}
+
+type BookIdentifier struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
 }
 
 type UserTagsCacheEntry struct {
@@ -9,6 +9,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/lokeam/bravo-kilo/internal/dbconfig"
 	"github.com/lokeam/bravo-kilo/internal/shared/collections"
 )
@@ -21,6 +22,7 @@ var booksByLangCache         sync.Map
 var bookCountByGenreCache    sync.Map
 var allBooksByGenresCache    sync.Map
 var userTagsCache            sync.Map
+var libraryMatcherCache      sync.Map
 
 const (
 	ShortTTL  = 15 * time.Minute // Frequently changing data
@@ -37,6 +39,7 @@ var cacheTTL = map[string]time.Duration{
 	"bookCountByGenre":      ShortTTL,  // Home page statistics
 	"allBooksByGenres":      ShortTTL,  // Library page sorting
 	"userTags":              ShortTTL,  // Book detail/form meta data
+	"libraryMatcher":        MediumTTL, // Search "already in library" checks
 }
 
 type BookCache interface {
@@ -46,6 +49,7 @@ type BookCache interface {
 	GetAllBooksISBN13(userID int) (*collections.Set, error)
 	GetAllBooksTitles(userID int) (*collections.Set, error)
 	GetAllBooksPublishDate(userID int) ([]BookInfo, error)
+	GetLibraryMatcher(userID int) (*LibraryMatcher, error)
 	GetBooksByLanguage(ctx context.Context, userID int) (map[string]interface{}, error)
 	InvalidateCaches(bookID int, userID int)
 	StopCleanupWorker()
@@ -321,6 +325,71 @@ func (b *BookCacheImpl) GetAllBooksPublishDate(userID int) ([]BookInfo, error) {
 	return books, nil
 }
 
+// (Returns a LibraryMatcher indexing the user's books by ISBN and title)
+func (b *BookCacheImpl) GetLibraryMatcher(userID int) (*LibraryMatcher, error) {
+	cacheKey := b.FormatCacheKey("libraryMatcher", userID)
+	// Check cache
+	if cacheData, found := libraryMatcherCache.Load(cacheKey); found {
+		if item, ok := cacheData.(*CacheItem); ok {
+			b.RecordCacheHit(item, "libraryMatcher")
+			return item.Value.(*LibraryMatcher), nil
+		}
+	} else {
+		b.RecordCacheMiss(nil, "libraryMatcher")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+	SELECT b.id, b.title, COALESCE(b.isbn_10, ''), COALESCE(b.isbn_13, ''),
+		COALESCE(ARRAY_AGG(a.name) FILTER (WHERE a.name IS NOT NULL), '{}')
+	FROM books b
+	INNER JOIN user_books ub ON b.id = ub.book_id
+	LEFT JOIN book_authors ba ON b.id = ba.book_id
+	LEFT JOIN authors a ON ba.author_id = a.id
+	WHERE ub.user_id = $1
+	GROUP BY b.id`
+
+	rows, err := b.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		b.Logger.Error("Error retrieving books for library matcher", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []LibraryMatchEntry
+	for rows.Next() {
+		var entry LibraryMatchEntry
+		var authors pq.StringArray
+		if err := rows.Scan(&entry.BookID, &entry.Title, &entry.ISBN10, &entry.ISBN13, &authors); err != nil {
+			b.Logger.Error("Error scanning book for library matcher", "error", err)
+			return nil, err
+		}
+		entry.Authors = authors
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		b.Logger.Error("Error with rows", "error", err)
+		return nil, err
+	}
+
+	matcher := NewLibraryMatcher(entries)
+
+	// Cache result
+	cacheItem := &CacheItem{
+		Value:      matcher,
+		ExpireTime: time.Now().Add(cacheTTL["libraryMatcher"]),
+		Hits:       0,
+		Misses:     1,
+	}
+	libraryMatcherCache.Store(cacheKey, cacheItem)
+	b.Logger.Info("Caching library matcher for user", "userID", userID, "books", len(entries))
+
+	return matcher, nil
+}
+
 // Languages
 func (b *BookCacheImpl) GetBooksByLanguage(ctx context.Context, userID int) (map[string]interface{}, error) {
 	// Check cache
@@ -426,12 +495,14 @@ func (b *BookCacheImpl) InvalidateCaches(bookID int, userID int) {
     isbn10Cache.Delete(b.FormatCacheKey("isbn10", userID))
     isbn13Cache.Delete(b.FormatCacheKey("isbn13", userID))
     titleCache.Delete(b.FormatCacheKey("titles", userID))
+    libraryMatcherCache.Delete(b.FormatCacheKey("libraryMatcher", userID))
 
     // User-specific caches
     booksByLangCache.Delete(b.FormatCacheKey("booksByLang", userID))
     bookCountByGenreCache.Delete(b.FormatCacheKey("bookCountByGenre", userID))
     allBooksByGenresCache.Delete(b.FormatCacheKey("allBooksByGenres", userID))
     userTagsCache.Delete(b.FormatCacheKey("userTags", userID))
+    authorListWithBookCountCache.Delete(userID)
 
     b.Logger.Info("Caches invalidated", "bookID", bookID, "userID", userID)
 }
@@ -493,6 +564,7 @@ func (b *BookCacheImpl) cleanup() {
 	cleanupCache(&bookCountByGenreCache, "bookCountByGenre", b.Logger)
 	cleanupCache(&allBooksByGenresCache, "allBooksByGenres", b.Logger)
 	cleanupCache(&userTagsCache, "userTags", b.Logger)
+	cleanupCache(&libraryMatcherCache, "libraryMatcher", b.Logger)
 
 	b.Logger.Info("Cache cleanup completed",
 		"duration", time.Since(start),
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/lib/pq"
 	"github.com/lokeam/bravo-kilo/internal/dbconfig"
 )
 
 type BookDeleter interface {
   Delete(id int) error
+  DeleteAllForUser(ctx context.Context, tx *sql.Tx, userID int) (int, error)
 }
 
 type BookDeleterImpl struct {
@@ -67,6 +69,51 @@ func (b *BookDeleterImpl) Delete(id int) error {
 	return nil
 }
 
+// DeleteAllForUser removes every book in the user's library inside the caller's transaction,
+// returns the number of books deleted
+func (b *BookDeleterImpl) DeleteAllForUser(ctx context.Context, tx *sql.Tx, userID int) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT book_id FROM user_books WHERE user_id = $1`, userID)
+	if err != nil {
+		b.Logger.Error("Book Model - Error fetching user's books for deletion", "error", err, "userID", userID)
+		return 0, err
+	}
+
+	var bookIDs []int
+	for rows.Next() {
+		var bookID int
+		if err := rows.Scan(&bookID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		bookIDs = append(bookIDs, bookID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(bookIDs) == 0 {
+		return 0, nil
+	}
+
+	statements := []string{
+		`DELETE FROM user_books WHERE book_id = ANY($1)`,
+		`DELETE FROM book_genres WHERE book_id = ANY($1)`,
+		`DELETE FROM book_authors WHERE book_id = ANY($1)`,
+		`DELETE FROM book_formats WHERE book_id = ANY($1)`,
+		`DELETE FROM book_tags WHERE book_id = ANY($1)`,
+		`DELETE FROM books WHERE id = ANY($1)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement, pq.Array(bookIDs)); err != nil {
+			b.Logger.Error("Book Model - Error deleting user's books", "error", err, "userID", userID, "statement", statement)
+			return 0, err
+		}
+	}
+
+	return len(bookIDs), nil
+}
+
 // Helper fn for Delete, handles deleting associated records in related tables
 func (b *BookDeleterImpl) deleteAssociations(ctx context.Context, tx *sql.Tx, bookID int) error {
 	// Delete associated user_books entries
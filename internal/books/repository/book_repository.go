@@ -19,6 +19,8 @@ type BookRepository interface {
 	GetBookByID(id int) (*Book, error)
 	GetBookIdByTitle(title string) (int, error)
 	GetAllBooksByUserID(userID int) ([]Book, error)
+	GetBooksPageByUserID(ctx context.Context, userID int, afterID int, limit int) ([]Book, error)
+	SearchBooksByUserID(ctx context.Context, userID int, params LibrarySearchParams) (*LibrarySearchResult, error)
 	AddBookToUser(tx *sql.Tx, userID, bookID int) error
 	IsUserBookOwner(userID, bookID int) (bool, error)
 	UpdateBook(ctx context.Context, tx *sql.Tx, book Book) error
@@ -461,6 +463,94 @@ func (r *BookRepositoryImpl) GetAllBooksByUserID(userID int) ([]Book, error) {
 	return books, nil
 }
 
+// GetBooksPageByUserID returns up to limit books with IDs greater than afterID, ordered by ID.
+// Pass the last ID of each page as the next cursor, an empty page means the end was reached
+func (r *BookRepositoryImpl) GetBooksPageByUserID(ctx context.Context, userID int, afterID int, limit int) ([]Book, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		SELECT b.id, b.title, b.subtitle, COALESCE(b.description::text, '{}')::json AS description, b.language, b.page_count, b.publish_date,
+					 b.image_link, COALESCE(b.notes::text, '{}')::json AS notes, b.created_at, b.last_updated, b.isbn_10, b.isbn_13
+		FROM books b
+		INNER JOIN user_books ub ON b.id = ub.book_id
+		WHERE ub.user_id = $1 AND b.id > $2
+		ORDER BY b.id
+		LIMIT $3`
+
+	rows, err := r.DB.QueryContext(ctx, query, userID, afterID, limit)
+	if err != nil {
+		r.Logger.Error("Error fetching page of books", "error", err, "userID", userID, "afterID", afterID)
+		return nil, fmt.Errorf("failed to fetch page of books: %w", err)
+	}
+	defer rows.Close()
+
+	bookIDMap := make(map[int]*Book)
+	var bookIDs []int
+
+	for rows.Next() {
+		var book Book
+		var descriptionJSON, notesJSON []byte
+
+		if err := rows.Scan(
+			&book.ID,
+			&book.Title,
+			&book.Subtitle,
+			&descriptionJSON,
+			&book.Language,
+			&book.PageCount,
+			&book.PublishDate,
+			&book.ImageLink,
+			&notesJSON,
+			&book.CreatedAt,
+			&book.LastUpdated,
+			&book.ISBN10,
+			&book.ISBN13,
+		); err != nil {
+			r.Logger.Error("Error scanning book", "error", err)
+			return nil, fmt.Errorf("failed to scan book row: %w", err)
+		}
+
+		// Convert description and notes from JSON to RichText
+		if len(descriptionJSON) > 0 {
+			if err := json.Unmarshal(descriptionJSON, &book.Description); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal description: %w", err)
+			}
+		}
+		if len(notesJSON) > 0 {
+			if err := json.Unmarshal(notesJSON, &book.Notes); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal notes: %w", err)
+			}
+		}
+
+		book.IsInLibrary = true
+		bookIDMap[book.ID] = &book
+		bookIDs = append(bookIDs, book.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate book rows: %w", err)
+	}
+
+	if len(bookIDs) == 0 {
+		return []Book{}, nil
+	}
+
+	// Batch Fetch authors, formats, genres, and tags
+	if err := r.batchFetchBookDetails(ctx, bookIDs, bookIDMap); err != nil {
+		return nil, fmt.Errorf("failed to fetch additional book details: %w", err)
+	}
+
+	// Keep cursor order, the map loses it
+	books := make([]Book, 0, len(bookIDs))
+	for _, bookID := range bookIDs {
+		book := bookIDMap[bookID]
+		book.EmptyFields, book.HasEmptyFields = r.findEmptyFields(book)
+		books = append(books, *book)
+	}
+
+	return books, nil
+}
+
 func (r *BookRepositoryImpl) UpdateBook(ctx context.Context, tx *sql.Tx, book Book) error {
 
 	// Check if the prepared statement is available
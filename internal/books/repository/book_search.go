@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/lokeam/bravo-kilo/internal/dbconfig"
+)
+
+const maxLibrarySearchTerms = 16
+
+// Highlight markers used inside ts_headline, swapped for <mark> once the text around them is escaped
+const (
+	librarySearchStartSel = "\x01"
+	librarySearchStopSel  = "\x02"
+)
+
+var (
+	librarySearchTitleHeadline   = fmt.Sprintf("HighlightAll=true, StartSel=%s, StopSel=%s", librarySearchStartSel, librarySearchStopSel)
+	librarySearchSnippetHeadline = fmt.Sprintf(`MaxFragments=2, MaxWords=24, MinWords=8, FragmentDelimiter=" … ", StartSel=%s, StopSel=%s`, librarySearchStartSel, librarySearchStopSel)
+)
+
+// LibrarySearchParams filters are exact, case-insensitive matches. Query may be empty when a filter is set
+type LibrarySearchParams struct {
+	Query    string
+	Format   string
+	Genre    string
+	Language string
+	Tag      string
+	Limit    int
+	Offset   int
+}
+
+type LibrarySearchHit struct {
+	Book           Book    `json:"book"`
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"titleHighlight"` // HTML, matched terms wrapped in <mark>
+	Snippet        string  `json:"snippet"`        // HTML, from description and notes
+}
+
+type LibrarySearchResult struct {
+	Hits       []LibrarySearchHit `json:"hits"`
+	TotalItems int                `json:"totalItems"`
+}
+
+// SearchBooksByUserID ranks the user's books against books.search_document, see migration 000004
+func (r *BookRepositoryImpl) SearchBooksByUserID(ctx context.Context, userID int, params LibrarySearchParams) (*LibrarySearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	tsQuery := libraryTSQuery(params.Query)
+
+	// Rank and count first, headlines are expensive so they're only built for the page being returned
+	query := `
+		WITH search AS (
+			SELECT CASE WHEN $2 = '' THEN NULL ELSE to_tsquery('simple', $2) END AS query
+		),
+		matches AS (
+			SELECT b.id,
+						 COALESCE(ts_rank_cd(b.search_document, search.query), 0) AS rank,
+						 COUNT(*) OVER () AS total
+			FROM books b
+			INNER JOIN user_books ub ON b.id = ub.book_id
+			CROSS JOIN search
+			WHERE ub.user_id = $1
+				AND (search.query IS NULL OR b.search_document @@ search.query)
+				AND ($3 = '' OR EXISTS (
+					SELECT 1 FROM book_formats bf INNER JOIN formats f ON f.id = bf.format_id
+					WHERE bf.book_id = b.id AND LOWER(f.format_type) = LOWER($3)))
+				AND ($4 = '' OR EXISTS (
+					SELECT 1 FROM book_genres bg INNER JOIN genres g ON g.id = bg.genre_id
+					WHERE bg.book_id = b.id AND LOWER(g.name) = LOWER($4)))
+				AND ($5 = '' OR LOWER(b.language) = LOWER($5))
+				AND ($6 = '' OR EXISTS (
+					SELECT 1 FROM book_tags bt INNER JOIN tags t ON t.id = bt.tag_id
+					WHERE bt.book_id = b.id AND LOWER(t.name) = LOWER($6)))
+			ORDER BY rank DESC, LOWER(b.title), b.id
+			LIMIT $7 OFFSET $8
+		)
+		SELECT b.id, b.title, b.subtitle, COALESCE(b.description::text, '{}')::json AS description, b.language, b.page_count, b.publish_date,
+					 b.image_link, COALESCE(b.notes::text, '{}')::json AS notes, b.created_at, b.last_updated, b.isbn_10, b.isbn_13,
+					 m.rank, m.total,
+					 CASE WHEN search.query IS NULL THEN '' ELSE ts_headline('simple', b.title, search.query, $9) END,
+					 CASE WHEN search.query IS NULL THEN '' ELSE ts_headline('simple',
+						 concat_ws(' ', rich_text_plain_text(b.description::text), rich_text_plain_text(b.notes::text)),
+						 search.query, $10) END
+		FROM matches m
+		INNER JOIN books b ON b.id = m.id
+		CROSS JOIN search
+		ORDER BY m.rank DESC, LOWER(b.title), b.id`
+
+	rows, err := r.DB.QueryContext(ctx, query,
+		userID,
+		tsQuery,
+		params.Format,
+		params.Genre,
+		params.Language,
+		params.Tag,
+		params.Limit,
+		params.Offset,
+		librarySearchTitleHeadline,
+		librarySearchSnippetHeadline,
+	)
+	if err != nil {
+		r.Logger.Error("Error searching user's books", "error", err, "userID", userID)
+		return nil, fmt.Errorf("failed to search books: %w", err)
+	}
+	defer rows.Close()
+
+	result := &LibrarySearchResult{Hits: []LibrarySearchHit{}}
+	bookIDMap := make(map[int]*Book)
+	var bookIDs []int
+	var hits []*LibrarySearchHit
+
+	for rows.Next() {
+		hit := &LibrarySearchHit{}
+		book := &hit.Book
+		var descriptionJSON, notesJSON []byte
+
+		if err := rows.Scan(
+			&book.ID,
+			&book.Title,
+			&book.Subtitle,
+			&descriptionJSON,
+			&book.Language,
+			&book.PageCount,
+			&book.PublishDate,
+			&book.ImageLink,
+			&notesJSON,
+			&book.CreatedAt,
+			&book.LastUpdated,
+			&book.ISBN10,
+			&book.ISBN13,
+			&hit.Rank,
+			&result.TotalItems,
+			&hit.TitleHighlight,
+			&hit.Snippet,
+		); err != nil {
+			r.Logger.Error("Error scanning book search row", "error", err)
+			return nil, fmt.Errorf("failed to scan book search row: %w", err)
+		}
+
+		// Convert description and notes from JSON to RichText
+		if len(descriptionJSON) > 0 {
+			if err := json.Unmarshal(descriptionJSON, &book.Description); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal description: %w", err)
+			}
+		}
+		if len(notesJSON) > 0 {
+			if err := json.Unmarshal(notesJSON, &book.Notes); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal notes: %w", err)
+			}
+		}
+
+		hit.TitleHighlight = librarySearchHighlightHTML(hit.TitleHighlight)
+		hit.Snippet = librarySearchHighlightHTML(hit.Snippet)
+		book.IsInLibrary = true
+		bookIDMap[book.ID] = book
+		bookIDs = append(bookIDs, book.ID)
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate book search rows: %w", err)
+	}
+
+	if len(bookIDs) == 0 {
+		return result, nil
+	}
+
+	// Batch Fetch authors, formats, genres, and tags
+	if err := r.batchFetchBookDetails(ctx, bookIDs, bookIDMap); err != nil {
+		return nil, fmt.Errorf("failed to fetch additional book details: %w", err)
+	}
+
+	for _, hit := range hits {
+		hit.Book.EmptyFields, hit.Book.HasEmptyFields = r.findEmptyFields(&hit.Book)
+		result.Hits = append(result.Hits, *hit)
+	}
+
+	return result, nil
+}
+
+// HasLibrarySearchTerms reports whether raw has any letters or digits to search for. Punctuation alone
+// leaves an empty tsquery, which the search treats as no query at all
+func HasLibrarySearchTerms(raw string) bool {
+	return libraryTSQuery(raw) != ""
+}
+
+// Helper fn: every word becomes a prefix match, all words must match. Punctuation splits words the
+// same way the 'simple' parser does, and leaves nothing that to_tsquery would read as an operator
+func libraryTSQuery(raw string) string {
+	words := strings.FieldsFunc(strings.ToLower(raw), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, min(len(words), maxLibrarySearchTerms))
+	for _, word := range words {
+		if len(terms) == maxLibrarySearchTerms {
+			break
+		}
+		terms = append(terms, word+":*")
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// Helper fn: escape the headline text, then turn the ts_headline markers into <mark> tags
+func librarySearchHighlightHTML(headline string) string {
+	escaped := html.EscapeString(headline)
+	escaped = strings.ReplaceAll(escaped, librarySearchStartSel, "<mark>")
+	return strings.ReplaceAll(escaped, librarySearchStopSel, "</mark>")
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Records each query's arguments and answers with no rows
+type fakeSearchConn struct {
+	args [][]driver.Value
+}
+
+func (c *fakeSearchConn) Connect(ctx context.Context) (driver.Conn, error) { return c, nil }
+func (c *fakeSearchConn) Driver() driver.Driver                            { return c }
+func (c *fakeSearchConn) Open(name string) (driver.Conn, error)            { return c, nil }
+func (c *fakeSearchConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c *fakeSearchConn) Close() error              { return nil }
+func (c *fakeSearchConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (c *fakeSearchConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.args = append(c.args, values)
+	return fakeSearchRows{}, nil
+}
+
+type fakeSearchRows struct{}
+
+func (fakeSearchRows) Columns() []string              { return []string{"id"} }
+func (fakeSearchRows) Close() error                   { return nil }
+func (fakeSearchRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestLibraryTSQuery(t *testing.T) {
+	tests := map[string]string{
+		"":                       "",
+		"  ":                     "",
+		"!!! ---":                "",
+		"Dune":                   "dune:*",
+		"left hand":              "left:* & hand:*",
+		"Le Guin's Earthsea":     "le:* & guin:* & s:* & earthsea:*",
+		"c++ & (rust | go)!":     "c:* & rust:* & go:*",
+		"Ursula K. Le Guin 1969": "ursula:* & k:* & le:* & guin:* & 1969:*",
+		"Éowyn café":             "éowyn:* & café:*",
+	}
+
+	for raw, want := range tests {
+		if got := libraryTSQuery(raw); got != want {
+			t.Errorf("libraryTSQuery(%q) = %q, want %q", raw, got, want)
+		}
+	}
+
+	// Long queries are capped rather than handed to Postgres whole
+	if terms := strings.Split(libraryTSQuery(strings.Repeat("word ", 40)), " & "); len(terms) != maxLibrarySearchTerms {
+		t.Errorf("expected %d terms, got %d", maxLibrarySearchTerms, len(terms))
+	}
+}
+
+func TestLibrarySearchHighlightHTML(t *testing.T) {
+	headline := "<b>" + librarySearchStartSel + "Kindred" + librarySearchStopSel + "</b> & more"
+	want := "&lt;b&gt;<mark>Kindred</mark>&lt;/b&gt; &amp; more"
+	if got := librarySearchHighlightHTML(headline); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSearchBooksByUserIDPassesQueryAndFilters(t *testing.T) {
+	conn := &fakeSearchConn{}
+	db := sql.OpenDB(conn)
+	defer db.Close()
+	repo := &BookRepositoryImpl{DB: db, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	result, err := repo.SearchBooksByUserID(context.Background(), 7, LibrarySearchParams{
+		Query:    "Left Hand",
+		Format:   "eBook",
+		Genre:    "Science Fiction",
+		Language: "en",
+		Limit:    20,
+		Offset:   40,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Hits == nil || len(result.Hits) != 0 || result.TotalItems != 0 {
+		t.Errorf("expected an empty, non-nil page, got %+v", result)
+	}
+
+	if len(conn.args) != 1 {
+		t.Fatalf("expected one query, got %d", len(conn.args))
+	}
+	want := []driver.Value{int64(7), "left:* & hand:*", "eBook", "Science Fiction", "en", "", int64(20), int64(40)}
+	if got := conn.args[0][:len(want)]; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected query arguments\nwant %v\n got %v", want, got)
+	}
+}
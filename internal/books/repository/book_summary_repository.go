@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/dbconfig"
+)
+
+var ErrBookSummaryNotFound = errors.New("book summary not found")
+
+// BookSummary is an AI generated summary saved with the book, one per kind
+type BookSummary struct {
+	ID         int        `json:"id"`
+	BookID     int        `json:"bookId"`
+	Kind       string     `json:"kind"`
+	Text       string     `json:"text"`
+	Provider   string     `json:"provider"`
+	Model      string     `json:"model"`
+	Edited     bool       `json:"edited"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"` // Last copied into the book's notes
+}
+
+type BookSummaryRepository interface {
+	GetSummary(ctx context.Context, bookID int, kind string) (*BookSummary, error)
+	ListSummaries(ctx context.Context, bookID int) ([]BookSummary, error)
+	SaveSummary(ctx context.Context, summary *BookSummary) error
+	UpdateSummaryText(ctx context.Context, bookID int, kind string, text string) (*BookSummary, error)
+	MarkSummaryAccepted(ctx context.Context, bookID int, kind string) (*BookSummary, error)
+}
+
+type BookSummaryRepositoryImpl struct {
+	DB     *sql.DB
+	Logger *slog.Logger
+}
+
+func NewBookSummaryRepository(db *sql.DB, logger *slog.Logger) (BookSummaryRepository, error) {
+	if db == nil || logger == nil {
+		return nil, fmt.Errorf("book summary repository, database or logger is nil")
+	}
+
+	return &BookSummaryRepositoryImpl{
+		DB:     db,
+		Logger: logger,
+	}, nil
+}
+
+const bookSummaryColumns = `
+	id, book_id, kind, content, provider, model, edited, created_at, updated_at, accepted_at`
+
+func (r *BookSummaryRepositoryImpl) GetSummary(ctx context.Context, bookID int, kind string) (*BookSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `SELECT` + bookSummaryColumns + ` FROM book_summaries WHERE book_id = $1 AND kind = $2`
+
+	summary, err := scanBookSummary(r.DB.QueryRowContext(ctx, query, bookID, kind))
+	if err == sql.ErrNoRows {
+		return nil, ErrBookSummaryNotFound
+	}
+	if err != nil {
+		r.Logger.Error("Error fetching book summary", "error", err, "bookID", bookID, "kind", kind)
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+func (r *BookSummaryRepositoryImpl) ListSummaries(ctx context.Context, bookID int) ([]BookSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `SELECT` + bookSummaryColumns + ` FROM book_summaries WHERE book_id = $1 ORDER BY kind`
+
+	rows, err := r.DB.QueryContext(ctx, query, bookID)
+	if err != nil {
+		r.Logger.Error("Error fetching book summaries", "error", err, "bookID", bookID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []BookSummary{}
+	for rows.Next() {
+		summary, err := scanBookSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, *summary)
+	}
+	return summaries, rows.Err()
+}
+
+// SaveSummary stores a freshly generated summary, replacing the book's previous one of the same kind
+// along with any edits made to it
+func (r *BookSummaryRepositoryImpl) SaveSummary(ctx context.Context, summary *BookSummary) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO book_summaries (book_id, kind, content, provider, model)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (book_id, kind) DO UPDATE
+		SET content = EXCLUDED.content, provider = EXCLUDED.provider, model = EXCLUDED.model,
+			edited = FALSE, created_at = NOW(), updated_at = NOW(), accepted_at = NULL
+		RETURNING` + bookSummaryColumns
+
+	saved, err := scanBookSummary(r.DB.QueryRowContext(ctx, query,
+		summary.BookID, summary.Kind, summary.Text, summary.Provider, summary.Model))
+	if err != nil {
+		r.Logger.Error("Error saving book summary", "error", err, "bookID", summary.BookID, "kind", summary.Kind)
+		return err
+	}
+
+	*summary = *saved
+	return nil
+}
+
+func (r *BookSummaryRepositoryImpl) UpdateSummaryText(ctx context.Context, bookID int, kind string, text string) (*BookSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE book_summaries SET content = $1, edited = TRUE, updated_at = NOW()
+		WHERE book_id = $2 AND kind = $3
+		RETURNING` + bookSummaryColumns
+
+	summary, err := scanBookSummary(r.DB.QueryRowContext(ctx, query, text, bookID, kind))
+	if err == sql.ErrNoRows {
+		return nil, ErrBookSummaryNotFound
+	}
+	if err != nil {
+		r.Logger.Error("Error updating book summary", "error", err, "bookID", bookID, "kind", kind)
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+func (r *BookSummaryRepositoryImpl) MarkSummaryAccepted(ctx context.Context, bookID int, kind string) (*BookSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE book_summaries SET accepted_at = NOW()
+		WHERE book_id = $1 AND kind = $2
+		RETURNING` + bookSummaryColumns
+
+	summary, err := scanBookSummary(r.DB.QueryRowContext(ctx, query, bookID, kind))
+	if err == sql.ErrNoRows {
+		return nil, ErrBookSummaryNotFound
+	}
+	if err != nil {
+		r.Logger.Error("Error marking book summary accepted", "error", err, "bookID", bookID, "kind", kind)
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// Summaries are scanned from both *sql.Row and *sql.Rows
+type bookSummaryRow interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBookSummary(row bookSummaryRow) (*BookSummary, error) {
+	var summary BookSummary
+	var acceptedAt sql.NullTime
+
+	if err := row.Scan(
+		&summary.ID,
+		&summary.BookID,
+		&summary.Kind,
+		&summary.Text,
+		&summary.Provider,
+		&summary.Model,
+		&summary.Edited,
+		&summary.CreatedAt,
+		&summary.UpdatedAt,
+		&acceptedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if acceptedAt.Valid {
+		summary.AcceptedAt = &acceptedAt.Time
+	}
+	return &summary, nil
+}
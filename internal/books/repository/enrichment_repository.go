@@ -0,0 +1,487 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lokeam/bravo-kilo/internal/dbconfig"
+)
+
+var (
+	ErrEnrichmentScanNotFound     = errors.New("enrichment scan not found")
+	ErrEnrichmentProposalNotFound = errors.New("enrichment proposal not found")
+)
+
+type EnrichmentScanStatus string
+
+const (
+	EnrichmentScanPending   EnrichmentScanStatus = "pending"
+	EnrichmentScanRunning   EnrichmentScanStatus = "running"
+	EnrichmentScanCompleted EnrichmentScanStatus = "completed"
+	EnrichmentScanFailed    EnrichmentScanStatus = "failed"
+)
+
+type EnrichmentProposalStatus string
+
+const (
+	EnrichmentProposalPending  EnrichmentProposalStatus = "pending"
+	EnrichmentProposalAccepted EnrichmentProposalStatus = "accepted"
+	EnrichmentProposalRejected EnrichmentProposalStatus = "rejected"
+)
+
+// Book fields an enrichment proposal can fill in
+const (
+	EnrichmentFieldISBN10      = "isbn10"
+	EnrichmentFieldISBN13      = "isbn13"
+	EnrichmentFieldPageCount   = "pageCount"
+	EnrichmentFieldImageLink   = "imageLink"
+	EnrichmentFieldPublishDate = "publishDate"
+)
+
+type EnrichmentScan struct {
+	ID            int                  `json:"id"`
+	UserID        int                  `json:"-"`
+	Status        EnrichmentScanStatus `json:"status"`
+	CheckedBooks  int                  `json:"checkedBooks"`
+	ProposedBooks int                  `json:"proposedBooks"`
+	FailureReason string               `json:"failureReason,omitempty"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	StartedAt     *time.Time           `json:"startedAt,omitempty"`
+	CompletedAt   *time.Time           `json:"completedAt,omitempty"`
+}
+
+// EnrichmentFieldChange is one missing field and the value a provider had for it
+type EnrichmentFieldChange struct {
+	Field    string `json:"field"`
+	Proposed string `json:"proposed"`
+}
+
+type EnrichmentProposal struct {
+	ID         int                      `json:"id"`
+	UserID     int                      `json:"-"`
+	BookID     int                      `json:"bookId"`
+	BookTitle  string                   `json:"bookTitle"`
+	Provider   string                   `json:"provider"`
+	Changes    []EnrichmentFieldChange  `json:"changes"`
+	Status     EnrichmentProposalStatus `json:"status"`
+	CreatedAt  time.Time                `json:"createdAt"`
+	ResolvedAt *time.Time               `json:"resolvedAt,omitempty"`
+}
+
+type EnrichmentRepository interface {
+	CreateScan(ctx context.Context, userID int) (*EnrichmentScan, bool, error)
+	GetLatestScan(ctx context.Context, userID int) (*EnrichmentScan, error)
+	ClaimNextPendingScan(ctx context.Context) (*EnrichmentScan, error)
+	FinishScan(ctx context.Context, scan *EnrichmentScan) error
+	HeartbeatScan(ctx context.Context, scanID int) error
+	FailInterruptedScans(ctx context.Context, staleAfter time.Duration) (int64, error)
+	GetIncompleteBooks(ctx context.Context, userID int, recheckAfter time.Duration, limit int) ([]Book, error)
+	MarkBookChecked(ctx context.Context, userID, bookID int) error
+	CreateProposal(ctx context.Context, proposal *EnrichmentProposal) error
+	ListProposals(ctx context.Context, userID int, status EnrichmentProposalStatus) ([]EnrichmentProposal, error)
+	GetProposal(ctx context.Context, proposalID, userID int) (*EnrichmentProposal, error)
+	ResolveProposal(ctx context.Context, proposalID, userID int, from, to EnrichmentProposalStatus) error
+}
+
+type EnrichmentRepositoryImpl struct {
+	DB     *sql.DB
+	Logger *slog.Logger
+}
+
+func NewEnrichmentRepository(db *sql.DB, logger *slog.Logger) (EnrichmentRepository, error) {
+	if db == nil || logger == nil {
+		return nil, fmt.Errorf("enrichment repository, database or logger is nil")
+	}
+
+	return &EnrichmentRepositoryImpl{
+		DB:     db,
+		Logger: logger,
+	}, nil
+}
+
+const enrichmentScanColumns = `
+	id, user_id, status, checked_books, proposed_books, failure_reason, created_at, started_at, completed_at`
+
+const enrichmentProposalColumns = `
+	p.id, p.user_id, p.book_id, b.title, p.provider, p.changes, p.status, p.created_at, p.resolved_at`
+
+// CreateScan queues a scan, or returns the user's scan that is already queued or running.
+// The bool reports whether a new scan was created
+func (r *EnrichmentRepositoryImpl) CreateScan(ctx context.Context, userID int) (*EnrichmentScan, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `SELECT` + enrichmentScanColumns + ` FROM enrichment_scans
+		WHERE user_id = $1 AND status IN ($2, $3)
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	scan, err := scanEnrichmentScan(r.DB.QueryRowContext(ctx, query, userID, EnrichmentScanPending, EnrichmentScanRunning))
+	if err == nil {
+		return scan, false, nil
+	}
+	if err != sql.ErrNoRows {
+		r.Logger.Error("Error checking for active enrichment scan", "error", err, "userID", userID)
+		return nil, false, err
+	}
+
+	query = `INSERT INTO enrichment_scans (user_id, status) VALUES ($1, $2) RETURNING` + enrichmentScanColumns
+
+	scan, err = scanEnrichmentScan(r.DB.QueryRowContext(ctx, query, userID, EnrichmentScanPending))
+	if err != nil {
+		r.Logger.Error("Error creating enrichment scan", "error", err, "userID", userID)
+		return nil, false, err
+	}
+
+	return scan, true, nil
+}
+
+func (r *EnrichmentRepositoryImpl) GetLatestScan(ctx context.Context, userID int) (*EnrichmentScan, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `SELECT` + enrichmentScanColumns + ` FROM enrichment_scans WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
+
+	scan, err := scanEnrichmentScan(r.DB.QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrEnrichmentScanNotFound
+	}
+	if err != nil {
+		r.Logger.Error("Error fetching enrichment scan", "error", err, "userID", userID)
+		return nil, err
+	}
+
+	return scan, nil
+}
+
+// ClaimNextPendingScan marks the oldest pending scan as running, returns nil when the queue is empty
+func (r *EnrichmentRepositoryImpl) ClaimNextPendingScan(ctx context.Context) (*EnrichmentScan, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE enrichment_scans
+		SET status = $1, started_at = NOW(), heartbeat_at = NOW()
+		WHERE id = (
+			SELECT id FROM enrichment_scans
+			WHERE status = $2
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING` + enrichmentScanColumns
+
+	scan, err := scanEnrichmentScan(r.DB.QueryRowContext(ctx, query, EnrichmentScanRunning, EnrichmentScanPending))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.Logger.Error("Error claiming enrichment scan", "error", err)
+		return nil, err
+	}
+
+	return scan, nil
+}
+
+func (r *EnrichmentRepositoryImpl) FinishScan(ctx context.Context, scan *EnrichmentScan) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE enrichment_scans
+		SET status = $1, checked_books = $2, proposed_books = $3, failure_reason = $4, completed_at = NOW()
+		WHERE id = $5`
+
+	_, err := r.DB.ExecContext(ctx, query, scan.Status, scan.CheckedBooks, scan.ProposedBooks, scan.FailureReason, scan.ID)
+	if err != nil {
+		r.Logger.Error("Error finishing enrichment scan", "error", err, "scanID", scan.ID, "status", scan.Status)
+		return err
+	}
+
+	return nil
+}
+
+// HeartbeatScan tells other instances the worker running the scan is still alive
+func (r *EnrichmentRepositoryImpl) HeartbeatScan(ctx context.Context, scanID int) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `UPDATE enrichment_scans SET heartbeat_at = NOW() WHERE id = $1 AND status = $2`
+	if _, err := r.DB.ExecContext(ctx, query, scanID, EnrichmentScanRunning); err != nil {
+		r.Logger.Error("Error recording enrichment scan heartbeat", "error", err, "scanID", scanID)
+		return err
+	}
+
+	return nil
+}
+
+// FailInterruptedScans fails running scans without a heartbeat for staleAfter, scans other instances
+// are still running are left alone
+func (r *EnrichmentRepositoryImpl) FailInterruptedScans(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	// Scans claimed before heartbeats existed fall back to their start time
+	query := `
+		UPDATE enrichment_scans
+		SET status = $1, failure_reason = 'scan interrupted by server restart', completed_at = NOW()
+		WHERE status = $2 AND COALESCE(heartbeat_at, started_at, created_at) < NOW() - $3 * INTERVAL '1 second'`
+
+	result, err := r.DB.ExecContext(ctx, query, EnrichmentScanFailed, EnrichmentScanRunning, staleAfter.Seconds())
+	if err != nil {
+		r.Logger.Error("Error failing interrupted enrichment scans", "error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetIncompleteBooks returns the user's books missing an ISBN, page count, cover or publish date.
+// Books with a pending proposal, or looked up within recheckAfter, are skipped
+func (r *EnrichmentRepositoryImpl) GetIncompleteBooks(ctx context.Context, userID int, recheckAfter time.Duration, limit int) ([]Book, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+	SELECT b.id, b.title, COALESCE(b.subtitle, ''), COALESCE(b.isbn_10, ''), COALESCE(b.isbn_13, ''),
+		COALESCE(b.page_count, 0), COALESCE(b.image_link, ''), COALESCE(b.publish_date::text, ''),
+		COALESCE(ARRAY_AGG(a.name ORDER BY a.id) FILTER (WHERE a.name IS NOT NULL), '{}')
+	FROM books b
+	INNER JOIN user_books ub ON b.id = ub.book_id
+	LEFT JOIN book_authors ba ON b.id = ba.book_id
+	LEFT JOIN authors a ON ba.author_id = a.id
+	LEFT JOIN book_enrichment_checks c ON c.book_id = b.id AND c.user_id = ub.user_id
+	WHERE ub.user_id = $1
+		AND (COALESCE(b.isbn_10, '') = '' OR COALESCE(b.isbn_13, '') = '' OR COALESCE(b.page_count, 0) = 0
+			OR COALESCE(b.image_link, '') = '' OR COALESCE(b.publish_date::text, '') = '')
+		AND (c.checked_at IS NULL OR c.checked_at < NOW() - make_interval(secs => $2))
+		AND NOT EXISTS (
+			SELECT 1 FROM book_enrichment_proposals p
+			WHERE p.book_id = b.id AND p.user_id = ub.user_id AND p.status = 'pending'
+		)
+	GROUP BY b.id
+	ORDER BY b.id
+	LIMIT $3`
+
+	rows, err := r.DB.QueryContext(ctx, query, userID, recheckAfter.Seconds(), limit)
+	if err != nil {
+		r.Logger.Error("Error fetching incomplete books", "error", err, "userID", userID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []Book{}
+	for rows.Next() {
+		var book Book
+		if err := rows.Scan(
+			&book.ID,
+			&book.Title,
+			&book.Subtitle,
+			&book.ISBN10,
+			&book.ISBN13,
+			&book.PageCount,
+			&book.ImageLink,
+			&book.PublishDate,
+			pq.Array(&book.Authors),
+		); err != nil {
+			r.Logger.Error("Error scanning incomplete book", "error", err)
+			return nil, err
+		}
+		books = append(books, book)
+	}
+
+	return books, rows.Err()
+}
+
+func (r *EnrichmentRepositoryImpl) MarkBookChecked(ctx context.Context, userID, bookID int) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	statement := `
+		INSERT INTO book_enrichment_checks (user_id, book_id, checked_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id, book_id) DO UPDATE SET checked_at = EXCLUDED.checked_at`
+
+	if _, err := r.DB.ExecContext(ctx, statement, userID, bookID); err != nil {
+		r.Logger.Error("Error marking book as checked for enrichment", "error", err, "bookID", bookID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *EnrichmentRepositoryImpl) CreateProposal(ctx context.Context, proposal *EnrichmentProposal) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	changesJSON, err := json.Marshal(proposal.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal enrichment changes: %w", err)
+	}
+
+	// A pending proposal for the book already exists when two scans overlap, keep the first.
+	// proposal.ID stays 0 when nothing was inserted
+	statement := `
+		INSERT INTO book_enrichment_proposals (user_id, book_id, provider, changes, status)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+		RETURNING id, created_at`
+
+	err = r.DB.QueryRowContext(ctx, statement,
+		proposal.UserID,
+		proposal.BookID,
+		proposal.Provider,
+		changesJSON,
+		EnrichmentProposalPending,
+	).Scan(&proposal.ID, &proposal.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		r.Logger.Error("Error creating enrichment proposal", "error", err, "bookID", proposal.BookID)
+		return err
+	}
+
+	proposal.Status = EnrichmentProposalPending
+	return nil
+}
+
+func (r *EnrichmentRepositoryImpl) ListProposals(ctx context.Context, userID int, status EnrichmentProposalStatus) ([]EnrichmentProposal, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `SELECT` + enrichmentProposalColumns + `
+		FROM book_enrichment_proposals p
+		INNER JOIN books b ON p.book_id = b.id
+		WHERE p.user_id = $1 AND p.status = $2
+		ORDER BY p.created_at DESC, p.id DESC`
+
+	rows, err := r.DB.QueryContext(ctx, query, userID, status)
+	if err != nil {
+		r.Logger.Error("Error listing enrichment proposals", "error", err, "userID", userID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	proposals := []EnrichmentProposal{}
+	for rows.Next() {
+		proposal, err := scanEnrichmentProposal(rows)
+		if err != nil {
+			r.Logger.Error("Error scanning enrichment proposal", "error", err)
+			return nil, err
+		}
+		proposals = append(proposals, *proposal)
+	}
+
+	return proposals, rows.Err()
+}
+
+func (r *EnrichmentRepositoryImpl) GetProposal(ctx context.Context, proposalID, userID int) (*EnrichmentProposal, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `SELECT` + enrichmentProposalColumns + `
+		FROM book_enrichment_proposals p
+		INNER JOIN books b ON p.book_id = b.id
+		WHERE p.id = $1 AND p.user_id = $2`
+
+	proposal, err := scanEnrichmentProposal(r.DB.QueryRowContext(ctx, query, proposalID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrEnrichmentProposalNotFound
+	}
+	if err != nil {
+		r.Logger.Error("Error fetching enrichment proposal", "error", err, "proposalID", proposalID)
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+// ResolveProposal moves a proposal from one status to another. A proposal that isn't in the from status
+// reports ErrEnrichmentProposalNotFound, so two concurrent accepts can't both apply it
+func (r *EnrichmentRepositoryImpl) ResolveProposal(ctx context.Context, proposalID, userID int, from, to EnrichmentProposalStatus) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	statement := `
+		UPDATE book_enrichment_proposals
+		SET status = $1, resolved_at = CASE WHEN $1 = 'pending' THEN NULL ELSE NOW() END
+		WHERE id = $2 AND user_id = $3 AND status = $4`
+
+	result, err := r.DB.ExecContext(ctx, statement, to, proposalID, userID, from)
+	if err != nil {
+		r.Logger.Error("Error resolving enrichment proposal", "error", err, "proposalID", proposalID)
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrEnrichmentProposalNotFound
+	}
+
+	return nil
+}
+
+func scanEnrichmentScan(row *sql.Row) (*EnrichmentScan, error) {
+	var scan EnrichmentScan
+	var startedAt, completedAt sql.NullTime
+
+	if err := row.Scan(
+		&scan.ID,
+		&scan.UserID,
+		&scan.Status,
+		&scan.CheckedBooks,
+		&scan.ProposedBooks,
+		&scan.FailureReason,
+		&scan.CreatedAt,
+		&startedAt,
+		&completedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if startedAt.Valid {
+		scan.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		scan.CompletedAt = &completedAt.Time
+	}
+	return &scan, nil
+}
+
+// Proposals are scanned from both *sql.Row and *sql.Rows
+type enrichmentProposalRow interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEnrichmentProposal(row enrichmentProposalRow) (*EnrichmentProposal, error) {
+	var proposal EnrichmentProposal
+	var changesJSON []byte
+	var resolvedAt sql.NullTime
+
+	if err := row.Scan(
+		&proposal.ID,
+		&proposal.UserID,
+		&proposal.BookID,
+		&proposal.BookTitle,
+		&proposal.Provider,
+		&changesJSON,
+		&proposal.Status,
+		&proposal.CreatedAt,
+		&resolvedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(changesJSON, &proposal.Changes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal enrichment changes: %w", err)
+	}
+	if resolvedAt.Valid {
+		proposal.ResolvedAt = &resolvedAt.Time
+	}
+	return &proposal, nil
+}
@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lokeam/bravo-kilo/internal/dbconfig"
+)
+
+var (
+	ErrImportJobNotFound   = errors.New("import job not found")
+	ErrImportJobNotRunning = errors.New("import job is no longer running")
+)
+
+type ImportJobStatus string
+
+const (
+	ImportJobPending   ImportJobStatus = "pending"
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobCompleted ImportJobStatus = "completed"
+	ImportJobFailed    ImportJobStatus = "failed"
+	ImportJobCanceled  ImportJobStatus = "canceled"
+)
+
+// ImportJobError records a rejected row for progress polling
+type ImportJobError struct {
+	Row    int    `json:"row"`
+	Title  string `json:"title,omitempty"`
+	Reason string `json:"reason"`
+}
+
+type ImportJob struct {
+	ID              string           `json:"id"`
+	UserID          int              `json:"-"`
+	Status          ImportJobStatus  `json:"status"`
+	FileData        []byte           `json:"-"` // Only loaded when the job is claimed
+	ColumnMapping   json.RawMessage  `json:"columnMapping,omitempty"`
+	TotalRows       int              `json:"totalRows"`
+	ProcessedRows   int              `json:"processedRows"`
+	InsertedRows    int              `json:"insertedRows"`
+	DuplicateRows   int              `json:"duplicateRows"`
+	RejectedRows    int              `json:"rejectedRows"`
+	Errors          []ImportJobError `json:"errors"`
+	FailureReason   string           `json:"failureReason,omitempty"`
+	CancelRequested bool             `json:"cancelRequested"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	UpdatedAt       time.Time        `json:"updatedAt"`
+	StartedAt       *time.Time       `json:"startedAt,omitempty"`
+	CompletedAt     *time.Time       `json:"completedAt,omitempty"`
+}
+
+type ImportJobRepository interface {
+	CreateJob(ctx context.Context, userID int, fileData []byte, columnMapping json.RawMessage) (*ImportJob, error)
+	GetJobByID(ctx context.Context, jobID string, userID int) (*ImportJob, error)
+	ClaimNextPendingJob(ctx context.Context) (*ImportJob, error)
+	SetTotalRows(ctx context.Context, jobID string, totalRows int) error
+	UpdateProgress(ctx context.Context, job *ImportJob) (bool, error)
+	FinishJob(ctx context.Context, job *ImportJob) error
+	RequestCancel(ctx context.Context, jobID string, userID int) (*ImportJob, error)
+	Heartbeat(ctx context.Context, jobID string) error
+	FailInterruptedJobs(ctx context.Context, staleAfter time.Duration) (int64, error)
+}
+
+type ImportJobRepositoryImpl struct {
+	DB     *sql.DB
+	Logger *slog.Logger
+}
+
+func NewImportJobRepository(db *sql.DB, logger *slog.Logger) (ImportJobRepository, error) {
+	if db == nil || logger == nil {
+		return nil, fmt.Errorf("import job repository, database or logger is nil")
+	}
+
+	return &ImportJobRepositoryImpl{
+		DB:     db,
+		Logger: logger,
+	}, nil
+}
+
+const importJobColumns = `
+	id, user_id, status, column_mapping, total_rows, processed_rows, inserted_rows,
+	duplicate_rows, rejected_rows, errors, failure_reason, cancel_requested,
+	created_at, updated_at, started_at, completed_at`
+
+// CreateJob queues an uploaded file, columnMapping is the optional user-supplied header mapping.
+// The file is stored on the job, whichever instance claims it may not be the one that received the upload
+func (r *ImportJobRepositoryImpl) CreateJob(ctx context.Context, userID int, fileData []byte, columnMapping json.RawMessage) (*ImportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO import_jobs (id, user_id, status, file_data, column_mapping)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING` + importJobColumns
+
+	// Store SQL NULL rather than an empty JSONB value when no mapping was sent
+	var mapping interface{}
+	if len(columnMapping) > 0 {
+		mapping = []byte(columnMapping)
+	}
+
+	job, err := scanImportJob(r.DB.QueryRowContext(ctx, query, uuid.New().String(), userID, ImportJobPending, fileData, mapping))
+	if err != nil {
+		r.Logger.Error("Error creating import job", "error", err, "userID", userID)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *ImportJobRepositoryImpl) GetJobByID(ctx context.Context, jobID string, userID int) (*ImportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `SELECT` + importJobColumns + ` FROM import_jobs WHERE id = $1 AND user_id = $2`
+
+	job, err := scanImportJob(r.DB.QueryRowContext(ctx, query, jobID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrImportJobNotFound
+	}
+	if err != nil {
+		r.Logger.Error("Error fetching import job", "error", err, "jobID", jobID)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ClaimNextPendingJob marks the oldest pending job as running and loads its file, returns nil when the queue is empty
+func (r *ImportJobRepositoryImpl) ClaimNextPendingJob(ctx context.Context) (*ImportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE import_jobs
+		SET status = $1, started_at = NOW(), updated_at = NOW(), heartbeat_at = NOW()
+		WHERE id = (
+			SELECT id FROM import_jobs
+			WHERE status = $2
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING` + importJobColumns + `, file_data`
+
+	var fileData []byte
+	job, err := scanImportJob(r.DB.QueryRowContext(ctx, query, ImportJobRunning, ImportJobPending), &fileData)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.Logger.Error("Error claiming import job", "error", err)
+		return nil, err
+	}
+
+	job.FileData = fileData
+	return job, nil
+}
+
+func (r *ImportJobRepositoryImpl) SetTotalRows(ctx context.Context, jobID string, totalRows int) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `UPDATE import_jobs SET total_rows = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := r.DB.ExecContext(ctx, query, totalRows, jobID); err != nil {
+		r.Logger.Error("Error setting import job total rows", "error", err, "jobID", jobID)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateProgress persists row counters and errors, returns whether the user requested cancellation
+func (r *ImportJobRepositoryImpl) UpdateProgress(ctx context.Context, job *ImportJob) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	errorsJSON, err := json.Marshal(job.Errors)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal import errors: %w", err)
+	}
+
+	query := `
+		UPDATE import_jobs
+		SET processed_rows = $1, inserted_rows = $2, duplicate_rows = $3,
+			rejected_rows = $4, errors = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING cancel_requested`
+
+	var cancelRequested bool
+	err = r.DB.QueryRowContext(ctx, query,
+		job.ProcessedRows,
+		job.InsertedRows,
+		job.DuplicateRows,
+		job.RejectedRows,
+		errorsJSON,
+		job.ID,
+	).Scan(&cancelRequested)
+	if err != nil {
+		r.Logger.Error("Error updating import job progress", "error", err, "jobID", job.ID)
+		return false, err
+	}
+
+	return cancelRequested, nil
+}
+
+// FinishJob writes the final counters along with a terminal status, the stored file is no longer needed.
+// Returns ErrImportJobNotRunning when the job was already finished, FailInterruptedJobs may have
+// failed it while its worker was stalled
+func (r *ImportJobRepositoryImpl) FinishJob(ctx context.Context, job *ImportJob) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	errorsJSON, err := json.Marshal(job.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal import errors: %w", err)
+	}
+
+	query := `
+		UPDATE import_jobs
+		SET status = $1, processed_rows = $2, inserted_rows = $3, duplicate_rows = $4,
+			rejected_rows = $5, errors = $6, failure_reason = $7, file_data = NULL,
+			updated_at = NOW(), completed_at = NOW()
+		WHERE id = $8 AND status = $9`
+
+	result, err := r.DB.ExecContext(ctx, query,
+		job.Status,
+		job.ProcessedRows,
+		job.InsertedRows,
+		job.DuplicateRows,
+		job.RejectedRows,
+		errorsJSON,
+		job.FailureReason,
+		job.ID,
+		ImportJobRunning,
+	)
+	if err != nil {
+		r.Logger.Error("Error finishing import job", "error", err, "jobID", job.ID, "status", job.Status)
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		r.Logger.Warn("Import job was already finished elsewhere, keeping its status", "jobID", job.ID, "status", job.Status)
+		return ErrImportJobNotRunning
+	}
+
+	return nil
+}
+
+// RequestCancel cancels a pending job outright and flags a running job for the worker to stop
+func (r *ImportJobRepositoryImpl) RequestCancel(ctx context.Context, jobID string, userID int) (*ImportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE import_jobs
+		SET cancel_requested = TRUE,
+			status = CASE WHEN status = $1 THEN $2 ELSE status END,
+			completed_at = CASE WHEN status = $1 THEN NOW() ELSE completed_at END,
+			file_data = CASE WHEN status = $1 THEN NULL ELSE file_data END,
+			updated_at = NOW()
+		WHERE id = $3 AND user_id = $4 AND status IN ($1, $5)
+		RETURNING` + importJobColumns
+
+	job, err := scanImportJob(r.DB.QueryRowContext(ctx, query,
+		ImportJobPending,
+		ImportJobCanceled,
+		jobID,
+		userID,
+		ImportJobRunning,
+	))
+	if err == sql.ErrNoRows {
+		// Either the job doesn't exist for this user or it has already finished
+		return r.GetJobByID(ctx, jobID, userID)
+	}
+	if err != nil {
+		r.Logger.Error("Error canceling import job", "error", err, "jobID", jobID)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Heartbeat tells other instances the worker running the job is still alive
+func (r *ImportJobRepositoryImpl) Heartbeat(ctx context.Context, jobID string) error {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	query := `UPDATE import_jobs SET heartbeat_at = NOW() WHERE id = $1 AND status = $2`
+	if _, err := r.DB.ExecContext(ctx, query, jobID, ImportJobRunning); err != nil {
+		r.Logger.Error("Error recording import job heartbeat", "error", err, "jobID", jobID)
+		return err
+	}
+
+	return nil
+}
+
+// FailInterruptedJobs fails running jobs without a heartbeat for staleAfter, their worker's process is gone.
+// Jobs other instances are still running keep beating, so they're left alone
+func (r *ImportJobRepositoryImpl) FailInterruptedJobs(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbconfig.DBTimeout)
+	defer cancel()
+
+	// Jobs claimed before heartbeats existed fall back to their start time
+	query := `
+		UPDATE import_jobs
+		SET status = $1, failure_reason = $2, file_data = NULL, updated_at = NOW(), completed_at = NOW()
+		WHERE status = $3 AND COALESCE(heartbeat_at, started_at, updated_at) < NOW() - $4 * INTERVAL '1 second'`
+
+	result, err := r.DB.ExecContext(ctx, query,
+		ImportJobFailed,
+		"import interrupted by server restart",
+		ImportJobRunning,
+		staleAfter.Seconds(),
+	)
+	if err != nil {
+		r.Logger.Error("Error failing interrupted import jobs", "error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Helper fn to scan a single import job row, extra holds destinations for columns after importJobColumns
+func scanImportJob(row *sql.Row, extra ...interface{}) (*ImportJob, error) {
+	var job ImportJob
+	var errorsJSON, columnMapping []byte
+	var startedAt, completedAt sql.NullTime
+
+	dest := []interface{}{
+		&job.ID,
+		&job.UserID,
+		&job.Status,
+		&columnMapping,
+		&job.TotalRows,
+		&job.ProcessedRows,
+		&job.InsertedRows,
+		&job.DuplicateRows,
+		&job.RejectedRows,
+		&errorsJSON,
+		&job.FailureReason,
+		&job.CancelRequested,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&startedAt,
+		&completedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if len(columnMapping) > 0 {
+		job.ColumnMapping = json.RawMessage(columnMapping)
+	}
+
+	job.Errors = []ImportJobError{}
+	if len(errorsJSON) > 0 {
+		if err := json.Unmarshal(errorsJSON, &job.Errors); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal import errors: %w", err)
+		}
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A database/sql driver that records each statement and answers with scripted rows or a rows affected count
+type fakeJobConn struct {
+	statements []fakeJobStatement
+	respond    func(query string) fakeJobResult
+}
+
+type fakeJobStatement struct {
+	query string
+	args  []driver.Value
+}
+
+type fakeJobResult struct {
+	rows         [][]driver.Value
+	rowsAffected int64
+}
+
+func (c *fakeJobConn) Connect(ctx context.Context) (driver.Conn, error) { return c, nil }
+func (c *fakeJobConn) Driver() driver.Driver                            { return c }
+func (c *fakeJobConn) Open(name string) (driver.Conn, error)            { return c, nil }
+func (c *fakeJobConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c *fakeJobConn) Close() error              { return nil }
+func (c *fakeJobConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (c *fakeJobConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(c.record(query, args).rowsAffected), nil
+}
+
+func (c *fakeJobConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeJobRows{rows: c.record(query, args).rows}, nil
+}
+
+func (c *fakeJobConn) record(query string, args []driver.NamedValue) fakeJobResult {
+	statement := fakeJobStatement{query: strings.Join(strings.Fields(query), " ")}
+	for _, arg := range args {
+		statement.args = append(statement.args, arg.Value)
+	}
+	c.statements = append(c.statements, statement)
+
+	if c.respond == nil {
+		return fakeJobResult{}
+	}
+	return c.respond(statement.query)
+}
+
+type fakeJobRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeJobRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{"id"}
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeJobRows) Close() error { return nil }
+
+func (r *fakeJobRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+func newTestImportJobRepo(t *testing.T, conn *fakeJobConn) ImportJobRepository {
+	t.Helper()
+
+	db := sql.OpenDB(conn)
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := NewImportJobRepository(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error creating import job repository: %v", err)
+	}
+	return repo
+}
+
+func TestImportJobFinishJobKeepsJobsFinishedElsewhere(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		wantErr      error
+	}{
+		{name: "still running", rowsAffected: 1},
+		{name: "already failed as interrupted", rowsAffected: 0, wantErr: ErrImportJobNotRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeJobConn{respond: func(query string) fakeJobResult {
+				return fakeJobResult{rowsAffected: tt.rowsAffected}
+			}}
+			repo := newTestImportJobRepo(t, conn)
+
+			err := repo.FinishJob(context.Background(), &ImportJob{ID: "job-1", Status: ImportJobCompleted})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+
+			statement := conn.statements[0]
+			if !strings.Contains(statement.query, "WHERE id = $8 AND status = $9") {
+				t.Errorf("expected the update to require a running job, got %s", statement.query)
+			}
+			if statement.args[0] != string(ImportJobCompleted) || statement.args[8] != string(ImportJobRunning) {
+				t.Errorf("unexpected arguments %v", statement.args)
+			}
+		})
+	}
+}
+
+func newTestImportJobRow(status ImportJobStatus, extra ...driver.Value) []driver.Value {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	row := []driver.Value{
+		"job-1", int64(1), string(status), nil, int64(0), int64(0), int64(0),
+		int64(0), int64(0), []byte("[]"), "", false,
+		now, now, now, nil,
+	}
+	return append(row, extra...)
+}
+
+func TestImportJobClaimNextPendingJob(t *testing.T) {
+	t.Run("claims the oldest pending job with its file", func(t *testing.T) {
+		conn := &fakeJobConn{respond: func(query string) fakeJobResult {
+			return fakeJobResult{rows: [][]driver.Value{newTestImportJobRow(ImportJobRunning, []byte("Title\nKindred\n"))}}
+		}}
+		repo := newTestImportJobRepo(t, conn)
+
+		job, err := repo.ClaimNextPendingJob(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if job.ID != "job-1" || job.Status != ImportJobRunning || string(job.FileData) != "Title\nKindred\n" {
+			t.Errorf("unexpected job %+v", job)
+		}
+
+		// Instances polling at once skip rows another claim has locked instead of waiting on them
+		statement := conn.statements[0]
+		for _, want := range []string{"ORDER BY created_at FOR UPDATE SKIP LOCKED LIMIT 1", "heartbeat_at = NOW()", "completed_at, file_data"} {
+			if !strings.Contains(statement.query, want) {
+				t.Errorf("expected %q in the claim, got %s", want, statement.query)
+			}
+		}
+		if statement.args[0] != string(ImportJobRunning) || statement.args[1] != string(ImportJobPending) {
+			t.Errorf("unexpected arguments %v", statement.args)
+		}
+	})
+
+	t.Run("empty queue", func(t *testing.T) {
+		repo := newTestImportJobRepo(t, &fakeJobConn{})
+
+		job, err := repo.ClaimNextPendingJob(context.Background())
+		if err != nil || job != nil {
+			t.Errorf("expected no job and no error, got %+v, %v", job, err)
+		}
+	})
+}
+
+func TestImportJobUpdateProgressReportsCancelRequests(t *testing.T) {
+	for _, cancelRequested := range []bool{false, true} {
+		conn := &fakeJobConn{respond: func(query string) fakeJobResult {
+			return fakeJobResult{rows: [][]driver.Value{{cancelRequested}}}
+		}}
+		repo := newTestImportJobRepo(t, conn)
+
+		got, err := repo.UpdateProgress(context.Background(), &ImportJob{ID: "job-1", ProcessedRows: 3, InsertedRows: 2, RejectedRows: 1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != cancelRequested {
+			t.Errorf("expected cancel requested %v, got %v", cancelRequested, got)
+		}
+		if !strings.Contains(conn.statements[0].query, "RETURNING cancel_requested") {
+			t.Errorf("expected the update to return the cancel flag, got %s", conn.statements[0].query)
+		}
+	}
+}
+
+func TestImportJobRequestCancel(t *testing.T) {
+	t.Run("pending job is canceled outright", func(t *testing.T) {
+		conn := &fakeJobConn{respond: func(query string) fakeJobResult {
+			return fakeJobResult{rows: [][]driver.Value{newTestImportJobRow(ImportJobCanceled)}}
+		}}
+		repo := newTestImportJobRepo(t, conn)
+
+		job, err := repo.RequestCancel(context.Background(), "job-1", 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if job.Status != ImportJobCanceled {
+			t.Errorf("expected canceled, got %s", job.Status)
+		}
+
+		statement := conn.statements[0]
+		if !strings.Contains(statement.query, "file_data = CASE WHEN status = $1 THEN NULL ELSE file_data END") {
+			t.Errorf("expected a canceled pending job to drop its file, got %s", statement.query)
+		}
+		want := []driver.Value{string(ImportJobPending), string(ImportJobCanceled), "job-1", int64(1), string(ImportJobRunning)}
+		if !reflect.DeepEqual(statement.args, want) {
+			t.Errorf("expected arguments %v, got %v", want, statement.args)
+		}
+	})
+
+	t.Run("finished job is returned unchanged", func(t *testing.T) {
+		conn := &fakeJobConn{respond: func(query string) fakeJobResult {
+			if strings.HasPrefix(query, "SELECT") {
+				return fakeJobResult{rows: [][]driver.Value{newTestImportJobRow(ImportJobCompleted)}}
+			}
+			return fakeJobResult{}
+		}}
+		repo := newTestImportJobRepo(t, conn)
+
+		job, err := repo.RequestCancel(context.Background(), "job-1", 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if job.Status != ImportJobCompleted || len(conn.statements) != 2 {
+			t.Errorf("expected the completed job read back, got %s after %d statements", job.Status, len(conn.statements))
+		}
+	})
+}
+
+func TestImportJobHeartbeatOnlyTouchesRunningJobs(t *testing.T) {
+	conn := &fakeJobConn{}
+	repo := newTestImportJobRepo(t, conn)
+
+	if err := repo.Heartbeat(context.Background(), "job-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statement := conn.statements[0]
+	if statement.query != "UPDATE import_jobs SET heartbeat_at = NOW() WHERE id = $1 AND status = $2" {
+		t.Errorf("unexpected heartbeat %s", statement.query)
+	}
+	if !reflect.DeepEqual(statement.args, []driver.Value{"job-1", string(ImportJobRunning)}) {
+		t.Errorf("unexpected arguments %v", statement.args)
+	}
+}
+
+func TestImportJobFailInterruptedJobs(t *testing.T) {
+	conn := &fakeJobConn{respond: func(query string) fakeJobResult {
+		return fakeJobResult{rowsAffected: 2}
+	}}
+	repo := newTestImportJobRepo(t, conn)
+
+	failed, err := repo.FailInterruptedJobs(context.Background(), 3*time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if failed != 2 {
+		t.Errorf("expected 2 failed jobs, got %d", failed)
+	}
+
+	statement := conn.statements[0]
+	if !strings.Contains(statement.query, "COALESCE(heartbeat_at, started_at, updated_at) < NOW() - $4 * INTERVAL '1 second'") {
+		t.Errorf("expected jobs judged by their last heartbeat, got %s", statement.query)
+	}
+	want := []driver.Value{string(ImportJobFailed), "import interrupted by server restart", string(ImportJobRunning), float64(180)}
+	if !reflect.DeepEqual(statement.args, want) {
+		t.Errorf("expected arguments %v, got %v", want, statement.args)
+	}
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Leading articles dropped from titles before comparing, "The Hobbit" and "Hobbit" are the same book
+var libraryTitleArticles = map[string]bool{
+	"a":   true,
+	"an":  true,
+	"the": true,
+}
+
+// How alike two normalized titles or surnames must be for a fuzzy match, one typo in a title of seven or
+// more letters, or in a surname of five or more
+const (
+	libraryTitleSimilarity   = 0.85
+	librarySurnameSimilarity = 0.8
+)
+
+// LibraryMatcher answers "is this book already in the user's library" without a scan per lookup.
+// Books are indexed by ISBN, with every ISBN-10 converted to its ISBN-13, by a normalized title
+// and by author surname
+type LibraryMatcher struct {
+	byISBN    map[string]int
+	byTitle   map[string][]libraryMatchCandidate
+	bySurname map[string][]libraryMatchCandidate
+}
+
+type libraryMatchCandidate struct {
+	bookID   int
+	title    string
+	surnames map[string]bool
+}
+
+// LibraryMatchEntry is the subset of a local book the matcher indexes
+type LibraryMatchEntry struct {
+	BookID  int
+	Title   string
+	ISBN10  string
+	ISBN13  string
+	Authors []string
+}
+
+func NewLibraryMatcher(entries []LibraryMatchEntry) *LibraryMatcher {
+	matcher := &LibraryMatcher{
+		byISBN:    make(map[string]int, len(entries)),
+		byTitle:   make(map[string][]libraryMatchCandidate, len(entries)),
+		bySurname: make(map[string][]libraryMatchCandidate, len(entries)),
+	}
+
+	for _, entry := range entries {
+		for _, isbn := range []string{entry.ISBN13, entry.ISBN10} {
+			if key := NormalizeISBN13(isbn); key != "" {
+				if _, exists := matcher.byISBN[key]; !exists {
+					matcher.byISBN[key] = entry.BookID
+				}
+			}
+		}
+
+		key := libraryTitleKey(entry.Title)
+		if key == "" {
+			continue
+		}
+		candidate := libraryMatchCandidate{
+			bookID:   entry.BookID,
+			title:    key,
+			surnames: librarySurnames(entry.Authors),
+		}
+		matcher.byTitle[key] = append(matcher.byTitle[key], candidate)
+		for surname := range candidate.surnames {
+			matcher.bySurname[surname] = append(matcher.bySurname[surname], candidate)
+		}
+	}
+
+	return matcher
+}
+
+// Match returns the ID of the local copy of book. ISBNs are tried first, then titles that are equal once
+// normalized by libraryTitleKey, together with at least one shared author surname. Title alone isn't enough,
+// too many different books share one. Failing that, one side may be misspelled: a title that's nearly
+// equal to one by an author with the same surname, or the same title with a nearly equal surname
+func (m *LibraryMatcher) Match(book Book) (int, bool) {
+	for _, isbn := range []string{book.ISBN13, book.ISBN10} {
+		if bookID, ok := m.byISBN[NormalizeISBN13(isbn)]; ok {
+			return bookID, true
+		}
+	}
+
+	title := libraryTitleKey(book.Title)
+	if title == "" {
+		return 0, false
+	}
+	surnames := librarySurnames(book.Authors)
+
+	candidates := m.byTitle[title]
+	for _, candidate := range candidates {
+		for surname := range surnames {
+			if candidate.surnames[surname] {
+				return candidate.bookID, true
+			}
+		}
+	}
+
+	// Fuzzy matching only compares against books that already share the title or a surname.
+	// The closest wins, ties go to the lowest book ID so the result doesn't depend on map order
+	bestID, bestScore := 0, 0.0
+	consider := func(bookID int, score float64, threshold float64) {
+		if score >= threshold && (score > bestScore || (score == bestScore && bookID < bestID)) {
+			bestID, bestScore = bookID, score
+		}
+	}
+	for surname := range surnames {
+		for _, candidate := range m.bySurname[surname] {
+			consider(candidate.bookID, NameSimilarity(title, candidate.title), libraryTitleSimilarity)
+		}
+	}
+	for _, candidate := range candidates {
+		for surname := range surnames {
+			for candidateSurname := range candidate.surnames {
+				consider(candidate.bookID, NameSimilarity(surname, candidateSurname), librarySurnameSimilarity)
+			}
+		}
+	}
+
+	return bestID, bestScore > 0
+}
+
+// NormalizeISBN13 returns the ISBN-13 for a valid ISBN-10 or ISBN-13, ignoring hyphens and spaces.
+// Anything else, including an ISBN with a bad check digit, comes back empty
+func NormalizeISBN13(isbn string) string {
+	isbn = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+
+	switch len(isbn) {
+	case 10:
+		if check, ok := ISBN10CheckDigit(isbn[:9]); !ok || check != isbn[9] {
+			return ""
+		}
+		isbn13 := "978" + isbn[:9]
+		check, _ := ISBN13CheckDigit(isbn13)
+		return isbn13 + string(check)
+	case 13:
+		if check, ok := ISBN13CheckDigit(isbn[:12]); !ok || check != isbn[12] {
+			return ""
+		}
+		return isbn
+	}
+	return ""
+}
+
+// ISBN13ToISBN10 converts a 978-prefixed ISBN-13 back to an ISBN-10, 979 ISBNs have no ISBN-10 form
+func ISBN13ToISBN10(isbn string) string {
+	isbn = NormalizeISBN13(isbn)
+	if !strings.HasPrefix(isbn, "978") {
+		return ""
+	}
+	check, _ := ISBN10CheckDigit(isbn[3:12])
+	return isbn[3:12] + string(check)
+}
+
+// ISBN10CheckDigit returns the check digit for the first nine digits of an ISBN-10, 'X' for ten.
+// False when those aren't all digits, utils.IsValidISBN10 and IsValidISBN13 share it
+func ISBN10CheckDigit(digits string) (byte, bool) {
+	if len(digits) < 9 {
+		return 0, false
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		digit := int(digits[i]) - '0'
+		if digit < 0 || digit > 9 {
+			return 0, false
+		}
+		sum += digit * (10 - i)
+	}
+
+	switch checksum := (11 - sum%11) % 11; checksum {
+	case 10:
+		return 'X', true
+	default:
+		return byte('0' + checksum), true
+	}
+}
+
+// ISBN13CheckDigit returns the check digit for the first twelve digits of an ISBN-13.
+// False when those aren't all digits, utils.IsValidISBN10 and IsValidISBN13 share it
+func ISBN13CheckDigit(digits string) (byte, bool) {
+	if len(digits) < 12 {
+		return 0, false
+	}
+
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(digits[i]) - '0'
+		if digit < 0 || digit > 9 {
+			return 0, false
+		}
+		if i%2 == 0 {
+			sum += digit
+		} else {
+			sum += 3 * digit
+		}
+	}
+	return byte('0' + (10-sum%10)%10), true
+}
+
+// Helper fn: lowercase words without accents or punctuation, subtitle and leading article dropped
+func libraryTitleKey(title string) string {
+	if main, _, found := strings.Cut(title, ":"); found {
+		title = main
+	}
+
+	words := libraryMatchWords(title)
+	if len(words) > 1 && libraryTitleArticles[words[0]] {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// Helper fn: last word of each author's name, "Le Guin, Ursula K." is flipped first
+func librarySurnames(authors []string) map[string]bool {
+	surnames := make(map[string]bool, len(authors))
+	for _, author := range authors {
+		if last, first, found := strings.Cut(author, ","); found {
+			author = first + " " + last
+		}
+		if words := libraryMatchWords(author); len(words) > 0 {
+			surnames[words[len(words)-1]] = true
+		}
+	}
+	return surnames
+}
+
+func libraryMatchWords(text string) []string {
+	var folded strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == '\'' || r == '’':
+			continue // "O'Brien" and "OBrien" should agree
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			folded.WriteRune(r)
+		default:
+			folded.WriteRune(' ')
+		}
+	}
+	return strings.Fields(folded.String())
+}
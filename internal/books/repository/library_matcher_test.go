@@ -0,0 +1,128 @@
+package repository
+
+import "testing"
+
+func TestNormalizeISBN13(t *testing.T) {
+	tests := map[string]string{
+		"9780807083055":     "9780807083055",
+		"978-0-306-40615-7": "9780306406157",
+		"0807083054":        "9780807083055",
+		"0 306 40615 2":     "9780306406157",
+		"080442957X":        "9780804429573",
+		"080442957x":        "9780804429573",
+		"9791090636071":     "9791090636071",
+		"9780807083056":     "", // Bad check digit
+		"0807083055":        "",
+		"08070830X4":        "",
+		"978080708305":      "",
+		"":                  "",
+		"kindred":           "",
+	}
+
+	for input, want := range tests {
+		if got := NormalizeISBN13(input); got != want {
+			t.Errorf("NormalizeISBN13(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestISBN13ToISBN10(t *testing.T) {
+	tests := map[string]string{
+		"9780807083055":     "0807083054",
+		"978-0-306-40615-7": "0306406152",
+		"9780804429573":     "080442957X",
+		"0807083054":        "0807083054", // Already an ISBN-10
+		"9791090636071":     "",           // 979 ISBNs have no ISBN-10
+		"9780807083056":     "",
+		"":                  "",
+	}
+
+	for input, want := range tests {
+		if got := ISBN13ToISBN10(input); got != want {
+			t.Errorf("ISBN13ToISBN10(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestLibraryMatcherMatch(t *testing.T) {
+	matcher := NewLibraryMatcher([]LibraryMatchEntry{
+		{BookID: 1, Title: "Kindred", ISBN10: "0807083054", Authors: []string{"Octavia E. Butler"}},
+		{BookID: 2, Title: "The Left Hand of Darkness", Authors: []string{"Le Guin, Ursula K."}},
+		{BookID: 3, Title: "Cien años de soledad", Authors: []string{"Gabriel García Márquez"}},
+		{BookID: 4, Title: "Dubliners", Authors: []string{"James Joyce"}},
+		{BookID: 5, Title: "Dubliners", Authors: []string{"Tim O'Brien"}},
+	})
+
+	tests := []struct {
+		name   string
+		book   Book
+		wantID int
+		wantOK bool
+	}{
+		{
+			name:   "ISBN-13 matches a stored ISBN-10",
+			book:   Book{Title: "Something else entirely", ISBN13: "978-0-8070-8305-5"},
+			wantID: 1,
+			wantOK: true,
+		},
+		{
+			name:   "leading article and subtitle are ignored",
+			book:   Book{Title: "Left Hand of Darkness: A Novel", Authors: []string{"Ursula K. Le Guin"}},
+			wantID: 2,
+			wantOK: true,
+		},
+		{
+			name:   "accents and case are folded",
+			book:   Book{Title: "CIEN ANOS DE SOLEDAD", Authors: []string{"Gabriel Garcia Marquez"}},
+			wantID: 3,
+			wantOK: true,
+		},
+		{
+			name:   "shared title picks the author with the same surname",
+			book:   Book{Title: "Dubliners", Authors: []string{"Tim OBrien"}},
+			wantID: 5,
+			wantOK: true,
+		},
+		{
+			name: "title without a shared surname",
+			book: Book{Title: "Kindred", Authors: []string{"Someone Else"}},
+		},
+		{
+			name:   "misspelled title by an author with the same surname",
+			book:   Book{Title: "Kindread", Authors: []string{"Octavia Butler"}},
+			wantID: 1,
+			wantOK: true,
+		},
+		{
+			name:   "misspelled surname on the same title",
+			book:   Book{Title: "Cien años de soledad", Authors: []string{"Gabriel García Marques"}},
+			wantID: 3,
+			wantOK: true,
+		},
+		{
+			name: "misspelled title and surname",
+			book: Book{Title: "Kindread", Authors: []string{"Octavia E. Butlr"}},
+		},
+		{
+			name: "another book by the same author",
+			book: Book{Title: "Kindling", Authors: []string{"Octavia E. Butler"}},
+		},
+		{
+			name: "short surnames need an exact match",
+			book: Book{Title: "Dubliners", Authors: []string{"James Jones"}},
+		},
+		{
+			name: "invalid ISBN falls back to the title",
+			book: Book{Title: "Unknown", ISBN13: "9780807083056", Authors: []string{"Octavia E. Butler"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookID, ok := matcher.Match(tt.book)
+			if bookID != tt.wantID || ok != tt.wantOK {
+				t.Errorf("expected (%d, %v), got (%d, %v)", tt.wantID, tt.wantOK, bookID, ok)
+			}
+		})
+	}
+}
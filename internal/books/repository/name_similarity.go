@@ -0,0 +1,39 @@
+package repository
+
+// NameSimilarity is 1 minus the edit distance relative to the longer string. Swapped neighbouring letters
+// count as one edit, "Tolkein" is a typo for "Tolkien" rather than two changes
+func NameSimilarity(a string, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ar, br := []rune(a), []rune(b)
+	longest := max(len(ar), len(br))
+	if longest == 0 {
+		return 1
+	}
+
+	// Three rows of the optimal string alignment matrix
+	beforePrevious := make([]int, len(br)+1)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				current[j] = min(current[j], beforePrevious[j-2]+1)
+			}
+		}
+		beforePrevious, previous, current = previous, current, beforePrevious
+	}
+
+	return 1 - float64(previous[len(br)])/float64(longest)
+}
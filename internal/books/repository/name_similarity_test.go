@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "jrr tolkien", b: "jrr tolkien", want: 1},
+		{a: "", b: "", want: 1},
+		{a: "tolkien", b: "tolkein", want: 1 - 1.0/7}, // Swapped letters are one edit
+		{a: "kitten", b: "sitting", want: 1 - 3.0/7},
+		{a: "tolkien", b: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		got := NameSimilarity(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("NameSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if reversed := NameSimilarity(tt.b, tt.a); reversed != got {
+			t.Errorf("NameSimilarity is not symmetric for %q and %q: %v and %v", tt.a, tt.b, got, reversed)
+		}
+	}
+}
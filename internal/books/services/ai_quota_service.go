@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+var ErrAIQuotaExceeded = errors.New("daily AI usage quota exceeded")
+
+// AIQuotaLimits caps each user's AI use per UTC day, zero means no limit
+type AIQuotaLimits struct {
+	DailyTokens   int
+	DailyRequests int
+}
+
+// AIUsageReport is a user's AI use today against their quota. Remaining counts are null when there's no limit
+type AIUsageReport struct {
+	Date              string    `json:"date"`
+	Requests          int       `json:"requests"`
+	PromptTokens      int       `json:"promptTokens"`
+	OutputTokens      int       `json:"outputTokens"`
+	TotalTokens       int       `json:"totalTokens"`
+	TokenLimit        int       `json:"tokenLimit"`
+	RequestLimit      int       `json:"requestLimit"`
+	RemainingTokens   *int      `json:"remainingTokens"`
+	RemainingRequests *int      `json:"remainingRequests"`
+	ResetsAt          time.Time `json:"resetsAt"`
+}
+
+// AIQuotaExceededError carries the usage that broke the quota, it matches ErrAIQuotaExceeded with errors.Is
+type AIQuotaExceededError struct {
+	Usage *AIUsageReport
+}
+
+func (e *AIQuotaExceededError) Error() string {
+	return fmt.Sprintf("%v, resets at %s", ErrAIQuotaExceeded, e.Usage.ResetsAt.Format(time.RFC3339))
+}
+
+func (e *AIQuotaExceededError) Unwrap() error {
+	return ErrAIQuotaExceeded
+}
+
+// AIQuotaReservation is one request counted against a user's quota, its tokens are added once they're known
+type AIQuotaReservation struct {
+	UserID int
+	Day    time.Time
+}
+
+// AIQuotaService counts the requests and tokens each user spends on AI per day and enforces the daily limits
+type AIQuotaService interface {
+	// ReserveRequest counts a request before it's sent, returning an *AIQuotaExceededError once the user has nothing left today
+	ReserveRequest(ctx context.Context, userID int) (*AIQuotaReservation, error)
+	RecordUsage(ctx context.Context, reservation *AIQuotaReservation, promptTokens int, outputTokens int) error
+	GetUsage(ctx context.Context, userID int) (*AIUsageReport, error)
+}
+
+type AIQuotaServiceImpl struct {
+	logger    *slog.Logger
+	usageRepo repository.AIUsageRepository
+	limits    AIQuotaLimits
+}
+
+func NewAIQuotaService(logger *slog.Logger, usageRepo repository.AIUsageRepository, limits AIQuotaLimits) (AIQuotaService, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	if usageRepo == nil {
+		return nil, fmt.Errorf("ai quota service, usage repository is nil")
+	}
+
+	return &AIQuotaServiceImpl{
+		logger:    logger,
+		usageRepo: usageRepo,
+		limits:    limits,
+	}, nil
+}
+
+// ReserveRequest is atomic, so the request limit holds under concurrent requests. Tokens are only known
+// once a request finishes, so requests already in flight can still take the day's total past the token limit
+func (s *AIQuotaServiceImpl) ReserveRequest(ctx context.Context, userID int) (*AIQuotaReservation, error) {
+	day := aiUsageDay(time.Now())
+
+	_, err := s.usageRepo.ReserveDailyRequest(ctx, userID, day, s.limits.DailyRequests, s.limits.DailyTokens)
+	if errors.Is(err, repository.ErrAIUsageLimitReached) {
+		report, reportErr := s.GetUsage(ctx, userID)
+		if reportErr != nil {
+			return nil, reportErr
+		}
+		s.logger.Warn("AI quota exceeded", "userID", userID, "requests", report.Requests, "totalTokens", report.TotalTokens)
+		return nil, &AIQuotaExceededError{Usage: report}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &AIQuotaReservation{UserID: userID, Day: day}, nil
+}
+
+// RecordUsage charges the tokens to the day the request was reserved on
+func (s *AIQuotaServiceImpl) RecordUsage(ctx context.Context, reservation *AIQuotaReservation, promptTokens int, outputTokens int) error {
+	return s.usageRepo.AddDailyTokens(ctx, reservation.UserID, reservation.Day, promptTokens, outputTokens)
+}
+
+func (s *AIQuotaServiceImpl) GetUsage(ctx context.Context, userID int) (*AIUsageReport, error) {
+	day := aiUsageDay(time.Now())
+
+	usage, err := s.usageRepo.GetDailyUsage(ctx, userID, day)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AIUsageReport{
+		Date:         day.Format(time.DateOnly),
+		Requests:     usage.Requests,
+		PromptTokens: usage.PromptTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.PromptTokens + usage.OutputTokens,
+		TokenLimit:   s.limits.DailyTokens,
+		RequestLimit: s.limits.DailyRequests,
+		ResetsAt:     day.AddDate(0, 0, 1),
+	}
+	if s.limits.DailyTokens > 0 {
+		remaining := max(s.limits.DailyTokens-report.TotalTokens, 0)
+		report.RemainingTokens = &remaining
+	}
+	if s.limits.DailyRequests > 0 {
+		remaining := max(s.limits.DailyRequests-report.Requests, 0)
+		report.RemainingRequests = &remaining
+	}
+
+	return report, nil
+}
+
+// Helper fn: quotas reset at midnight UTC
+func aiUsageDay(now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// Mirrors the limit check of AIUsageRepositoryImpl.ReserveDailyRequest for one user
+type fakeAIUsageRepo struct {
+	usage repository.AIUsage
+	days  []time.Time
+}
+
+func (f *fakeAIUsageRepo) GetDailyUsage(ctx context.Context, userID int, day time.Time) (*repository.AIUsage, error) {
+	usage := f.usage
+	return &usage, nil
+}
+
+func (f *fakeAIUsageRepo) ReserveDailyRequest(ctx context.Context, userID int, day time.Time, requestLimit int, tokenLimit int) (*repository.AIUsage, error) {
+	if (requestLimit > 0 && f.usage.Requests >= requestLimit) ||
+		(tokenLimit > 0 && f.usage.PromptTokens+f.usage.OutputTokens >= tokenLimit) {
+		return nil, repository.ErrAIUsageLimitReached
+	}
+	f.usage.Requests++
+	usage := f.usage
+	return &usage, nil
+}
+
+func (f *fakeAIUsageRepo) AddDailyTokens(ctx context.Context, userID int, day time.Time, promptTokens int, outputTokens int) error {
+	f.days = append(f.days, day)
+	f.usage.PromptTokens += promptTokens
+	f.usage.OutputTokens += outputTokens
+	return nil
+}
+
+func TestAIQuotaReserveRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		limits        AIQuotaLimits
+		usage         repository.AIUsage
+		wantExceeded  bool
+		wantRemaining func(*AIUsageReport) bool
+	}{
+		{
+			name:   "under both limits",
+			limits: AIQuotaLimits{DailyTokens: 1000, DailyRequests: 5},
+			usage:  repository.AIUsage{Requests: 4, PromptTokens: 400, OutputTokens: 500},
+		},
+		{
+			name:         "request limit reached",
+			limits:       AIQuotaLimits{DailyTokens: 1000, DailyRequests: 5},
+			usage:        repository.AIUsage{Requests: 5, PromptTokens: 100},
+			wantExceeded: true,
+			wantRemaining: func(report *AIUsageReport) bool {
+				return *report.RemainingRequests == 0 && *report.RemainingTokens == 900
+			},
+		},
+		{
+			name:         "token limit reached",
+			limits:       AIQuotaLimits{DailyTokens: 1000},
+			usage:        repository.AIUsage{Requests: 2, PromptTokens: 600, OutputTokens: 500},
+			wantExceeded: true,
+			wantRemaining: func(report *AIUsageReport) bool {
+				return *report.RemainingTokens == 0 && report.RemainingRequests == nil
+			},
+		},
+		{
+			name:  "no limits",
+			usage: repository.AIUsage{Requests: 500, PromptTokens: 1000000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usageRepo := &fakeAIUsageRepo{usage: tt.usage}
+			quota, err := NewAIQuotaService(newTestMetadataLogger(), usageRepo, tt.limits)
+			if err != nil {
+				t.Fatalf("unexpected error creating quota service: %v", err)
+			}
+
+			reservation, err := quota.ReserveRequest(context.Background(), 1)
+			if !tt.wantExceeded {
+				if err != nil || reservation == nil {
+					t.Fatalf("expected a reservation, got %v", err)
+				}
+				if usageRepo.usage.Requests != tt.usage.Requests+1 {
+					t.Errorf("expected the request to be counted")
+				}
+				return
+			}
+
+			var quotaErr *AIQuotaExceededError
+			if !errors.As(err, &quotaErr) || !errors.Is(err, ErrAIQuotaExceeded) {
+				t.Fatalf("expected *AIQuotaExceededError, got %v", err)
+			}
+			if usageRepo.usage.Requests != tt.usage.Requests {
+				t.Errorf("a refused request should not be counted")
+			}
+			if !tt.wantRemaining(quotaErr.Usage) {
+				t.Errorf("unexpected remaining budget in %+v", quotaErr.Usage)
+			}
+			if !quotaErr.Usage.ResetsAt.After(time.Now()) || quotaErr.Usage.ResetsAt.Sub(time.Now()) > 24*time.Hour {
+				t.Errorf("unexpected reset time %v", quotaErr.Usage.ResetsAt)
+			}
+		})
+	}
+}
+
+func TestAIQuotaRecordUsageChargesReservationDay(t *testing.T) {
+	usageRepo := &fakeAIUsageRepo{}
+	quota, _ := NewAIQuotaService(newTestMetadataLogger(), usageRepo, AIQuotaLimits{DailyTokens: 100})
+
+	yesterday := aiUsageDay(time.Now()).AddDate(0, 0, -1)
+	if err := quota.RecordUsage(context.Background(), &AIQuotaReservation{UserID: 1, Day: yesterday}, 40, 80); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(usageRepo.days) != 1 || !usageRepo.days[0].Equal(yesterday) {
+		t.Errorf("expected tokens charged to %v, got %v", yesterday, usageRepo.days)
+	}
+
+	report, _ := quota.GetUsage(context.Background(), 1)
+	if report.TotalTokens != 120 || *report.RemainingTokens != 0 {
+		t.Errorf("expected an overdrawn budget to report 0 remaining, got %+v", report)
+	}
+}
+
+func TestAIQuotaGetUsage(t *testing.T) {
+	repo := &fakeAIUsageRepo{usage: repository.AIUsage{Requests: 3, PromptTokens: 700, OutputTokens: 450}}
+	quota, err := NewAIQuotaService(newTestMetadataLogger(), repo, AIQuotaLimits{DailyTokens: 1000})
+	if err != nil {
+		t.Fatalf("unexpected error creating quota service: %v", err)
+	}
+
+	report, err := quota.GetUsage(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	today := aiUsageDay(time.Now())
+	if report.Date != today.Format(time.DateOnly) || !report.ResetsAt.Equal(today.AddDate(0, 0, 1)) {
+		t.Errorf("expected today's usage resetting at midnight UTC, got %s resetting %v", report.Date, report.ResetsAt)
+	}
+	if report.Requests != 3 || report.TotalTokens != 1150 || report.TokenLimit != 1000 {
+		t.Errorf("unexpected usage %+v", report)
+	}
+	// Requests in flight can overshoot the token limit, what's left never goes below zero
+	if report.RemainingTokens == nil || *report.RemainingTokens != 0 {
+		t.Errorf("expected no tokens remaining, got %v", report.RemainingTokens)
+	}
+	if report.RemainingRequests != nil {
+		t.Errorf("expected no remaining count without a request limit, got %d", *report.RemainingRequests)
+	}
+}
+
+func TestAIUsageDayIsUTC(t *testing.T) {
+	// 23:30 in New York is already the next day in UTC
+	newYork := time.FixedZone("EST", -5*60*60)
+	got := aiUsageDay(time.Date(2026, 3, 9, 23, 30, 0, 0, newYork))
+	if want := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
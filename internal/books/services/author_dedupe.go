@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/transaction"
+)
+
+const DefaultAuthorSimilarity = 0.85
+
+var ErrInvalidAuthorMerge = errors.New("invalid author merge")
+
+// Flips "Last, First" and folds accents the same way the author name rules do
+var authorDedupeNormalizer = repository.NewAuthorNameNormalizer([]repository.AuthorNameRule{
+	{Type: repository.AuthorNameRuleInvertLastFirst},
+	{Type: repository.AuthorNameRuleFoldDiacritics},
+})
+
+// AuthorDuplicateGroup is a set of authors that are probably the same person
+type AuthorDuplicateGroup struct {
+	SuggestedTargetID int                        `json:"suggestedTargetId"` // The spelling with the most books
+	Similarity        float64                    `json:"similarity"`        // Weakest link in the group, 1 when every name normalizes the same
+	Authors           []repository.AuthorSummary `json:"authors"`
+}
+
+type AuthorMergeResult struct {
+	Target          repository.AuthorSummary `json:"target"`
+	MergedIDs       []int                    `json:"mergedIds"`
+	AffectedUserIDs []int                    `json:"-"` // Users whose cached book lists are now stale
+}
+
+type AuthorDedupeService interface {
+	FindDuplicateAuthors(ctx context.Context, minSimilarity float64) ([]AuthorDuplicateGroup, error)
+	MergeAuthors(ctx context.Context, targetID int, sourceIDs []int) (*AuthorMergeResult, error)
+}
+
+type AuthorDedupeServiceImpl struct {
+	logger     *slog.Logger
+	authorRepo repository.AuthorRepository
+	dbManager  transaction.DBManager
+}
+
+func NewAuthorDedupeService(
+	logger *slog.Logger,
+	authorRepo repository.AuthorRepository,
+	dbManager transaction.DBManager,
+) (AuthorDedupeService, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	if authorRepo == nil || dbManager == nil {
+		return nil, fmt.Errorf("author dedupe service, author repository or db manager is nil")
+	}
+
+	return &AuthorDedupeServiceImpl{
+		logger:     logger,
+		authorRepo: authorRepo,
+		dbManager:  dbManager,
+	}, nil
+}
+
+// FindDuplicateAuthors groups authors whose names match once punctuation, initials spacing, accents and
+// "Last, First" order are ignored, or whose normalized names are at least minSimilarity alike.
+// Fuzzy matches are only looked for between names sharing a surname, or sharing given names and
+// the surname's first letter, comparing every pair of authors doesn't scale
+func (s *AuthorDedupeServiceImpl) FindDuplicateAuthors(ctx context.Context, minSimilarity float64) ([]AuthorDuplicateGroup, error) {
+	authors, err := s.authorRepo.ListAuthorSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(authors))
+	buckets := make(map[string][]int)
+	for i, author := range authors {
+		keys[i] = authorDedupeKey(author.Name)
+		words := strings.Fields(keys[i])
+		if len(words) == 0 {
+			continue
+		}
+
+		surname := words[len(words)-1]
+		buckets["surname:"+surname] = append(buckets["surname:"+surname], i)
+		if len(words) > 1 {
+			givenNames := strings.Join(words[:len(words)-1], " ")
+			bucket := "given:" + givenNames + "|" + string([]rune(surname)[0])
+			buckets[bucket] = append(buckets[bucket], i)
+		}
+	}
+
+	groups := newAuthorUnionFind(len(authors))
+	for _, members := range buckets {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				i, j := members[x], members[y]
+				if score := repository.NameSimilarity(keys[i], keys[j]); score >= minSimilarity {
+					groups.union(i, j, score)
+				}
+			}
+		}
+	}
+
+	return groups.duplicateGroups(authors), nil
+}
+
+// MergeAuthors folds sourceIDs into targetID in one transaction, books credited to a source are credited to the target
+func (s *AuthorDedupeServiceImpl) MergeAuthors(ctx context.Context, targetID int, sourceIDs []int) (*AuthorMergeResult, error) {
+	sourceIDs = uniqueAuthorIDs(sourceIDs, targetID)
+	if targetID <= 0 || len(sourceIDs) == 0 {
+		return nil, fmt.Errorf("%w: a target and at least one other author are required", ErrInvalidAuthorMerge)
+	}
+
+	tx, err := s.dbManager.BeginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	authors, err := s.authorRepo.GetAuthorSummaries(ctx, tx, append([]int{targetID}, sourceIDs...))
+	if err != nil {
+		return nil, err
+	}
+
+	affectedUserIDs, err := s.authorRepo.GetAuthorBookOwners(ctx, tx, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sourceID := range sourceIDs {
+		if err := s.authorRepo.MergeAuthors(ctx, tx, sourceID, targetID); err != nil {
+			return nil, fmt.Errorf("error merging author %d into %d: %w", sourceID, targetID, err)
+		}
+	}
+
+	merged, err := s.authorRepo.GetAuthorSummaries(ctx, tx, []int{targetID})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.dbManager.CommitTransaction(tx); err != nil {
+		return nil, err
+	}
+
+	for _, author := range authors {
+		if author.ID != targetID {
+			s.logger.Info("Author merged", "sourceID", author.ID, "source", author.Name, "targetID", targetID, "target", merged[0].Name)
+		}
+	}
+
+	return &AuthorMergeResult{
+		Target:          merged[0],
+		MergedIDs:       sourceIDs,
+		AffectedUserIDs: affectedUserIDs,
+	}, nil
+}
+
+// Helper fn: comparison form of an author name. The normalizer flips "Last, First" and folds accents, then
+// it's lowercased without punctuation or suffixes and runs of initials are joined, so "Tolkien, J.R.R."
+// and "JRR Tolkien" both give "jrr tolkien"
+func authorDedupeKey(name string) string {
+	var folded strings.Builder
+	for _, r := range strings.ToLower(authorDedupeNormalizer.Normalize(name)) {
+		switch {
+		case r == '\'', r == '’':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			folded.WriteRune(r)
+		default:
+			folded.WriteRune(' ')
+		}
+	}
+
+	var words []string
+	initials := ""
+	for _, word := range strings.Fields(folded.String()) {
+		switch {
+		case repository.IsAuthorNameSuffix(word):
+			continue
+		case len([]rune(word)) == 1:
+			initials += word
+			continue
+		}
+		if initials != "" {
+			words = append(words, initials)
+			initials = ""
+		}
+		words = append(words, word)
+	}
+	if initials != "" {
+		words = append(words, initials)
+	}
+
+	return strings.Join(words, " ")
+}
+
+func uniqueAuthorIDs(authorIDs []int, exclude int) []int {
+	seen := map[int]bool{exclude: true}
+	unique := []int{}
+	for _, authorID := range authorIDs {
+		if authorID > 0 && !seen[authorID] {
+			seen[authorID] = true
+			unique = append(unique, authorID)
+		}
+	}
+	return unique
+}
+
+// Union-find over author indexes, each set remembers the weakest similarity that joined it
+type authorUnionFind struct {
+	parent     []int
+	similarity []float64
+}
+
+func newAuthorUnionFind(size int) *authorUnionFind {
+	groups := &authorUnionFind{parent: make([]int, size), similarity: make([]float64, size)}
+	for i := range groups.parent {
+		groups.parent[i] = i
+		groups.similarity[i] = 1
+	}
+	return groups
+}
+
+func (u *authorUnionFind) find(i int) int {
+	for u.parent[i] != i {
+		u.parent[i] = u.parent[u.parent[i]]
+		i = u.parent[i]
+	}
+	return i
+}
+
+func (u *authorUnionFind) union(i int, j int, score float64) {
+	rootI, rootJ := u.find(i), u.find(j)
+	if rootI == rootJ {
+		return
+	}
+	u.parent[rootJ] = rootI
+	u.similarity[rootI] = min(u.similarity[rootI], u.similarity[rootJ], score)
+}
+
+// Helper fn: sets of two or more authors, largest by book count first
+func (u *authorUnionFind) duplicateGroups(authors []repository.AuthorSummary) []AuthorDuplicateGroup {
+	members := make(map[int][]repository.AuthorSummary)
+	for i, author := range authors {
+		root := u.find(i)
+		members[root] = append(members[root], author)
+	}
+
+	type rankedGroup struct {
+		group AuthorDuplicateGroup
+		books int
+	}
+
+	var ranked []rankedGroup
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+
+		target, books := group[0], 0
+		for _, author := range group {
+			books += author.BookCount
+			if author.BookCount > target.BookCount || (author.BookCount == target.BookCount && author.ID < target.ID) {
+				target = author
+			}
+		}
+
+		ranked = append(ranked, rankedGroup{
+			group: AuthorDuplicateGroup{
+				SuggestedTargetID: target.ID,
+				Similarity:        u.similarity[root],
+				Authors:           group,
+			},
+			books: books,
+		})
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].books != ranked[j].books {
+			return ranked[i].books > ranked[j].books
+		}
+		return ranked[i].group.SuggestedTargetID < ranked[j].group.SuggestedTargetID
+	})
+
+	groups := make([]AuthorDuplicateGroup, len(ranked))
+	for i := range ranked {
+		groups[i] = ranked[i].group
+	}
+	return groups
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+type fakeDedupeAuthorRepo struct {
+	repository.AuthorRepository
+	authors []repository.AuthorSummary
+}
+
+func (r *fakeDedupeAuthorRepo) ListAuthorSummaries(ctx context.Context) ([]repository.AuthorSummary, error) {
+	return r.authors, nil
+}
+
+func TestAuthorDedupeKey(t *testing.T) {
+	tests := map[string]string{
+		"J. R. R. Tolkien":        "jrr tolkien",
+		"Tolkien, J.R.R.":         "jrr tolkien",
+		"JRR Tolkien":             "jrr tolkien",
+		"Vonnegut, Kurt, Jr.":     "kurt vonnegut",
+		"Kurt Vonnegut, Jr.":      "kurt vonnegut",
+		"García Márquez, Gabriel": "gabriel garcia marquez",
+		"O'Brien, Tim":            "tim obrien",
+		"Ursula K. Le Guin":       "ursula k le guin",
+		"  ":                      "",
+	}
+
+	for name, want := range tests {
+		if got := authorDedupeKey(name); got != want {
+			t.Errorf("authorDedupeKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestAuthorUnionFindKeepsWeakestLink(t *testing.T) {
+	authors := []repository.AuthorSummary{
+		{ID: 4, Name: "a", BookCount: 1},
+		{ID: 3, Name: "b", BookCount: 2},
+		{ID: 2, Name: "c", BookCount: 2},
+		{ID: 1, Name: "d", BookCount: 1},
+		{ID: 5, Name: "e", BookCount: 9},
+	}
+
+	groups := newAuthorUnionFind(len(authors))
+	groups.union(0, 1, 0.9)
+	groups.union(2, 3, 0.95)
+	groups.union(1, 3, 0.87)
+	groups.union(0, 3, 0.5) // Already joined, doesn't lower the score
+
+	got := groups.duplicateGroups(authors)
+	if len(got) != 1 {
+		t.Fatalf("expected one group, got %+v", got)
+	}
+	if got[0].Similarity != 0.87 {
+		t.Errorf("expected similarity 0.87, got %v", got[0].Similarity)
+	}
+	// Book count ties go to the lower ID
+	if got[0].SuggestedTargetID != 2 {
+		t.Errorf("expected suggested target 2, got %d", got[0].SuggestedTargetID)
+	}
+	if len(got[0].Authors) != 4 {
+		t.Errorf("expected 4 authors, got %+v", got[0].Authors)
+	}
+}
+
+func TestFindDuplicateAuthors(t *testing.T) {
+	authors := []repository.AuthorSummary{
+		{ID: 1, Name: "J. R. R. Tolkien", BookCount: 5},
+		{ID: 2, Name: "Tolkien, J.R.R.", BookCount: 2},
+		{ID: 3, Name: "J.R.R. Tolkein", BookCount: 1},
+		{ID: 4, Name: "Christopher Tolkien", BookCount: 1},
+		{ID: 5, Name: "Vonnegut, Kurt", BookCount: 1},
+		{ID: 6, Name: "Kurt Vonnegut, Jr.", BookCount: 3},
+		{ID: 7, Name: "Ursula K. Le Guin", BookCount: 1},
+	}
+
+	service, err := NewAuthorDedupeService(newTestMetadataLogger(), &fakeDedupeAuthorRepo{authors: authors}, &fakeDBManager{})
+	if err != nil {
+		t.Fatalf("unexpected error creating author dedupe service: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		minSimilarity float64
+		want          []AuthorDuplicateGroup
+	}{
+		{
+			name:          "typos within the threshold join the group",
+			minSimilarity: DefaultAuthorSimilarity,
+			want: []AuthorDuplicateGroup{
+				{SuggestedTargetID: 1, Similarity: 1 - 1.0/11, Authors: authors[0:3]},
+				{SuggestedTargetID: 6, Similarity: 1, Authors: authors[4:6]},
+			},
+		},
+		{
+			name:          "exact matches only",
+			minSimilarity: 1,
+			want: []AuthorDuplicateGroup{
+				{SuggestedTargetID: 1, Similarity: 1, Authors: authors[0:2]},
+				{SuggestedTargetID: 6, Similarity: 1, Authors: authors[4:6]},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.FindDuplicateAuthors(context.Background(), tt.minSimilarity)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected groups\nwant %+v\n got %+v", tt.want, got)
+			}
+		})
+	}
+}
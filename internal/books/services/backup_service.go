@@ -0,0 +1,377 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/transaction"
+)
+
+const (
+	BackupFormatName = "bravo-kilo-library-backup"
+	BackupVersion    = 1 // Bump when the archive layout changes, restore accepts every version up to this one
+
+	backupManifestFile     = "manifest.json"
+	backupBooksFile        = "books.json"
+	backupMaxManifestBytes = 1 << 20
+	backupMaxBooksBytes    = 256 << 20 // Guards against zip bombs
+)
+
+type RestoreMode string
+
+const (
+	RestoreModeMerge   RestoreMode = "merge"   // Keep existing books, skip archive books already in the library
+	RestoreModeReplace RestoreMode = "replace" // Delete the library first
+)
+
+var ErrInvalidBackup = errors.New("invalid backup archive")
+
+// ParseRestoreMode validates a mode= value, empty defaults to merge
+func ParseRestoreMode(raw string) (RestoreMode, error) {
+	switch mode := RestoreMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case "":
+		return RestoreModeMerge, nil
+	case RestoreModeMerge, RestoreModeReplace:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported restore mode %q", raw)
+	}
+}
+
+// BackupManifest describes the archive contents, written as manifest.json
+type BackupManifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	BookCount int       `json:"bookCount"`
+	BooksFile string    `json:"booksFile"`
+}
+
+// RestoreReport reuses the import row statuses, one row per archived book
+type RestoreReport struct {
+	Mode    RestoreMode `json:"mode"`
+	Deleted int         `json:"deleted"`
+	ImportReport
+}
+
+type BackupService interface {
+	WriteBackup(ctx context.Context, userID int, writer io.Writer) error
+	RestoreBackup(ctx context.Context, userID int, archive io.ReaderAt, size int64, mode RestoreMode) (*RestoreReport, error)
+}
+
+type BackupServiceImpl struct {
+	bookRepository repository.BookRepository
+	bookDeleter    repository.BookDeleter
+	bookService    BookService
+	dbManager      transaction.DBManager
+	logger         *slog.Logger
+}
+
+func NewBackupService(
+	logger *slog.Logger,
+	bookRepo repository.BookRepository,
+	bookDeleter repository.BookDeleter,
+	bookService BookService,
+	dbManager transaction.DBManager,
+) (BackupService, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	if bookRepo == nil {
+		return nil, fmt.Errorf("book repository is nil")
+	}
+
+	if bookDeleter == nil {
+		return nil, fmt.Errorf("book deleter is nil")
+	}
+
+	if bookService == nil {
+		return nil, fmt.Errorf("book service is nil")
+	}
+
+	if dbManager == nil {
+		return nil, fmt.Errorf("db manager is nil")
+	}
+
+	return &BackupServiceImpl{
+		bookRepository: bookRepo,
+		bookDeleter:    bookDeleter,
+		bookService:    bookService,
+		dbManager:      dbManager,
+		logger:         logger,
+	}, nil
+}
+
+// WriteBackup streams a zip holding every book as JSON, followed by the manifest
+func (b *BackupServiceImpl) WriteBackup(ctx context.Context, userID int, writer io.Writer) error {
+	zipWriter := zip.NewWriter(writer)
+
+	booksEntry, err := zipWriter.Create(backupBooksFile)
+	if err != nil {
+		return fmt.Errorf("failed to create books entry: %w", err)
+	}
+
+	// JSON export keeps rich text as Quill Delta ops, so notes survive the round trip
+	bookWriter, err := newBookExportWriter(ExportFormatJSON, booksEntry, defaultExportColumns())
+	if err != nil {
+		return err
+	}
+	if err := bookWriter.Begin(); err != nil {
+		return err
+	}
+
+	bookCount := 0
+	err = forEachUserBookPage(ctx, b.bookRepository, userID, func(books []repository.Book) error {
+		for _, book := range books {
+			if err := bookWriter.WriteBook(book); err != nil {
+				return err
+			}
+		}
+		bookCount += len(books)
+
+		if err := zipWriter.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := writer.(exportFlusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		b.logger.Error("Failed to write backup books", "userID", userID, "error", err)
+		return err
+	}
+
+	if err := bookWriter.End(); err != nil {
+		return err
+	}
+
+	manifestEntry, err := zipWriter.Create(backupManifestFile)
+	if err != nil {
+		return fmt.Errorf("failed to create manifest entry: %w", err)
+	}
+
+	encoder := json.NewEncoder(manifestEntry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(BackupManifest{
+		Format:    BackupFormatName,
+		Version:   BackupVersion,
+		CreatedAt: time.Now().UTC(),
+		BookCount: bookCount,
+		BooksFile: backupBooksFile,
+	}); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish backup archive: %w", err)
+	}
+
+	b.logger.Info("Backup completed", "userID", userID, "bookCount", bookCount)
+	return nil
+}
+
+// RestoreBackup replays an archive in a single transaction, nothing is written unless every step succeeds.
+// Books that fail validation are reported and skipped rather than aborting the restore
+func (b *BackupServiceImpl) RestoreBackup(
+	ctx context.Context,
+	userID int,
+	archive io.ReaderAt,
+	size int64,
+	mode RestoreMode,
+) (*RestoreReport, error) {
+	zipReader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+
+	manifest, err := readBackupManifest(zipReader)
+	if err != nil {
+		return nil, err
+	}
+
+	booksFile, err := zipReader.Open(manifest.BooksFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidBackup, manifest.BooksFile)
+	}
+	defer booksFile.Close()
+
+	decoder := json.NewDecoder(io.LimitReader(booksFile, backupMaxBooksBytes))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, fmt.Errorf("%w: %s must hold a JSON array", ErrInvalidBackup, manifest.BooksFile)
+	}
+
+	tx, err := b.dbManager.BeginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer b.dbManager.RollbackTransaction(tx)
+
+	report := &RestoreReport{
+		Mode: mode,
+		ImportReport: ImportReport{
+			Format: BackupFormatName,
+			Rows:   []ImportRowResult{},
+		},
+	}
+
+	existing := newBackupBookKeys()
+	if mode == RestoreModeReplace {
+		report.Deleted, err = b.bookDeleter.DeleteAllForUser(ctx, tx, userID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err = forEachUserBookPage(ctx, b.bookRepository, userID, func(books []repository.Book) error {
+			for _, book := range books {
+				existing.add(book)
+			}
+			return nil
+		})
+		if err != nil {
+			b.logger.Error("Failed to load library for restore merge", "userID", userID, "error", err)
+			return nil, err
+		}
+	}
+
+	for index := 1; decoder.More(); index++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var book repository.Book
+		if err := decoder.Decode(&book); err != nil {
+			return nil, fmt.Errorf("%w: book %d: %v", ErrInvalidBackup, index, err)
+		}
+
+		report.addResult(b.restoreBook(ctx, tx, userID, index, book, existing))
+	}
+
+	if err := b.dbManager.CommitTransaction(tx); err != nil {
+		return nil, err
+	}
+
+	b.logger.Info("Restore completed",
+		"userID", userID,
+		"mode", mode,
+		"deleted", report.Deleted,
+		"restored", report.Inserted,
+		"duplicates", report.Duplicates,
+		"rejected", report.Rejected,
+	)
+
+	return report, nil
+}
+
+func (b *BackupServiceImpl) restoreBook(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID int,
+	index int,
+	book repository.Book,
+	existing *backupBookKeys,
+) ImportRowResult {
+	result := ImportRowResult{Row: index, Title: book.Title}
+
+	if existing.has(book) {
+		result.Status = ImportRowDuplicate
+		result.Reason = "book already in library"
+		return result
+	}
+
+	if strings.TrimSpace(book.Title) == "" || len(book.Authors) == 0 {
+		result.Status = ImportRowRejected
+		result.Reason = "title and authors are required"
+		return result
+	}
+
+	// Archived IDs belong to the library the backup came from
+	book.ID = 0
+
+	bookID, err := createBookEntryInSavepoint(ctx, tx, b.bookService, book, userID)
+	if err != nil {
+		b.logger.Error("Error restoring book", "error", err, "index", index, "userID", userID)
+		result.Status = ImportRowRejected
+		result.Reason = "unable to save book"
+		return result
+	}
+
+	existing.add(book)
+	result.Status = ImportRowInserted
+	result.BookID = bookID
+	return result
+}
+
+// Helper fn: find and validate manifest.json
+func readBackupManifest(zipReader *zip.Reader) (*BackupManifest, error) {
+	file, err := zipReader.Open(backupManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidBackup, backupManifestFile)
+	}
+	defer file.Close()
+
+	var manifest BackupManifest
+	if err := json.NewDecoder(io.LimitReader(file, backupMaxManifestBytes)).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: unreadable manifest: %v", ErrInvalidBackup, err)
+	}
+
+	if manifest.Format != BackupFormatName {
+		return nil, fmt.Errorf("%w: unexpected format %q", ErrInvalidBackup, manifest.Format)
+	}
+	if manifest.Version < 1 || manifest.Version > BackupVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, manifest.Version)
+	}
+	if manifest.BooksFile == "" {
+		manifest.BooksFile = backupBooksFile
+	}
+
+	return &manifest, nil
+}
+
+// backupBookKeys matches archived books against the library by ISBN, or by title when a book has none
+type backupBookKeys struct {
+	keys map[string]bool
+}
+
+func newBackupBookKeys() *backupBookKeys {
+	return &backupBookKeys{keys: make(map[string]bool)}
+}
+
+func (k *backupBookKeys) add(book repository.Book) {
+	for _, key := range backupBookKeysFor(book) {
+		k.keys[key] = true
+	}
+}
+
+func (k *backupBookKeys) has(book repository.Book) bool {
+	for _, key := range backupBookKeysFor(book) {
+		if k.keys[key] {
+			return true
+		}
+	}
+	return false
+}
+
+func backupBookKeysFor(book repository.Book) []string {
+	var keys []string
+	if book.ISBN10 != "" {
+		keys = append(keys, "isbn10:"+book.ISBN10)
+	}
+	if book.ISBN13 != "" {
+		keys = append(keys, "isbn13:"+book.ISBN13)
+	}
+	if len(keys) == 0 && strings.TrimSpace(book.Title) != "" {
+		keys = append(keys, "title:"+strings.ToLower(strings.TrimSpace(book.Title)))
+	}
+	return keys
+}
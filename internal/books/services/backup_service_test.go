@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// fakeBackupLibrary keeps every user's books in memory, in ID order
+type fakeBackupLibrary struct {
+	books  map[int][]repository.Book
+	nextID int
+}
+
+func (l *fakeBackupLibrary) Delete(id int) error { return nil }
+
+func (l *fakeBackupLibrary) DeleteAllForUser(ctx context.Context, tx *sql.Tx, userID int) (int, error) {
+	deleted := len(l.books[userID])
+	delete(l.books, userID)
+	return deleted, nil
+}
+
+type fakeBackupBookRepo struct {
+	repository.BookRepository
+	library *fakeBackupLibrary
+}
+
+func (f *fakeBackupBookRepo) GetBooksPageByUserID(ctx context.Context, userID int, afterID int, limit int) ([]repository.Book, error) {
+	page := []repository.Book{}
+	for _, book := range f.library.books[userID] {
+		if book.ID > afterID && len(page) < limit {
+			page = append(page, book)
+		}
+	}
+	return page, nil
+}
+
+type fakeBackupBookService struct {
+	BookService
+	library *fakeBackupLibrary
+}
+
+func (f *fakeBackupBookService) CreateBookEntryTx(ctx context.Context, tx *sql.Tx, book repository.Book, userID int) (int, error) {
+	f.library.nextID++
+	book.ID = f.library.nextID
+	f.library.books[userID] = append(f.library.books[userID], book)
+	return book.ID, nil
+}
+
+// A database/sql driver that accepts every statement, so restores get a real *sql.Tx for their savepoints
+type fakeBackupConnector struct{ commits *int }
+
+func (c fakeBackupConnector) Connect(ctx context.Context) (driver.Conn, error) { return c, nil }
+func (c fakeBackupConnector) Driver() driver.Driver                            { return c }
+func (c fakeBackupConnector) Open(name string) (driver.Conn, error)            { return c, nil }
+func (c fakeBackupConnector) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c fakeBackupConnector) Close() error              { return nil }
+func (c fakeBackupConnector) Begin() (driver.Tx, error) { return c, nil }
+func (c fakeBackupConnector) Commit() error             { *c.commits++; return nil }
+func (c fakeBackupConnector) Rollback() error           { return nil }
+func (c fakeBackupConnector) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func newTestBackupService(t *testing.T, library *fakeBackupLibrary) (BackupService, *int) {
+	t.Helper()
+
+	commits := 0
+	db := sql.OpenDB(fakeBackupConnector{commits: &commits})
+	t.Cleanup(func() { db.Close() })
+
+	service, err := NewBackupService(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		&fakeBackupBookRepo{library: library},
+		library,
+		&fakeBackupBookService{library: library},
+		&fakeDBManager{db: db},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating backup service: %v", err)
+	}
+	return service, &commits
+}
+
+func newTestBackupBooks() []repository.Book {
+	books := []repository.Book{
+		{
+			Title:       "The Dispossessed",
+			Subtitle:    "An Ambiguous Utopia",
+			Authors:     []string{"Ursula K. Le Guin"},
+			Description: repository.RichText{Ops: []repository.DeltaOp{{Insert: "Shevek leaves Anarres.\n"}}},
+			Notes: repository.RichText{Ops: []repository.DeltaOp{
+				{Insert: "Reread", Attributes: map[string]interface{}{"bold": true}},
+				{Insert: " the wall chapter.\n"},
+			}},
+			Language:    "en",
+			PageCount:   387,
+			PublishDate: "1974",
+			ImageLink:   "https://example.com/dispossessed.jpg",
+			Genres:      []string{"Science Fiction"},
+			Formats:     []string{"physical"},
+			Tags:        []string{"favourites", "anarchism"},
+			ISBN10:      "0060512750",
+			ISBN13:      "9780060512750",
+		},
+		{
+			Title:       "A Handwritten Zine",
+			Authors:     []string{"Anonymous", "Friends"},
+			Description: repository.RichText{Ops: []repository.DeltaOp{}},
+			Notes:       repository.RichText{Ops: []repository.DeltaOp{{Insert: "No ISBN, matched by title\n"}}},
+			Genres:      []string{},
+			Formats:     []string{"physical"},
+			Tags:        []string{},
+		},
+	}
+
+	// More books than one export page, so paging is part of the round trip
+	for i := 0; i < exportPageSize; i++ {
+		books = append(books, repository.Book{
+			Title:       fmt.Sprintf("Filler %d", i+1),
+			Authors:     []string{"Someone"},
+			Description: repository.RichText{Ops: []repository.DeltaOp{}},
+			Notes:       repository.RichText{Ops: []repository.DeltaOp{}},
+			Genres:      []string{},
+			Formats:     []string{},
+			Tags:        []string{},
+		})
+	}
+
+	for i := range books {
+		books[i].ID = i + 1
+	}
+	return books
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	const backupUserID, restoreUserID = 1, 2
+
+	tests := []struct {
+		name           string
+		mode           RestoreMode
+		sameLibrary    bool // Restore over the library the backup came from
+		wantInserted   int
+		wantDuplicates int
+		wantDeleted    int
+	}{
+		{
+			name:         "merge into an empty library",
+			mode:         RestoreModeMerge,
+			wantInserted: 2 + exportPageSize,
+		},
+		{
+			name:           "merge into the same library",
+			mode:           RestoreModeMerge,
+			sameLibrary:    true,
+			wantDuplicates: 2 + exportPageSize,
+		},
+		{
+			name:         "replace the same library",
+			mode:         RestoreModeReplace,
+			sameLibrary:  true,
+			wantInserted: 2 + exportPageSize,
+			wantDeleted:  2 + exportPageSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := newTestBackupBooks()
+			library := &fakeBackupLibrary{
+				books:  map[int][]repository.Book{backupUserID: original},
+				nextID: len(original),
+			}
+			service, commits := newTestBackupService(t, library)
+
+			var archive bytes.Buffer
+			if err := service.WriteBackup(context.Background(), backupUserID, &archive); err != nil {
+				t.Fatalf("unexpected backup error: %v", err)
+			}
+
+			targetUserID := restoreUserID
+			if tt.sameLibrary {
+				targetUserID = backupUserID
+			}
+
+			report, err := service.RestoreBackup(context.Background(), targetUserID, bytes.NewReader(archive.Bytes()), int64(archive.Len()), tt.mode)
+			if err != nil {
+				t.Fatalf("unexpected restore error: %v", err)
+			}
+
+			if report.Inserted != tt.wantInserted || report.Duplicates != tt.wantDuplicates ||
+				report.Rejected != 0 || report.Deleted != tt.wantDeleted {
+				t.Errorf("unexpected report: inserted=%d duplicates=%d rejected=%d deleted=%d",
+					report.Inserted, report.Duplicates, report.Rejected, report.Deleted)
+			}
+			if *commits != 1 {
+				t.Errorf("expected the restore to commit once, got %d", *commits)
+			}
+
+			restored := library.books[targetUserID]
+			if len(restored) != len(original) {
+				t.Fatalf("expected %d books after restore, got %d", len(original), len(restored))
+			}
+			for i := range original {
+				want, got := original[i], restored[i]
+				if tt.wantInserted > 0 {
+					want.ID, got.ID = 0, 0
+				}
+				if !reflect.DeepEqual(want, got) {
+					t.Errorf("book %d did not survive the round trip\nwant %+v\n got %+v", i+1, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreBackupRejectsInvalidArchive(t *testing.T) {
+	library := &fakeBackupLibrary{books: map[int][]repository.Book{}}
+	service, commits := newTestBackupService(t, library)
+
+	archive := []byte("not a zip")
+	_, err := service.RestoreBackup(context.Background(), 1, bytes.NewReader(archive), int64(len(archive)), RestoreModeReplace)
+	if !errors.Is(err, ErrInvalidBackup) {
+		t.Fatalf("expected ErrInvalidBackup, got %v", err)
+	}
+	if *commits != 0 {
+		t.Error("an invalid archive should not reach the database")
+	}
+}
@@ -16,10 +16,10 @@ type BookCacheService interface {
     // High-level cache operations
     SetCachedBook(ctx context.Context, userID int, bookID int, value interface{}, duration time.Duration) error
     SetCachedBookList(ctx context.Context, userID int, operation string, value interface{}, duration time.Duration) error
-	SetCachedGeminiResponse(ctx context.Context, prompt string, response string, duration time.Duration) error
 
     InvalidateCache(ctx context.Context, userID int, bookID int) error
-	GetCachedGeminiResponse(ctx context.Context, prompt string) (string, bool, error)
+	GetCachedMetadataSearch(ctx context.Context, queryKey string, result interface{}) (bool, error)
+	SetCachedMetadataSearch(ctx context.Context, queryKey string, value interface{}, duration time.Duration) error
     GetCachedBook(ctx context.Context, userID int, bookID int, result interface{}) (bool, error)
     GetCachedBookList(ctx context.Context, userID int, operation string, result interface{}) (bool, error)
     GetCacheKeys(itemID, userID int) []string
@@ -87,17 +87,6 @@ func (s *BookCacheServiceImpl) InvalidateCache(ctx context.Context, userID int,
     return s.redisClient.Delete(ctx, keys...)
 }
 
-// Gemini cache methods
-func (s *BookCacheServiceImpl) GetCachedGeminiResponse(ctx context.Context, prompt string) (string, bool, error) {
-	key := s.buildKey("gemini", 0, prompt)
-	var response string
-	found, err := s.getCachedData(ctx, key, &response)
-	if err != nil {
-			return "", false, err
-	}
-	return response, found, nil
-}
-
 // L2CacheInvalidator interface implementation
 func (s *BookCacheServiceImpl) GetCacheKeys(itemID, userID int) []string {
     return []string{
@@ -110,14 +99,20 @@ func (s *BookCacheServiceImpl) GetCacheKeys(itemID, userID int) []string {
     }
 }
 
-func (s *BookCacheServiceImpl) SetCachedGeminiResponse(
+// Metadata search cache methods, results are shared by every user so nothing user specific goes in here
+func (s *BookCacheServiceImpl) GetCachedMetadataSearch(ctx context.Context, queryKey string, result interface{}) (bool, error) {
+	key := s.buildKey("metadataSearch", 0, queryKey)
+	return s.getCachedData(ctx, key, result)
+}
+
+func (s *BookCacheServiceImpl) SetCachedMetadataSearch(
     ctx context.Context,
-    prompt string,
-    response string,
+    queryKey string,
+    value interface{},
     duration time.Duration,
     ) error {
-	key := s.buildKey("gemini", 0, prompt)
-	return s.setCachedData(ctx, key, response, duration)
+	key := s.buildKey("metadataSearch", 0, queryKey)
+	return s.setCachedData(ctx, key, value, duration)
 }
 
 // Internal helper methods
@@ -174,9 +169,9 @@ func (s *BookCacheServiceImpl) buildKey(operation string, userID int, params ...
         return fmt.Sprintf("%s%d", redis.PrefixBookTag, userID)
     case "homepage":
         return fmt.Sprintf("%s%d", redis.PrefixBookHomepage, userID)
-		case "gemini":
+		case "metadataSearch":
 			if len(params) > 0 {
-				return fmt.Sprintf("%s:%v", redis.PrefixGemini, params[0])
+				return fmt.Sprintf("%s%v", redis.PrefixMetadataSearch, params[0])
 			}
     }
     return ""
@@ -22,6 +22,7 @@ import (
 
 type BookService interface {
 	CreateBookEntry(ctx context.Context, book repository.Book, userID int) (int, error)
+	CreateBookEntryTx(ctx context.Context, tx *sql.Tx, book repository.Book, userID int) (int, error)
 	CreateEntries(
 		ctx context.Context,
 		tx *sql.Tx,
@@ -108,6 +109,30 @@ func NewBookService(
 
 // InsertBook creates a new book with its associated authors, genres, and formats
 func (s *BookServiceImpl) CreateBookEntry(ctx context.Context, book repository.Book, userID int) (int, error) {
+	// Start transaction
+	tx, err := s.dbManager.BeginTransaction(ctx)
+	if err != nil {
+		s.logger.Error("Error starting transaction", "error", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	bookID, err := s.CreateBookEntryTx(ctx, tx, book, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Commit the transaction
+	if err = s.dbManager.CommitTransaction(tx); err != nil {
+		s.logger.Error("Error committing transaction", "error", err)
+		return 0, err
+	}
+
+	return bookID, nil
+}
+
+// CreateBookEntryTx inserts a book and its associations inside a transaction owned by the caller
+func (s *BookServiceImpl) CreateBookEntryTx(ctx context.Context, tx *sql.Tx, book repository.Book, userID int) (int, error) {
 	// Normalize + sanitize book data before proceeding
 	s.NormalizeBookData(&book)
 	s.SanitizeBookData(&book)
@@ -120,14 +145,6 @@ func (s *BookServiceImpl) CreateBookEntry(ctx context.Context, book repository.B
 	// Format publish date if only year is provided
 	book.PublishDate = formatPublishDate(book.PublishDate)
 
-	// Start transaction
-	tx, err := s.dbManager.BeginTransaction(ctx)
-	if err != nil {
-		s.logger.Error("Error starting transaction", "error", err)
-		return 0, err
-	}
-	defer tx.Rollback()
-
 	// Insert the book into the books table and associate with the user
 	bookID, err := s.bookRepository.InsertBook(ctx, tx, book, userID)
 	if err != nil {
@@ -187,9 +204,24 @@ func (s *BookServiceImpl) CreateBookEntry(ctx context.Context, book repository.B
 		return 0, err
 	}
 
-	// Commit the transaction
-	if err = s.dbManager.CommitTransaction(tx); err != nil {
-		s.logger.Error("Error committing transaction", "error", err)
+	return bookID, nil
+}
+
+// Helper fn: run CreateBookEntryTx inside a savepoint so one bad book doesn't abort the caller's transaction
+func createBookEntryInSavepoint(ctx context.Context, tx *sql.Tx, bookService BookService, book repository.Book, userID int) (int, error) {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT book_entry"); err != nil {
+		return 0, err
+	}
+
+	bookID, err := bookService.CreateBookEntryTx(ctx, tx, book, userID)
+	if err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT book_entry"); rollbackErr != nil {
+			return 0, fmt.Errorf("%w (savepoint rollback failed: %v)", err, rollbackErr)
+		}
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT book_entry"); err != nil {
 		return 0, err
 	}
 
@@ -381,10 +413,13 @@ func (s *BookServiceImpl) sanitizeRichTextContent(rt *repository.RichText) {
 	}
 }
 
-// Helper to format the publish date
+// Helper to format the publish date, year-only and year-month dates from metadata providers are padded to the first
 func formatPublishDate(dateStr string) string {
-	if len(dateStr) == 4 {
+	switch len(dateStr) {
+	case 4:
 		return dateStr + "-01-01"
+	case 7:
+		return dateStr + "-01"
 	}
 	return dateStr
 }
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/utils"
+)
+
+const (
+	enrichmentBatchSize       = 25
+	enrichmentMaxBooksPerScan = 200 // Keeps one scan from using up the providers' daily quota
+	enrichmentRecheckAfter    = 30 * 24 * time.Hour
+	enrichmentSearchResults   = 5
+)
+
+var (
+	ErrEnrichmentProposalResolved = errors.New("enrichment proposal was already accepted or rejected")
+	ErrInvalidEnrichmentFields    = errors.New("unknown enrichment fields")
+)
+
+// Cover hosts of the metadata providers, the only image links enrichment proposes or writes
+var enrichmentImageHosts = []string{"books.google.com", "books.googleusercontent.com", "covers.openlibrary.org"}
+
+type EnrichmentScanResult struct {
+	CheckedBooks  int
+	ProposedBooks int
+}
+
+// EnrichmentService fills in missing book metadata from the metadata providers. Scans only record
+// proposals, a book changes once its owner accepts one
+type EnrichmentService interface {
+	ScanUserBooks(ctx context.Context, userID int) (*EnrichmentScanResult, error)
+	AcceptProposal(ctx context.Context, userID int, proposalID int, fields []string) (*repository.EnrichmentProposal, error)
+	RejectProposal(ctx context.Context, userID int, proposalID int) error
+}
+
+type EnrichmentServiceImpl struct {
+	logger           *slog.Logger
+	enrichmentRepo   repository.EnrichmentRepository
+	bookRepo         repository.BookRepository
+	bookUpdater      BookUpdaterService
+	metadataProvider MetadataProvider
+}
+
+func NewEnrichmentService(
+	logger *slog.Logger,
+	enrichmentRepo repository.EnrichmentRepository,
+	bookRepo repository.BookRepository,
+	bookUpdater BookUpdaterService,
+	metadataProvider MetadataProvider,
+) (EnrichmentService, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	if enrichmentRepo == nil || bookRepo == nil || bookUpdater == nil {
+		return nil, fmt.Errorf("enrichment service, repositories or book updater is nil")
+	}
+
+	if metadataProvider == nil {
+		return nil, fmt.Errorf("enrichment service, metadata provider is nil")
+	}
+
+	return &EnrichmentServiceImpl{
+		logger:           logger,
+		enrichmentRepo:   enrichmentRepo,
+		bookRepo:         bookRepo,
+		bookUpdater:      bookUpdater,
+		metadataProvider: metadataProvider,
+	}, nil
+}
+
+// ScanUserBooks looks up the user's incomplete books by ISBN, or by title and author when there is no ISBN.
+// A provider outage stops the scan, books not reached yet are picked up by the next one
+func (s *EnrichmentServiceImpl) ScanUserBooks(ctx context.Context, userID int) (*EnrichmentScanResult, error) {
+	result := &EnrichmentScanResult{}
+
+	for result.CheckedBooks < enrichmentMaxBooksPerScan {
+		books, err := s.enrichmentRepo.GetIncompleteBooks(ctx, userID, enrichmentRecheckAfter, enrichmentBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(books) == 0 {
+			break
+		}
+
+		for _, book := range books {
+			proposal, err := s.proposeChanges(ctx, book)
+			if err != nil {
+				return result, err
+			}
+
+			if proposal != nil {
+				proposal.UserID = userID
+				if err := s.enrichmentRepo.CreateProposal(ctx, proposal); err != nil {
+					return result, err
+				}
+				if proposal.ID > 0 {
+					result.ProposedBooks++
+				}
+			}
+
+			if err := s.enrichmentRepo.MarkBookChecked(ctx, userID, book.ID); err != nil {
+				return result, err
+			}
+			result.CheckedBooks++
+		}
+	}
+
+	return result, nil
+}
+
+// AcceptProposal writes the proposal's changes to the book, fields limits it to some of them.
+// Fields the user filled in since the scan are left alone
+func (s *EnrichmentServiceImpl) AcceptProposal(ctx context.Context, userID int, proposalID int, fields []string) (*repository.EnrichmentProposal, error) {
+	proposal, err := s.enrichmentRepo.GetProposal(ctx, proposalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Status != repository.EnrichmentProposalPending {
+		return nil, ErrEnrichmentProposalResolved
+	}
+
+	changes, err := selectEnrichmentChanges(proposal.Changes, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	isOwner, err := s.bookRepo.IsUserBookOwner(userID, proposal.BookID)
+	if err != nil {
+		return nil, err
+	}
+	if !isOwner {
+		return nil, repository.ErrEnrichmentProposalNotFound
+	}
+
+	// Claim the proposal first, a second accept racing this one gets ErrEnrichmentProposalResolved
+	err = s.enrichmentRepo.ResolveProposal(ctx, proposalID, userID, repository.EnrichmentProposalPending, repository.EnrichmentProposalAccepted)
+	if errors.Is(err, repository.ErrEnrichmentProposalNotFound) {
+		return nil, ErrEnrichmentProposalResolved
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyChanges(ctx, userID, proposal.BookID, changes); err != nil {
+		if revertErr := s.enrichmentRepo.ResolveProposal(ctx, proposalID, userID, repository.EnrichmentProposalAccepted, repository.EnrichmentProposalPending); revertErr != nil {
+			s.logger.Error("Failed to reopen enrichment proposal after update error", "proposalID", proposalID, "error", revertErr)
+		}
+		return nil, err
+	}
+
+	proposal.Status = repository.EnrichmentProposalAccepted
+	proposal.Changes = changes
+	return proposal, nil
+}
+
+func (s *EnrichmentServiceImpl) RejectProposal(ctx context.Context, userID int, proposalID int) error {
+	err := s.enrichmentRepo.ResolveProposal(ctx, proposalID, userID, repository.EnrichmentProposalPending, repository.EnrichmentProposalRejected)
+	if !errors.Is(err, repository.ErrEnrichmentProposalNotFound) {
+		return err
+	}
+
+	// Tell a missing proposal apart from one that was already resolved
+	if _, getErr := s.enrichmentRepo.GetProposal(ctx, proposalID, userID); getErr != nil {
+		return getErr
+	}
+	return ErrEnrichmentProposalResolved
+}
+
+// Helper fn: nil when the providers have nothing to add
+func (s *EnrichmentServiceImpl) proposeChanges(ctx context.Context, book repository.Book) (*repository.EnrichmentProposal, error) {
+	// The other ISBN form can be computed, no lookup needed
+	changes := []repository.EnrichmentFieldChange{}
+	if book.ISBN13 == "" {
+		if isbn13 := repository.NormalizeISBN13(book.ISBN10); isbn13 != "" {
+			changes = append(changes, repository.EnrichmentFieldChange{Field: repository.EnrichmentFieldISBN13, Proposed: isbn13})
+			book.ISBN13 = isbn13
+		}
+	}
+	if book.ISBN10 == "" {
+		if isbn10 := repository.ISBN13ToISBN10(book.ISBN13); isbn10 != "" {
+			changes = append(changes, repository.EnrichmentFieldChange{Field: repository.EnrichmentFieldISBN10, Proposed: isbn10})
+			book.ISBN10 = isbn10
+		}
+	}
+
+	provider := "isbn"
+	if enrichmentFieldsMissing(book) {
+		match, matchProvider, err := s.findMatch(ctx, book)
+		switch {
+		case errors.Is(err, ErrMetadataNotFound):
+		case err != nil:
+			return nil, err
+		default:
+			provider = matchProvider
+			changes = append(changes, enrichmentChanges(book, *match)...)
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	return &repository.EnrichmentProposal{
+		BookID:    book.ID,
+		BookTitle: book.Title,
+		Provider:  provider,
+		Changes:   changes,
+	}, nil
+}
+
+// Helper fn: ISBN lookup when the book has one, otherwise a title + author search checked with the library matcher
+func (s *EnrichmentServiceImpl) findMatch(ctx context.Context, book repository.Book) (*repository.Book, string, error) {
+	for _, isbn := range []string{book.ISBN13, book.ISBN10} {
+		if isbn == "" {
+			continue
+		}
+		match, err := s.metadataProvider.LookupISBN(ctx, isbn)
+		if err == nil {
+			return match, s.metadataProvider.Name(), nil
+		}
+		if !errors.Is(err, ErrMetadataNotFound) {
+			return nil, "", err
+		}
+	}
+
+	if book.Title == "" || len(book.Authors) == 0 {
+		return nil, "", ErrMetadataNotFound
+	}
+
+	searchResult, err := s.metadataProvider.Search(ctx, MetadataSearchQuery{
+		Title:      book.Title,
+		Author:     book.Authors[0],
+		MaxResults: enrichmentSearchResults,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Same rule as the "already in library" check, the title plus an author surname, allowing a typo in one
+	matcher := repository.NewLibraryMatcher([]repository.LibraryMatchEntry{{
+		BookID:  book.ID,
+		Title:   book.Title,
+		Authors: book.Authors,
+	}})
+	for i := range searchResult.Books {
+		if _, ok := matcher.Match(searchResult.Books[i]); ok {
+			return &searchResult.Books[i], searchResult.Provider, nil
+		}
+	}
+
+	return nil, "", ErrMetadataNotFound
+}
+
+// Helper fn: write the changes to the fields that are still empty
+func (s *EnrichmentServiceImpl) applyChanges(ctx context.Context, userID int, bookID int, changes []repository.EnrichmentFieldChange) error {
+	book, err := s.bookRepo.GetBookByID(bookID)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		switch change.Field {
+		case repository.EnrichmentFieldISBN10:
+			if book.ISBN10 == "" {
+				book.ISBN10 = change.Proposed
+			}
+		case repository.EnrichmentFieldISBN13:
+			if book.ISBN13 == "" {
+				book.ISBN13 = change.Proposed
+			}
+		case repository.EnrichmentFieldPageCount:
+			if pageCount, err := strconv.Atoi(change.Proposed); err == nil && book.PageCount == 0 {
+				book.PageCount = pageCount
+			}
+		case repository.EnrichmentFieldImageLink:
+			// Checked again here, the proposal may have been stored before the check existed
+			if book.ImageLink == "" && isAllowedEnrichmentImageLink(change.Proposed) {
+				book.ImageLink = change.Proposed
+			}
+		case repository.EnrichmentFieldPublishDate:
+			if book.PublishDate == "" {
+				book.PublishDate = change.Proposed
+			}
+		}
+	}
+
+	return s.bookUpdater.UpdateBookEntry(ctx, *book, userID)
+}
+
+func enrichmentFieldsMissing(book repository.Book) bool {
+	return book.ISBN10 == "" || book.ISBN13 == "" || book.PageCount == 0 || book.ImageLink == "" || book.PublishDate == ""
+}
+
+// Helper fn: the provider's values for the fields the book is missing
+func enrichmentChanges(book repository.Book, match repository.Book) []repository.EnrichmentFieldChange {
+	var changes []repository.EnrichmentFieldChange
+	add := func(field string, current bool, proposed string) {
+		if !current && proposed != "" {
+			changes = append(changes, repository.EnrichmentFieldChange{Field: field, Proposed: proposed})
+		}
+	}
+
+	matchISBN13 := repository.NormalizeISBN13(match.ISBN13)
+	if matchISBN13 == "" {
+		matchISBN13 = repository.NormalizeISBN13(match.ISBN10)
+	}
+	add(repository.EnrichmentFieldISBN13, book.ISBN13 != "", matchISBN13)
+	add(repository.EnrichmentFieldISBN10, book.ISBN10 != "", repository.ISBN13ToISBN10(matchISBN13))
+	if match.PageCount > 0 {
+		add(repository.EnrichmentFieldPageCount, book.PageCount > 0, strconv.Itoa(match.PageCount))
+	}
+	if isAllowedEnrichmentImageLink(match.ImageLink) {
+		add(repository.EnrichmentFieldImageLink, book.ImageLink != "", match.ImageLink)
+	}
+	add(repository.EnrichmentFieldPublishDate, book.PublishDate != "", formatPublishDate(match.PublishDate))
+
+	return changes
+}
+
+// Helper fn: same check as user supplied image links, https from an allowed host
+func isAllowedEnrichmentImageLink(link string) bool {
+	parsedURL, err := url.Parse(link)
+	if err != nil || parsedURL.Scheme != "https" {
+		return false
+	}
+	return utils.IsFromAllowedDomain(parsedURL.Hostname(), enrichmentImageHosts)
+}
+
+// Helper fn: every change when fields is empty, otherwise just the named ones
+func selectEnrichmentChanges(changes []repository.EnrichmentFieldChange, fields []string) ([]repository.EnrichmentFieldChange, error) {
+	if len(fields) == 0 {
+		return changes, nil
+	}
+
+	byField := make(map[string]repository.EnrichmentFieldChange, len(changes))
+	for _, change := range changes {
+		byField[change.Field] = change
+	}
+
+	selected := make([]repository.EnrichmentFieldChange, 0, len(fields))
+	for _, field := range fields {
+		change, ok := byField[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not part of this proposal", ErrInvalidEnrichmentFields, field)
+		}
+		selected = append(selected, change)
+	}
+	return selected, nil
+}
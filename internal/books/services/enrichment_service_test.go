@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+type fakeEnrichmentRepo struct {
+	repository.EnrichmentRepository
+	proposal *repository.EnrichmentProposal
+}
+
+func (r *fakeEnrichmentRepo) GetProposal(ctx context.Context, proposalID, userID int) (*repository.EnrichmentProposal, error) {
+	if r.proposal == nil || r.proposal.ID != proposalID || r.proposal.UserID != userID {
+		return nil, repository.ErrEnrichmentProposalNotFound
+	}
+	proposal := *r.proposal
+	return &proposal, nil
+}
+
+func (r *fakeEnrichmentRepo) ResolveProposal(ctx context.Context, proposalID, userID int, from, to repository.EnrichmentProposalStatus) error {
+	if r.proposal == nil || r.proposal.ID != proposalID || r.proposal.Status != from {
+		return repository.ErrEnrichmentProposalNotFound
+	}
+	r.proposal.Status = to
+	return nil
+}
+
+type fakeEnrichmentBookRepo struct {
+	repository.BookRepository
+	book repository.Book
+}
+
+func (r *fakeEnrichmentBookRepo) GetBookByID(id int) (*repository.Book, error) {
+	book := r.book
+	return &book, nil
+}
+
+func (r *fakeEnrichmentBookRepo) IsUserBookOwner(userID, bookID int) (bool, error) {
+	return bookID == r.book.ID, nil
+}
+
+type fakeEnrichmentBookUpdater struct {
+	updated []repository.Book
+}
+
+func (u *fakeEnrichmentBookUpdater) UpdateBookEntry(ctx context.Context, book repository.Book, userID int) error {
+	u.updated = append(u.updated, book)
+	return nil
+}
+
+type fakeEnrichmentMetadataProvider struct {
+	MetadataProvider
+}
+
+func TestEnrichmentChanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		book  repository.Book
+		match repository.Book
+		want  []repository.EnrichmentFieldChange
+	}{
+		{
+			name: "every missing field, ISBN-13 computed from the ISBN-10",
+			book: repository.Book{Title: "Kindred"},
+			match: repository.Book{
+				ISBN10:      "0-8070-8305-4",
+				PageCount:   264,
+				ImageLink:   "https://books.google.com/books/content?id=kindred",
+				PublishDate: "1979",
+			},
+			want: []repository.EnrichmentFieldChange{
+				{Field: repository.EnrichmentFieldISBN13, Proposed: "9780807083055"},
+				{Field: repository.EnrichmentFieldISBN10, Proposed: "0807083054"},
+				{Field: repository.EnrichmentFieldPageCount, Proposed: "264"},
+				{Field: repository.EnrichmentFieldImageLink, Proposed: "https://books.google.com/books/content?id=kindred"},
+				{Field: repository.EnrichmentFieldPublishDate, Proposed: "1979-01-01"},
+			},
+		},
+		{
+			name: "filled fields are never proposed",
+			book: repository.Book{
+				ISBN10:      "0807083054",
+				ISBN13:      "9780807083055",
+				PageCount:   300,
+				PublishDate: "1979-06-01",
+			},
+			match: repository.Book{
+				ISBN13:      "9780807083055",
+				PageCount:   264,
+				ImageLink:   "https://books.google.com/books/content?id=kindred",
+				PublishDate: "1979",
+			},
+			want: []repository.EnrichmentFieldChange{
+				{Field: repository.EnrichmentFieldImageLink, Proposed: "https://books.google.com/books/content?id=kindred"},
+			},
+		},
+		{
+			name:  "979 ISBNs have no ISBN-10",
+			book:  repository.Book{PageCount: 100, ImageLink: "x", PublishDate: "2020-01-01"},
+			match: repository.Book{ISBN13: "9791090636071"},
+			want: []repository.EnrichmentFieldChange{
+				{Field: repository.EnrichmentFieldISBN13, Proposed: "9791090636071"},
+			},
+		},
+		{
+			name:  "image links must be https from a provider's cover host",
+			book:  repository.Book{ISBN10: "0807083054", ISBN13: "9780807083055", PageCount: 264, PublishDate: "1979-06-01"},
+			match: repository.Book{ImageLink: "http://books.google.com/books/content?id=kindred"},
+		},
+		{
+			name:  "image links from other hosts are skipped",
+			book:  repository.Book{ISBN10: "0807083054", ISBN13: "9780807083055", PageCount: 264, PublishDate: "1979-06-01"},
+			match: repository.Book{ImageLink: "https://tracker.example.com/kindred.jpg"},
+		},
+		{
+			name:  "invalid ISBNs and empty values are skipped",
+			book:  repository.Book{},
+			match: repository.Book{ISBN13: "9780807083056", ISBN10: "0807083055"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := enrichmentChanges(tt.book, tt.match); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected changes\nwant %+v\n got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAcceptProposalKeepsFieldsFilledSinceScan(t *testing.T) {
+	const userID = 1
+
+	changes := []repository.EnrichmentFieldChange{
+		{Field: repository.EnrichmentFieldISBN13, Proposed: "9780807083055"},
+		{Field: repository.EnrichmentFieldPageCount, Proposed: "264"},
+		{Field: repository.EnrichmentFieldImageLink, Proposed: "https://books.google.com/books/content?id=kindred"},
+		{Field: repository.EnrichmentFieldPublishDate, Proposed: "1979-01-01"},
+	}
+	// The user filled in the ISBN and page count after the scan
+	stored := repository.Book{ID: 7, Title: "Kindred", ISBN13: "978-0-8070-8305-5", PageCount: 300}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   repository.Book
+	}{
+		{
+			name: "every change",
+			want: repository.Book{
+				ID:          7,
+				Title:       "Kindred",
+				ISBN13:      "978-0-8070-8305-5",
+				PageCount:   300,
+				ImageLink:   "https://books.google.com/books/content?id=kindred",
+				PublishDate: "1979-01-01",
+			},
+		},
+		{
+			name:   "selected fields only",
+			fields: []string{repository.EnrichmentFieldImageLink, repository.EnrichmentFieldPageCount},
+			want: repository.Book{
+				ID:        7,
+				Title:     "Kindred",
+				ISBN13:    "978-0-8070-8305-5",
+				PageCount: 300,
+				ImageLink: "https://books.google.com/books/content?id=kindred",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enrichmentRepo := &fakeEnrichmentRepo{proposal: &repository.EnrichmentProposal{
+				ID:      3,
+				UserID:  userID,
+				BookID:  stored.ID,
+				Changes: changes,
+				Status:  repository.EnrichmentProposalPending,
+			}}
+			updater := &fakeEnrichmentBookUpdater{}
+
+			service, err := NewEnrichmentService(newTestMetadataLogger(), enrichmentRepo, &fakeEnrichmentBookRepo{book: stored}, updater, &fakeEnrichmentMetadataProvider{})
+			if err != nil {
+				t.Fatalf("unexpected error creating enrichment service: %v", err)
+			}
+
+			if _, err := service.AcceptProposal(context.Background(), userID, 3, tt.fields); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(updater.updated) != 1 {
+				t.Fatalf("expected one update, got %d", len(updater.updated))
+			}
+			if !reflect.DeepEqual(updater.updated[0], tt.want) {
+				t.Errorf("unexpected book\nwant %+v\n got %+v", tt.want, updater.updated[0])
+			}
+
+			// Accepting again finds the proposal resolved
+			if _, err := service.AcceptProposal(context.Background(), userID, 3, tt.fields); !errors.Is(err, ErrEnrichmentProposalResolved) {
+				t.Errorf("expected ErrEnrichmentProposalResolved, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAcceptProposalSkipsDisallowedImageLinks(t *testing.T) {
+	enrichmentRepo := &fakeEnrichmentRepo{proposal: &repository.EnrichmentProposal{
+		ID:     3,
+		UserID: 1,
+		BookID: 7,
+		Changes: []repository.EnrichmentFieldChange{
+			{Field: repository.EnrichmentFieldImageLink, Proposed: "javascript:alert(1)"},
+			{Field: repository.EnrichmentFieldPageCount, Proposed: "264"},
+		},
+		Status: repository.EnrichmentProposalPending,
+	}}
+	updater := &fakeEnrichmentBookUpdater{}
+
+	service, err := NewEnrichmentService(newTestMetadataLogger(), enrichmentRepo, &fakeEnrichmentBookRepo{book: repository.Book{ID: 7}}, updater, &fakeEnrichmentMetadataProvider{})
+	if err != nil {
+		t.Fatalf("unexpected error creating enrichment service: %v", err)
+	}
+
+	if _, err := service.AcceptProposal(context.Background(), 1, 3, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := repository.Book{ID: 7, PageCount: 264}
+	if len(updater.updated) != 1 || !reflect.DeepEqual(updater.updated[0], want) {
+		t.Errorf("expected only the page count written, got %+v", updater.updated)
+	}
+}
+
+func TestAcceptProposalRejectsUnknownFields(t *testing.T) {
+	enrichmentRepo := &fakeEnrichmentRepo{proposal: &repository.EnrichmentProposal{
+		ID:      3,
+		UserID:  1,
+		BookID:  7,
+		Changes: []repository.EnrichmentFieldChange{{Field: repository.EnrichmentFieldImageLink, Proposed: "x"}},
+		Status:  repository.EnrichmentProposalPending,
+	}}
+	updater := &fakeEnrichmentBookUpdater{}
+
+	service, err := NewEnrichmentService(newTestMetadataLogger(), enrichmentRepo, &fakeEnrichmentBookRepo{book: repository.Book{ID: 7}}, updater, &fakeEnrichmentMetadataProvider{})
+	if err != nil {
+		t.Fatalf("unexpected error creating enrichment service: %v", err)
+	}
+
+	if _, err := service.AcceptProposal(context.Background(), 1, 3, []string{repository.EnrichmentFieldPageCount}); !errors.Is(err, ErrInvalidEnrichmentFields) {
+		t.Errorf("expected ErrInvalidEnrichmentFields, got %v", err)
+	}
+	if len(updater.updated) != 0 || enrichmentRepo.proposal.Status != repository.EnrichmentProposalPending {
+		t.Errorf("expected the proposal to stay pending and the book untouched")
+	}
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Calibre, BibTeX and RIS always describe the whole book, so these writers ignore the column selection
+
+// opfExportWriter zips one Calibre metadata.opf per book folder, the layout Calibre's "Add books from folders" reads
+type opfExportWriter struct {
+	zipWriter *zip.Writer
+}
+
+func (w *opfExportWriter) Begin() error { return nil }
+
+func (w *opfExportWriter) WriteBook(book repository.Book) error {
+	entry, err := w.zipWriter.Create(fmt.Sprintf("%d - %s/metadata.opf", book.ID, exportFileSlug(book)))
+	if err != nil {
+		return fmt.Errorf("failed to create OPF entry: %w", err)
+	}
+	return writeBookOPF(entry, book)
+}
+
+func (w *opfExportWriter) Flush() error {
+	return w.zipWriter.Flush()
+}
+
+func (w *opfExportWriter) End() error {
+	return w.zipWriter.Close()
+}
+
+// bibtexExportWriter writes one @book entry per book, cite keys stay unique across the file
+type bibtexExportWriter struct {
+	writer   io.Writer
+	citeKeys map[string]int
+}
+
+func (w *bibtexExportWriter) Begin() error { return nil }
+
+func (w *bibtexExportWriter) WriteBook(book repository.Book) error {
+	key := bibtexCiteKey(book)
+	w.citeKeys[key]++
+	// Repeats get a, b, c... the way BibTeX styles disambiguate same-year works
+	switch count := w.citeKeys[key]; {
+	case count > 27:
+		key += strconv.Itoa(count)
+	case count > 1:
+		key += string(rune('a' + count - 2))
+	}
+
+	_, err := io.WriteString(w.writer, renderBookBibTeX(book, key))
+	return err
+}
+
+func (w *bibtexExportWriter) Flush() error { return nil }
+
+func (w *bibtexExportWriter) End() error { return nil }
+
+// risExportWriter writes one TY/ER record per book
+type risExportWriter struct {
+	writer io.Writer
+}
+
+func (w *risExportWriter) Begin() error { return nil }
+
+func (w *risExportWriter) WriteBook(book repository.Book) error {
+	_, err := io.WriteString(w.writer, renderBookRIS(book))
+	return err
+}
+
+func (w *risExportWriter) Flush() error { return nil }
+
+func (w *risExportWriter) End() error { return nil }
+
+// writeBookOPF renders an OPF 2.0 package document with Dublin Core metadata, as Calibre writes metadata.opf
+func writeBookOPF(writer io.Writer, book repository.Book) error {
+	var doc strings.Builder
+
+	doc.WriteString(xml.Header)
+	doc.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="bravo_kilo_id" version="2.0">` + "\n")
+	doc.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">` + "\n")
+
+	writeOPFElement(&doc, `dc:identifier id="bravo_kilo_id" opf:scheme="bravo-kilo"`, strconv.Itoa(book.ID))
+	if isbn := exportPreferredISBN(book); isbn != "" {
+		writeOPFElement(&doc, `dc:identifier opf:scheme="ISBN"`, isbn)
+	}
+
+	writeOPFElement(&doc, "dc:title", exportCitationTitle(book))
+	for _, author := range book.Authors {
+		if author = strings.TrimSpace(author); author == "" {
+			continue
+		}
+		writeOPFElement(&doc, `dc:creator opf:role="aut" opf:file-as="`+escapeXMLAttribute(exportAuthorSortName(author))+`"`, author)
+	}
+
+	if date := exportOPFDate(book.PublishDate); date != "" {
+		writeOPFElement(&doc, "dc:date", date)
+	}
+	if description := flattenExportRichText(book.Description); description != "" {
+		writeOPFElement(&doc, "dc:description", description)
+	}
+	if book.Language != "" {
+		writeOPFElement(&doc, "dc:language", book.Language)
+	}
+
+	// Calibre reads dc:subject as tags
+	for _, subject := range exportSubjects(book) {
+		writeOPFElement(&doc, "dc:subject", subject)
+	}
+
+	doc.WriteString("  </metadata>\n")
+	doc.WriteString("</package>\n")
+
+	_, err := io.WriteString(writer, doc.String())
+	return err
+}
+
+// Helper fn: element is the opening tag contents, the closing tag is its first word
+func writeOPFElement(doc *strings.Builder, element string, text string) {
+	name, _, _ := strings.Cut(element, " ")
+	doc.WriteString("    <" + element + ">" + escapeXMLText(text) + "</" + name + ">\n")
+}
+
+var xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Helper fn: escape character data, dropping control characters XML 1.0 doesn't allow
+func escapeXMLText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, text)
+	return xmlTextEscaper.Replace(text)
+}
+
+func escapeXMLAttribute(value string) string {
+	return strings.ReplaceAll(escapeXMLText(value), `"`, "&quot;")
+}
+
+// Helper fn: Calibre stores dates as midnight UTC timestamps
+func exportOPFDate(publishDate string) string {
+	if date, err := time.Parse("2006-01-02", publishDate); err == nil {
+		return date.Format("2006-01-02T15:04:05-07:00")
+	}
+	if year := exportPublishYear(publishDate); year != "" {
+		return year + "-01-01T00:00:00+00:00"
+	}
+	return ""
+}
+
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	"{", `\{`,
+	"}", `\}`,
+	"&", `\&`,
+	"%", `\%`,
+	"$", `\$`,
+	"#", `\#`,
+	"_", `\_`,
+	"~", `\textasciitilde{}`,
+	"^", `\textasciicircum{}`,
+)
+
+// renderBookBibTeX writes a biblatex-compatible @book entry
+func renderBookBibTeX(book repository.Book, citeKey string) string {
+	var fields [][2]string
+
+	fields = append(fields, [2]string{"title", exportCitationTitle(book)})
+
+	var authors []string
+	for _, author := range book.Authors {
+		if author = strings.TrimSpace(author); author != "" {
+			authors = append(authors, author)
+		}
+	}
+	if len(authors) > 0 {
+		fields = append(fields, [2]string{"author", strings.Join(authors, " and ")})
+	}
+
+	fields = append(fields,
+		[2]string{"year", exportPublishYear(book.PublishDate)},
+		[2]string{"date", exportBibTeXDate(book.PublishDate)},
+		[2]string{"isbn", exportPreferredISBN(book)},
+		[2]string{"language", book.Language},
+		[2]string{"pagetotal", formatExportPageCount(book.PageCount)},
+		[2]string{"keywords", strings.Join(exportSubjects(book), ", ")},
+		[2]string{"abstract", collapseExportWhitespace(flattenExportRichText(book.Description))},
+	)
+
+	var entry strings.Builder
+	entry.WriteString("@book{" + citeKey + ",\n")
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		entry.WriteString("  " + field[0] + " = {" + bibtexEscaper.Replace(field[1]) + "},\n")
+	}
+	entry.WriteString("}\n\n")
+
+	return entry.String()
+}
+
+// Helper fn: first author's surname, year and first title word, e.g. "tolkien1954fellowship"
+func bibtexCiteKey(book repository.Book) string {
+	var key strings.Builder
+
+	if len(book.Authors) > 0 {
+		key.WriteString(exportASCIIWord(exportAuthorSurname(book.Authors[0])))
+	}
+	key.WriteString(exportPublishYear(book.PublishDate))
+
+	for _, word := range strings.Fields(book.Title) {
+		// Skip leading articles so keys group by the meaningful word
+		if lower := strings.ToLower(word); lower == "the" || lower == "a" || lower == "an" {
+			continue
+		}
+		if ascii := exportASCIIWord(word); ascii != "" {
+			key.WriteString(ascii)
+			break
+		}
+	}
+
+	if key.Len() == 0 {
+		return "book" + strconv.Itoa(book.ID)
+	}
+	return key.String()
+}
+
+// renderBookRIS writes one RIS record, each tag is a single line
+func renderBookRIS(book repository.Book) string {
+	var record strings.Builder
+
+	writeTag := func(tag string, value string) {
+		if value = collapseExportWhitespace(value); value != "" {
+			record.WriteString(tag + "  - " + value + "\r\n")
+		}
+	}
+
+	writeTag("TY", "BOOK")
+	writeTag("TI", exportCitationTitle(book))
+	for _, author := range book.Authors {
+		writeTag("AU", exportAuthorSortName(author))
+	}
+	writeTag("PY", exportPublishYear(book.PublishDate))
+	if date, err := time.Parse("2006-01-02", book.PublishDate); err == nil {
+		writeTag("DA", date.Format("2006/01/02/"))
+	}
+	writeTag("SN", exportPreferredISBN(book))
+	writeTag("LA", book.Language)
+	for _, keyword := range exportSubjects(book) {
+		writeTag("KW", keyword)
+	}
+	writeTag("AB", flattenExportRichText(book.Description))
+	record.WriteString("ER  - \r\n\r\n")
+
+	return record.String()
+}
+
+// Helper fn: titles are stored lowercased, citations use display casing
+func exportCitationTitle(book repository.Book) string {
+	title := cases.Title(language.Und).String(strings.TrimSpace(book.Title))
+	if subtitle := strings.TrimSpace(book.Subtitle); subtitle != "" {
+		title += ": " + subtitle
+	}
+	return title
+}
+
+// Helper fn: genres and tags together, Calibre, BibTeX and RIS have a single subject list
+func exportSubjects(book repository.Book) []string {
+	subjects := appendUniqueImportValues(nil, book.Genres...)
+	return appendUniqueImportValues(subjects, book.Tags...)
+}
+
+func exportPreferredISBN(book repository.Book) string {
+	if book.ISBN13 != "" {
+		return book.ISBN13
+	}
+	return book.ISBN10
+}
+
+// Helper fn: leading four digit year of a publish date, "" when there isn't one
+func exportPublishYear(publishDate string) string {
+	publishDate = strings.TrimSpace(publishDate)
+	if len(publishDate) < 4 {
+		return ""
+	}
+	if _, err := strconv.Atoi(publishDate[:4]); err != nil {
+		return ""
+	}
+	return publishDate[:4]
+}
+
+func exportBibTeXDate(publishDate string) string {
+	for _, layout := range []string{"2006-01-02", "2006-01"} {
+		if _, err := time.Parse(layout, publishDate); err == nil {
+			return publishDate
+		}
+	}
+	return ""
+}
+
+// Helper fn: "J. R. R. Tolkien" -> "Tolkien", names already written "Last, First" keep their surname
+func exportAuthorSurname(author string) string {
+	author = strings.TrimSpace(author)
+	if last, _, found := strings.Cut(author, ","); found {
+		return strings.TrimSpace(last)
+	}
+	words := strings.Fields(author)
+	if len(words) == 0 {
+		return ""
+	}
+	return words[len(words)-1]
+}
+
+// Helper fn: "First Middle Last" -> "Last, First Middle", the form Calibre's file-as and RIS AU expect
+func exportAuthorSortName(author string) string {
+	author = strings.TrimSpace(author)
+	if strings.Contains(author, ",") {
+		return author
+	}
+	words := strings.Fields(author)
+	if len(words) < 2 {
+		return author
+	}
+	return words[len(words)-1] + ", " + strings.Join(words[:len(words)-1], " ")
+}
+
+// Helper fn: lowercase ASCII letters and digits only, accents folded
+func exportASCIIWord(word string) string {
+	var ascii strings.Builder
+	for _, r := range norm.NFD.String(word) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			ascii.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return ascii.String()
+}
+
+func collapseExportWhitespace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Helper fn: filesystem-safe name built from the title, used for download and folder names
+func exportFileSlug(book repository.Book) string {
+	var words []string
+	for _, word := range strings.Fields(book.Title) {
+		if ascii := exportASCIIWord(word); ascii != "" {
+			words = append(words, ascii)
+		}
+		if len(words) == 8 {
+			break
+		}
+	}
+	if len(words) == 0 {
+		return "book-" + strconv.Itoa(book.ID)
+	}
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+func TestCitationRendering(t *testing.T) {
+	book := newTestExportBook()
+
+	tests := []struct {
+		name   string
+		render func(book repository.Book) string
+		want   string
+	}{
+		{
+			name:   "bibtex",
+			render: func(book repository.Book) string { return renderBookBibTeX(book, bibtexCiteKey(book)) },
+			want: "@book{tolkien1954fellowship,\n" +
+				"  title = {The Fellowship Of The Ring},\n" +
+				"  author = {J. R. R. Tolkien},\n" +
+				"  year = {1954},\n" +
+				"  date = {1954-07-29},\n" +
+				"  isbn = {9780547928210},\n" +
+				"  language = {en},\n" +
+				"  keywords = {Fantasy, favourites},\n" +
+				"  abstract = {The first volume, 50\\% of the journey \\& more.},\n" +
+				"}\n\n",
+		},
+		{
+			name:   "ris",
+			render: renderBookRIS,
+			want: "TY  - BOOK\r\n" +
+				"TI  - The Fellowship Of The Ring\r\n" +
+				"AU  - Tolkien, J. R. R.\r\n" +
+				"PY  - 1954\r\n" +
+				"DA  - 1954/07/29/\r\n" +
+				"SN  - 9780547928210\r\n" +
+				"LA  - en\r\n" +
+				"KW  - Fantasy\r\n" +
+				"KW  - favourites\r\n" +
+				"AB  - The first volume, 50% of the journey & more.\r\n" +
+				"ER  - \r\n\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.render(book); got != tt.want {
+				t.Errorf("unexpected %s\nwant %q\n got %q", tt.name, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteBookOPF(t *testing.T) {
+	book := newTestExportBook()
+	book.Authors = []string{"J. R. R. Tolkien", "Christopher <Ed.> Tolkien"}
+
+	var output bytes.Buffer
+	if err := writeBookOPF(&output, book); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`<dc:identifier opf:scheme="ISBN">9780547928210</dc:identifier>`,
+		`<dc:title>The Fellowship Of The Ring</dc:title>`,
+		`<dc:creator opf:role="aut" opf:file-as="Tolkien, J. R. R.">J. R. R. Tolkien</dc:creator>`,
+		`<dc:creator opf:role="aut" opf:file-as="Tolkien, Christopher &lt;Ed.&gt;">Christopher &lt;Ed.&gt; Tolkien</dc:creator>`,
+		`<dc:date>1954-07-29T00:00:00+00:00</dc:date>`,
+		`<dc:subject>Fantasy</dc:subject>`,
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("OPF is missing %s\n%s", want, output.String())
+		}
+	}
+}
+
+func TestBibTeXCiteKeysStayUnique(t *testing.T) {
+	var output bytes.Buffer
+	writer, err := newBookExportWriter(ExportFormatBibTeX, &output, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	untitled := repository.Book{ID: 9}
+	for _, book := range []repository.Book{newTestExportBook(), newTestExportBook(), newTestExportBook(), untitled} {
+		if err := writer.WriteBook(book); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	for _, key := range []string{"@book{tolkien1954fellowship,", "@book{tolkien1954fellowshipa,", "@book{tolkien1954fellowshipb,", "@book{book9,"} {
+		if !strings.Contains(output.String(), key) {
+			t.Errorf("expected cite key %s in\n%s", key, output.String())
+		}
+	}
+}
+
+func TestExportBook(t *testing.T) {
+	service, err := NewExportService(slog.New(slog.NewTextHandler(io.Discard, nil)), &fakeExportBookRepo{})
+	if err != nil {
+		t.Fatalf("unexpected error creating export service: %v", err)
+	}
+	book := newTestExportBook()
+
+	tests := []struct {
+		format      ExportFormat
+		fileName    string
+		contentType string
+		want        string
+	}{
+		{
+			// A lone book is served as the OPF document itself, not a zip
+			format:      ExportFormatOPF,
+			fileName:    "metadata.opf",
+			contentType: "application/oebps-package+xml",
+			want:        `<dc:title>The Fellowship Of The Ring</dc:title>`,
+		},
+		{
+			format:   ExportFormatBibTeX,
+			fileName: "the-fellowship-of-the-ring.bib",
+			want:     "@book{tolkien1954fellowship,\n",
+		},
+		{
+			format:   ExportFormatRIS,
+			fileName: "the-fellowship-of-the-ring.ris",
+			want:     "TY  - BOOK\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var output bytes.Buffer
+			if err := service.ExportBook(book, tt.format, &output, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(output.String(), tt.want) {
+				t.Errorf("expected %q in\n%s", tt.want, output.String())
+			}
+			if got := tt.format.BookFileName(book); got != tt.fileName {
+				t.Errorf("expected file name %q, got %q", tt.fileName, got)
+			}
+			if tt.contentType != "" && tt.format.BookContentType() != tt.contentType {
+				t.Errorf("expected content type %q, got %q", tt.contentType, tt.format.BookContentType())
+			}
+		})
+	}
+
+	if got := ExportFormatRIS.BookFileName(repository.Book{ID: 9, Title: "!!!"}); got != "book-9.ris" {
+		t.Errorf("expected a file name from the book ID, got %q", got)
+	}
+}
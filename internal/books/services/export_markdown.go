@@ -0,0 +1,155 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"#", `\#`,
+)
+
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// renderDeltaMarkdown converts Quill Delta ops to Markdown. Line formats (header, list, blockquote)
+// live on the "\n" insert that ends each line, inline formats on the text inserts
+func renderDeltaMarkdown(rt repository.RichText) string {
+	var out, line strings.Builder
+	var state markdownLineState
+
+	for _, op := range rt.Ops {
+		text, ok := deltaInsertText(op.Insert)
+		if !ok {
+			continue // Embeds have no Markdown equivalent
+		}
+
+		parts := strings.Split(text, "\n")
+		for i, part := range parts {
+			if part != "" {
+				line.WriteString(applyInlineMarkdown(part, op.Attributes))
+			}
+
+			// Every part except the last is followed by a newline
+			if i < len(parts)-1 {
+				out.WriteString(state.formatLine(line.String(), op.Attributes))
+				out.WriteString("\n")
+				line.Reset()
+			}
+		}
+	}
+
+	if line.Len() > 0 {
+		out.WriteString(line.String())
+	}
+
+	return strings.TrimRight(out.String(), "\n")
+}
+
+// Helper fn: inserts come back from JSON as strings, StringToRichText builds *string
+func deltaInsertText(insert interface{}) (string, bool) {
+	switch v := insert.(type) {
+	case string:
+		return v, true
+	case *string:
+		if v == nil {
+			return "", false
+		}
+		return *v, true
+	default:
+		return "", false
+	}
+}
+
+func applyInlineMarkdown(text string, attributes map[string]interface{}) string {
+	// Keep surrounding whitespace outside the markers or Markdown won't render them
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+
+	formatted := escapeMarkdown(trimmed)
+	if attributes["bold"] == true {
+		formatted = "**" + formatted + "**"
+	}
+	if attributes["italic"] == true {
+		formatted = "*" + formatted + "*"
+	}
+	if attributes["strike"] == true {
+		formatted = "~~" + formatted + "~~"
+	}
+
+	return leading + formatted + trailing
+}
+
+// Tracks list numbering and spacing between consecutive lines
+type markdownLineState struct {
+	orderedIndex int
+	inList       bool
+}
+
+func (s *markdownLineState) formatLine(line string, attributes map[string]interface{}) string {
+	indent := ""
+	if level, ok := markdownAttributeInt(attributes["indent"]); ok && level > 0 {
+		indent = strings.Repeat("  ", level)
+	}
+
+	list, _ := attributes["list"].(string)
+	if list != "ordered" {
+		s.orderedIndex = 0
+	}
+
+	if list == "ordered" || list == "bullet" {
+		s.inList = true
+		if list == "ordered" {
+			s.orderedIndex++
+			return indent + strconv.Itoa(s.orderedIndex) + ". " + line
+		}
+		return indent + "- " + line
+	}
+
+	// A list needs a blank line before whatever follows it
+	prefix := ""
+	if s.inList {
+		prefix = "\n"
+		s.inList = false
+	}
+
+	switch {
+	case attributes["header"] != nil:
+		level, _ := markdownAttributeInt(attributes["header"])
+		// Book sections already use levels 1-3
+		return prefix + strings.Repeat("#", min(level+3, 6)) + " " + line + "\n"
+	case attributes["blockquote"] == true:
+		return prefix + "> " + line + "\n"
+	default:
+		// Blank line between paragraphs
+		return prefix + line + "\n"
+	}
+}
+
+// Helper fn: JSON numbers decode as float64
+func markdownAttributeInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
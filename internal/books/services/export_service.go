@@ -1,7 +1,7 @@
 package services
 
 import (
-	"encoding/csv"
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,10 +10,172 @@ import (
 	"unicode"
 
 	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/utils"
 )
 
 type ExportService interface {
-	GenerateBookCSV(userID int, writer io.Writer) error
+	GenerateBookExport(ctx context.Context, userID int, format ExportFormat, writer io.Writer, columns []string) error
+	ExportBook(book repository.Book, format ExportFormat, writer io.Writer, columns []string) error
+}
+
+// Books fetched per page when streaming an export
+const exportPageSize = 500
+
+// Implemented by http.ResponseWriter, lets each page reach the client as soon as it's written
+type exportFlusher interface {
+	Flush()
+}
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV      ExportFormat = "csv"
+	ExportFormatJSON     ExportFormat = "json"
+	ExportFormatNDJSON   ExportFormat = "ndjson"
+	ExportFormatMarkdown ExportFormat = "markdown"
+	ExportFormatOPF      ExportFormat = "opf" // Calibre metadata.opf, zipped per book for a library export
+	ExportFormatBibTeX   ExportFormat = "bibtex"
+	ExportFormatRIS      ExportFormat = "ris"
+)
+
+// ParseExportFormat validates a format= value, empty defaults to CSV
+func ParseExportFormat(raw string) (ExportFormat, error) {
+	switch format := ExportFormat(strings.ToLower(strings.TrimSpace(raw))); format {
+	case "":
+		return ExportFormatCSV, nil
+	case ExportFormatCSV, ExportFormatJSON, ExportFormatNDJSON, ExportFormatMarkdown,
+		ExportFormatOPF, ExportFormatBibTeX, ExportFormatRIS:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", raw)
+	}
+}
+
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportFormatJSON:
+		return "application/json"
+	case ExportFormatNDJSON:
+		return "application/x-ndjson"
+	case ExportFormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case ExportFormatOPF:
+		return "application/zip"
+	case ExportFormatBibTeX:
+		return "application/x-bibtex; charset=utf-8"
+	case ExportFormatRIS:
+		return "application/x-research-info-systems"
+	default:
+		return "text/csv"
+	}
+}
+
+func (f ExportFormat) FileName() string {
+	if f == ExportFormatOPF {
+		return "books-opf.zip"
+	}
+	return "books." + f.extension()
+}
+
+// BookContentType is the content type of a single book export, OPF is served unzipped
+func (f ExportFormat) BookContentType() string {
+	if f == ExportFormatOPF {
+		return "application/oebps-package+xml"
+	}
+	return f.ContentType()
+}
+
+// BookFileName names a single book export after its title
+func (f ExportFormat) BookFileName(book repository.Book) string {
+	if f == ExportFormatOPF {
+		return "metadata.opf" // The name Calibre looks for
+	}
+	return exportFileSlug(book) + "." + f.extension()
+}
+
+func (f ExportFormat) extension() string {
+	switch f {
+	case ExportFormatJSON:
+		return "json"
+	case ExportFormatNDJSON:
+		return "ndjson"
+	case ExportFormatMarkdown:
+		return "md"
+	case ExportFormatOPF:
+		return "opf"
+	case ExportFormatBibTeX:
+		return "bib"
+	case ExportFormatRIS:
+		return "ris"
+	default:
+		return "csv"
+	}
+}
+
+// Values written per export column, keyed by import field so exports round-trip through the importer
+var exportColumnValues = map[string]func(book repository.Book) string{
+	ImportFieldTitle:       func(book repository.Book) string { return book.Title },
+	ImportFieldSubtitle:    func(book repository.Book) string { return book.Subtitle },
+	ImportFieldAuthors:     func(book repository.Book) string { return joinExportList(book.Authors) },
+	ImportFieldDescription: func(book repository.Book) string { return flattenExportRichText(book.Description) },
+	ImportFieldNotes:       func(book repository.Book) string { return flattenExportRichText(book.Notes) },
+	ImportFieldLanguage:    func(book repository.Book) string { return book.Language },
+	ImportFieldPageCount:   func(book repository.Book) string { return formatExportPageCount(book.PageCount) },
+	ImportFieldPublishDate: func(book repository.Book) string { return book.PublishDate },
+	ImportFieldImageLink:   func(book repository.Book) string { return book.ImageLink },
+	ImportFieldGenres:      func(book repository.Book) string { return joinExportList(book.Genres) },
+	ImportFieldFormats:     func(book repository.Book) string { return joinExportList(book.Formats) },
+	ImportFieldTags:        func(book repository.Book) string { return joinExportList(book.Tags) },
+	ImportFieldISBN10:      func(book repository.Book) string { return book.ISBN10 },
+	ImportFieldISBN13:      func(book repository.Book) string { return book.ISBN13 },
+}
+
+// Header written for each export field, same names the importer's native layout uses
+var exportColumnHeaders = func() map[string]string {
+	headers := make(map[string]string, len(ImportColumns))
+	for _, column := range ImportColumns {
+		headers[matchImportField(column)] = column
+	}
+	return headers
+}()
+
+// ParseExportColumns resolves a comma separated columns= value to export fields, empty selects every column
+func ParseExportColumns(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return defaultExportColumns(), nil
+	}
+
+	columns := []string{}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		field := matchImportField(name)
+		if _, ok := exportColumnValues[field]; !ok {
+			return nil, fmt.Errorf("unknown export column %q", name)
+		}
+		if !seen[field] {
+			seen[field] = true
+			columns = append(columns, field)
+		}
+	}
+
+	if len(columns) == 0 {
+		return defaultExportColumns(), nil
+	}
+	return columns, nil
+}
+
+// Helper fn: every column in import order
+func defaultExportColumns() []string {
+	columns := make([]string, 0, len(ImportColumns))
+	for _, column := range ImportColumns {
+		columns = append(columns, matchImportField(column))
+	}
+	return columns
 }
 
 type ExportServiceImpl struct {
@@ -35,52 +197,147 @@ func NewExportService (
 	}, nil
 }
 
-// Generate Book CSV
-func (e *ExportServiceImpl) GenerateBookCSV(userID int, writer io.Writer) error {
-	books, err := e.bookRepository.GetAllBooksByUserID(userID)
+// GenerateBookExport streams the user's books in the requested format one page at a time,
+// nil columns writes every field
+func (e *ExportServiceImpl) GenerateBookExport(
+	ctx context.Context,
+	userID int,
+	format ExportFormat,
+	writer io.Writer,
+	columns []string,
+) error {
+	if len(columns) == 0 {
+		columns = defaultExportColumns()
+	}
+
+	bookWriter, err := newBookExportWriter(format, writer, columns)
 	if err != nil {
-		e.logger.Error("Failed to fetch books for user", "error", err)
 		return err
 	}
 
-	csvWriter := csv.NewWriter(writer)
-	defer func() {
-		csvWriter.Flush()
-		if err := csvWriter.Error(); err != nil {
-			e.logger.Error("Error flushing CSV writer: ", "error", err)
+	if err := bookWriter.Begin(); err != nil {
+		e.logger.Error("Failed to write export header", "format", format, "error", err)
+		return err
+	}
+	// Start the download before the first query returns
+	if err := e.flushExport(bookWriter, writer); err != nil {
+		return err
+	}
+
+	totalBooks := 0
+	err = forEachUserBookPage(ctx, e.bookRepository, userID, func(books []repository.Book) error {
+		for _, book := range books {
+			if err := bookWriter.WriteBook(book); err != nil {
+				e.logger.Error("Failed to write export row", "format", format, "bookID", book.ID, "error", err)
+				return err
+			}
 		}
-	}()
 
-	if _, err := writer.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
-		return fmt.Errorf("failed to write UTF-8 BOM: %w", err)
+		totalBooks += len(books)
+		return e.flushExport(bookWriter, writer)
+	})
+	if err != nil {
+		e.logger.Error("Failed to export books for user", "userID", userID, "error", err)
+		return err
+	}
+
+	if err := bookWriter.End(); err != nil {
+		e.logger.Error("Failed to finish export", "format", format, "error", err)
+		return err
+	}
+
+	e.logger.Info("Export completed", "userID", userID, "format", format, "totalBooks", totalBooks)
+	return nil
+}
+
+// ExportBook writes a single book in the requested format, nil columns writes every field
+func (e *ExportServiceImpl) ExportBook(book repository.Book, format ExportFormat, writer io.Writer, columns []string) error {
+	if len(columns) == 0 {
+		columns = defaultExportColumns()
 	}
 
-	header := []string{"Title", "Authors", "ISBN", "Language", "PageCount", "Publish Date"}
-	if err := csvWriter.Write(header); err != nil {
-		e.logger.Error("Failed to write CSV header", "error", err)
+	// A lone book needs no archive, Calibre reads the document as is
+	if format == ExportFormatOPF {
+		return writeBookOPF(writer, book)
+	}
+
+	bookWriter, err := newBookExportWriter(format, writer, columns)
+	if err != nil {
+		return err
+	}
+
+	if err := bookWriter.Begin(); err != nil {
+		return err
+	}
+	if err := bookWriter.WriteBook(book); err != nil {
+		e.logger.Error("Failed to write book export", "format", format, "bookID", book.ID, "error", err)
 		return err
 	}
+	return bookWriter.End()
+}
 
-	// Write data
-	for _, book := range books {
-		row := []string{
-			sanitizeCSVField(book.Title),
-			sanitizeCSVField(strings.Join(book.Authors, ", ")),
-			sanitizeCSVField(book.ISBN13),
-			sanitizeCSVField(book.Language),
-			sanitizeCSVField(strconv.Itoa(book.PageCount)),
-			sanitizeCSVField(book.PublishDate),
+// Helper fn: walk the user's library in ID order, one page at a time
+func forEachUserBookPage(
+	ctx context.Context,
+	bookRepository repository.BookRepository,
+	userID int,
+	fn func(books []repository.Book) error,
+) error {
+	afterID := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		// Add UTF-8 Byte Order Mark for Excel
-		if err := csvWriter.Write(row); err != nil {
-			e.logger.Error("Failed to write CSV row", "bookID", book.ID, "error", err )
+
+		books, err := bookRepository.GetBooksPageByUserID(ctx, userID, afterID, exportPageSize)
+		if err != nil {
 			return err
 		}
+		if len(books) == 0 {
+			return nil
+		}
+
+		if err := fn(books); err != nil {
+			return err
+		}
+
+		afterID = books[len(books)-1].ID
+		if len(books) < exportPageSize {
+			return nil
+		}
 	}
+}
 
+// Helper fn: push buffered rows through to the client
+func (e *ExportServiceImpl) flushExport(bookWriter bookExportWriter, writer io.Writer) error {
+	if err := bookWriter.Flush(); err != nil {
+		e.logger.Error("Failed to flush export", "error", err)
+		return err
+	}
+	if flusher, ok := writer.(exportFlusher); ok {
+		flusher.Flush()
+	}
 	return nil
 }
 
+// Helper fn: multi-value cells use the same separator the importer splits on
+func joinExportList(items []string) string {
+	return strings.Join(items, ImportListSeparator+" ")
+}
+
+// Helper fn: Quill documents always end in a newline, drop it from the cell
+func flattenExportRichText(rt repository.RichText) string {
+	return strings.TrimRight(utils.RichTextToString(rt), "\n")
+}
+
+// Helper fn: leave unknown page counts blank rather than writing 0
+func formatExportPageCount(pageCount int) string {
+	if pageCount <= 0 {
+		return ""
+	}
+	return strconv.Itoa(pageCount)
+}
+
 // Helper fn: Sanitize Fields
 func sanitizeCSVField(field string) string {
 	// Guard Clause
@@ -88,18 +345,15 @@ func sanitizeCSVField(field string) string {
 		return ""
 	}
 
-	// Prevent formula injection
-	if strings.ContainsAny(field, "=+-@") {
+	// Prevent formula injection, only a leading character is evaluated by spreadsheets
+	if strings.ContainsAny(field[:1], "=+-@") {
 		field = "'" + field
 	}
 
-	// Escape double quotes
-	field = strings.ReplaceAll(field, "\"", "\"\"")
-
-	// Remove/replace ctl chars
+	// Remove/replace ctl chars, line breaks survive since csv.Writer quotes them
 	var sanitized strings.Builder
 	for _, r := range field {
-		if unicode.IsControl(r) {
+		if unicode.IsControl(r) && r != '\n' {
 			sanitized.WriteRune(' ')
 		} else {
 			sanitized.WriteRune(r)
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+func TestParseExportColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty selects every column", raw: "", want: defaultExportColumns()},
+		{name: "only separators selects every column", raw: " , ,", want: defaultExportColumns()},
+		{
+			name: "header names and aliases",
+			raw:  "Title, author, Page Count, ISBN-13",
+			want: []string{ImportFieldTitle, ImportFieldAuthors, ImportFieldPageCount, ImportFieldISBN13},
+		},
+		{name: "repeats are dropped", raw: "title,Title,tags", want: []string{ImportFieldTitle, ImportFieldTags}},
+		{name: "unknown column", raw: "title,rating", wantErr: true},
+		{name: "isbn alone is ambiguous", raw: "isbn", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := ParseExportColumns(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", columns)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(columns, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, columns)
+			}
+		})
+	}
+}
+
+func TestSanitizeCSVField(t *testing.T) {
+	tests := map[string]string{
+		"":                 "",
+		"Kindred":          "Kindred",
+		"=SUM(A1:A2)":      "'=SUM(A1:A2)",
+		"+1":               "'+1",
+		"-1":               "'-1",
+		"@user":            "'@user",
+		"a = b":            "a = b",
+		"tab\there":        "tab here",
+		"line one\nline 2": "line one\nline 2",
+		"carriage\rreturn": "carriage return",
+	}
+
+	for input, want := range tests {
+		if got := sanitizeCSVField(input); got != want {
+			t.Errorf("sanitizeCSVField(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCSVExportColumns(t *testing.T) {
+	service, err := NewExportService(slog.New(slog.NewTextHandler(io.Discard, nil)), &fakeExportBookRepo{})
+	if err != nil {
+		t.Fatalf("unexpected error creating export service: %v", err)
+	}
+
+	book := repository.Book{
+		ID:        7,
+		Title:     "=Kindred",
+		Authors:   []string{"Octavia E. Butler", "Someone Else"},
+		PageCount: 264,
+		Tags:      []string{"owned"},
+		ISBN13:    "9780807083055",
+	}
+
+	tests := []struct {
+		name    string
+		columns string
+		want    string
+	}{
+		{
+			name:    "selected columns in the order asked for",
+			columns: "isbn13,title,authors",
+			want:    "ISBN-13,Title,Authors\n9780807083055,'=Kindred,Octavia E. Butler; Someone Else\n",
+		},
+		{
+			name:    "empty fields stay blank",
+			columns: "title,pageCount,language",
+			want:    "Title,Page Count,Language\n'=Kindred,264,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := ParseExportColumns(tt.columns)
+			if err != nil {
+				t.Fatalf("unexpected column error: %v", err)
+			}
+
+			var output bytes.Buffer
+			if err := service.ExportBook(book, ExportFormatCSV, &output, columns); err != nil {
+				t.Fatalf("unexpected export error: %v", err)
+			}
+
+			got := strings.TrimPrefix(output.String(), "\uFEFF")
+			if got != tt.want {
+				t.Errorf("unexpected CSV\nwant %q\n got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCSVExportRoundTripsThroughImport(t *testing.T) {
+	service, err := NewExportService(slog.New(slog.NewTextHandler(io.Discard, nil)), &fakeExportBookRepo{})
+	if err != nil {
+		t.Fatalf("unexpected error creating export service: %v", err)
+	}
+
+	book := repository.Book{
+		Title:       "Kindred",
+		Subtitle:    "A Novel",
+		Authors:     []string{"Octavia E. Butler"},
+		Description: repository.RichText{Ops: []repository.DeltaOp{{Insert: "Dana is pulled back in time.\n"}}},
+		Notes:       repository.RichText{Ops: []repository.DeltaOp{{Insert: "-1 for the ending\n"}}},
+		Language:    "en",
+		PageCount:   264,
+		PublishDate: "1979-06-01",
+		ImageLink:   "https://example.com/kindred.jpg",
+		Genres:      []string{"Fiction", "Science Fiction"},
+		Formats:     []string{"physical", "eBook"},
+		Tags:        []string{"owned"},
+		ISBN10:      "0807083054",
+		ISBN13:      "9780807083055",
+	}
+
+	var output bytes.Buffer
+	if err := service.ExportBook(book, ExportFormatCSV, &output, nil); err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+
+	format, imported, _ := readTestImport(t, output.String(), nil)
+	if format != "generic" || len(imported) != 1 {
+		t.Fatalf("expected one generic row, got %d %q rows", len(imported), format)
+	}
+	if !reflect.DeepEqual(imported[0], book) {
+		t.Errorf("book did not survive the round trip\nwant %+v\n got %+v", book, imported[0])
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// bookExportWriter renders one export format, books are written one at a time
+type bookExportWriter interface {
+	Begin() error
+	WriteBook(book repository.Book) error
+	Flush() error // Writes anything buffered through to the underlying writer
+	End() error
+}
+
+func newBookExportWriter(format ExportFormat, writer io.Writer, columns []string) (bookExportWriter, error) {
+	switch format {
+	case ExportFormatCSV:
+		return &csvExportWriter{writer: writer, csvWriter: csv.NewWriter(writer), columns: columns}, nil
+	case ExportFormatJSON:
+		return &jsonExportWriter{writer: writer, columns: columns}, nil
+	case ExportFormatNDJSON:
+		return &ndjsonExportWriter{encoder: json.NewEncoder(writer), columns: columns}, nil
+	case ExportFormatMarkdown:
+		return &markdownExportWriter{writer: writer, columns: columns}, nil
+	case ExportFormatOPF:
+		return &opfExportWriter{zipWriter: zip.NewWriter(writer)}, nil
+	case ExportFormatBibTeX:
+		return &bibtexExportWriter{writer: writer, citeKeys: make(map[string]int)}, nil
+	case ExportFormatRIS:
+		return &risExportWriter{writer: writer}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// JSON values per export field, rich text stays as Quill Delta ops
+var exportColumnJSONValues = map[string]func(book repository.Book) interface{}{
+	ImportFieldTitle:       func(book repository.Book) interface{} { return book.Title },
+	ImportFieldSubtitle:    func(book repository.Book) interface{} { return book.Subtitle },
+	ImportFieldAuthors:     func(book repository.Book) interface{} { return nonNilExportList(book.Authors) },
+	ImportFieldDescription: func(book repository.Book) interface{} { return nonNilExportRichText(book.Description) },
+	ImportFieldNotes:       func(book repository.Book) interface{} { return nonNilExportRichText(book.Notes) },
+	ImportFieldLanguage:    func(book repository.Book) interface{} { return book.Language },
+	ImportFieldPageCount:   func(book repository.Book) interface{} { return book.PageCount },
+	ImportFieldPublishDate: func(book repository.Book) interface{} { return book.PublishDate },
+	ImportFieldImageLink:   func(book repository.Book) interface{} { return book.ImageLink },
+	ImportFieldGenres:      func(book repository.Book) interface{} { return nonNilExportList(book.Genres) },
+	ImportFieldFormats:     func(book repository.Book) interface{} { return nonNilExportList(book.Formats) },
+	ImportFieldTags:        func(book repository.Book) interface{} { return nonNilExportList(book.Tags) },
+	ImportFieldISBN10:      func(book repository.Book) interface{} { return book.ISBN10 },
+	ImportFieldISBN13:      func(book repository.Book) interface{} { return book.ISBN13 },
+}
+
+// Helper fn: keys match the Book JSON tags used across the API
+func exportBookJSON(book repository.Book, columns []string) map[string]interface{} {
+	object := make(map[string]interface{}, len(columns)+1)
+	object["id"] = book.ID
+	for _, column := range columns {
+		object[column] = exportColumnJSONValues[column](book)
+	}
+	return object
+}
+
+func nonNilExportList(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
+func nonNilExportRichText(rt repository.RichText) repository.RichText {
+	if rt.Ops == nil {
+		rt.Ops = []repository.DeltaOp{}
+	}
+	return rt
+}
+
+// csvExportWriter writes the importer's column layout
+type csvExportWriter struct {
+	writer    io.Writer
+	csvWriter *csv.Writer
+	columns   []string
+}
+
+func (w *csvExportWriter) Begin() error {
+	// Add UTF-8 Byte Order Mark for Excel
+	if _, err := w.writer.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		return fmt.Errorf("failed to write UTF-8 BOM: %w", err)
+	}
+
+	header := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		header[i] = exportColumnHeaders[column]
+	}
+	return w.csvWriter.Write(header)
+}
+
+func (w *csvExportWriter) WriteBook(book repository.Book) error {
+	row := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		row[i] = sanitizeCSVField(exportColumnValues[column](book))
+	}
+	return w.csvWriter.Write(row)
+}
+
+func (w *csvExportWriter) Flush() error {
+	w.csvWriter.Flush()
+	return w.csvWriter.Error()
+}
+
+func (w *csvExportWriter) End() error {
+	return w.Flush()
+}
+
+// jsonExportWriter writes a single array of book objects
+type jsonExportWriter struct {
+	writer  io.Writer
+	columns []string
+	count   int
+}
+
+func (w *jsonExportWriter) Begin() error {
+	_, err := io.WriteString(w.writer, "[")
+	return err
+}
+
+func (w *jsonExportWriter) WriteBook(book repository.Book) error {
+	data, err := json.Marshal(exportBookJSON(book, w.columns))
+	if err != nil {
+		return fmt.Errorf("failed to marshal book: %w", err)
+	}
+
+	separator := "\n"
+	if w.count > 0 {
+		separator = ",\n"
+	}
+	w.count++
+
+	if _, err := io.WriteString(w.writer, separator); err != nil {
+		return err
+	}
+	_, err = w.writer.Write(data)
+	return err
+}
+
+func (w *jsonExportWriter) Flush() error { return nil }
+
+func (w *jsonExportWriter) End() error {
+	_, err := io.WriteString(w.writer, "\n]\n")
+	return err
+}
+
+// ndjsonExportWriter writes one book object per line
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (w *ndjsonExportWriter) Begin() error { return nil }
+
+func (w *ndjsonExportWriter) WriteBook(book repository.Book) error {
+	return w.encoder.Encode(exportBookJSON(book, w.columns))
+}
+
+func (w *ndjsonExportWriter) Flush() error { return nil }
+
+func (w *ndjsonExportWriter) End() error { return nil }
+
+// markdownExportWriter writes a readable document, one section per book
+type markdownExportWriter struct {
+	writer  io.Writer
+	columns []string
+}
+
+func (w *markdownExportWriter) Begin() error {
+	_, err := io.WriteString(w.writer, "# My Library\n")
+	return err
+}
+
+func (w *markdownExportWriter) WriteBook(book repository.Book) error {
+	var section strings.Builder
+
+	// The heading is the title only when it was selected, like every other column
+	heading := fmt.Sprintf("Book %d", book.ID)
+	if slices.Contains(w.columns, ImportFieldTitle) {
+		heading = escapeMarkdown(book.Title)
+	}
+	section.WriteString("\n## " + heading + "\n")
+
+	var details, richText strings.Builder
+	for _, column := range w.columns {
+		switch column {
+		case ImportFieldTitle:
+			continue
+		case ImportFieldSubtitle:
+			if book.Subtitle != "" {
+				section.WriteString("\n*" + escapeMarkdown(book.Subtitle) + "*\n")
+			}
+		case ImportFieldDescription, ImportFieldNotes:
+			rt := book.Description
+			if column == ImportFieldNotes {
+				rt = book.Notes
+			}
+			if rendered := renderDeltaMarkdown(rt); rendered != "" {
+				richText.WriteString("\n### " + exportColumnHeaders[column] + "\n\n" + rendered + "\n")
+			}
+		default:
+			if value := exportColumnValues[column](book); value != "" {
+				details.WriteString("- **" + exportColumnHeaders[column] + ":** " + escapeMarkdown(value) + "\n")
+			}
+		}
+	}
+
+	if details.Len() > 0 {
+		section.WriteString("\n" + details.String())
+	}
+	section.WriteString(richText.String())
+
+	_, err := io.WriteString(w.writer, section.String())
+	return err
+}
+
+func (w *markdownExportWriter) Flush() error { return nil }
+
+func (w *markdownExportWriter) End() error { return nil }
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// Counts flushes the way an http.ResponseWriter would see them
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (r *flushRecorder) Flush() { r.flushes++ }
+
+// fakeExportBookRepo pages through each user's books in ID order
+type fakeExportBookRepo struct {
+	repository.BookRepository
+	books map[int][]repository.Book
+}
+
+func (f *fakeExportBookRepo) GetBooksPageByUserID(ctx context.Context, userID int, afterID int, limit int) ([]repository.Book, error) {
+	page := []repository.Book{}
+	for _, book := range f.books[userID] {
+		if book.ID > afterID && len(page) < limit {
+			page = append(page, book)
+		}
+	}
+	return page, nil
+}
+
+func newTestExportBook() repository.Book {
+	return repository.Book{
+		ID:          1,
+		Title:       "the fellowship of the ring",
+		Authors:     []string{"J. R. R. Tolkien"},
+		Description: repository.RichText{Ops: []repository.DeltaOp{{Insert: "The first volume,\n50% of the journey & more.\n"}}},
+		Language:    "en",
+		PublishDate: "1954-07-29",
+		Genres:      []string{"Fantasy"},
+		Tags:        []string{"favourites", "fantasy"},
+		ISBN13:      "9780547928210",
+	}
+}
+
+func TestGenerateBookExportStreamsEveryPage(t *testing.T) {
+	const userID = 1
+	totalBooks := exportPageSize + 2
+
+	books := make([]repository.Book, totalBooks)
+	for i := range books {
+		books[i] = repository.Book{
+			ID:      i + 1,
+			Title:   fmt.Sprintf("book %d", i+1),
+			Authors: []string{"Someone"},
+		}
+	}
+	service, err := NewExportService(slog.New(slog.NewTextHandler(io.Discard, nil)), &fakeExportBookRepo{
+		books: map[int][]repository.Book{userID: books},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating export service: %v", err)
+	}
+
+	tests := []struct {
+		format     ExportFormat
+		countBooks func(t *testing.T, output []byte) int
+	}{
+		{
+			format: ExportFormatCSV,
+			countBooks: func(t *testing.T, output []byte) int {
+				records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+				if err != nil {
+					t.Fatalf("invalid CSV: %v", err)
+				}
+				return len(records) - 1 // Header row
+			},
+		},
+		{
+			format: ExportFormatJSON,
+			countBooks: func(t *testing.T, output []byte) int {
+				var objects []map[string]interface{}
+				if err := json.Unmarshal(output, &objects); err != nil {
+					t.Fatalf("invalid JSON: %v", err)
+				}
+				return len(objects)
+			},
+		},
+		{
+			format: ExportFormatNDJSON,
+			countBooks: func(t *testing.T, output []byte) int {
+				count := 0
+				scanner := bufio.NewScanner(bytes.NewReader(output))
+				for scanner.Scan() {
+					var object map[string]interface{}
+					if err := json.Unmarshal(scanner.Bytes(), &object); err != nil {
+						t.Fatalf("invalid NDJSON line %d: %v", count+1, err)
+					}
+					count++
+				}
+				return count
+			},
+		},
+		{
+			format: ExportFormatMarkdown,
+			countBooks: func(t *testing.T, output []byte) int {
+				return strings.Count(string(output), "\n## ")
+			},
+		},
+		{
+			format: ExportFormatOPF,
+			countBooks: func(t *testing.T, output []byte) int {
+				archive, err := zip.NewReader(bytes.NewReader(output), int64(len(output)))
+				if err != nil {
+					t.Fatalf("invalid zip: %v", err)
+				}
+				return len(archive.File)
+			},
+		},
+		{
+			format: ExportFormatBibTeX,
+			countBooks: func(t *testing.T, output []byte) int {
+				return strings.Count(string(output), "@book{")
+			},
+		},
+		{
+			format: ExportFormatRIS,
+			countBooks: func(t *testing.T, output []byte) int {
+				return strings.Count(string(output), "ER  - \r\n")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var output flushRecorder
+			if err := service.GenerateBookExport(context.Background(), userID, tt.format, &output, nil); err != nil {
+				t.Fatalf("unexpected export error: %v", err)
+			}
+
+			if got := tt.countBooks(t, output.Bytes()); got != totalBooks {
+				t.Errorf("expected %d books, got %d", totalBooks, got)
+			}
+			// Once for the header, then once per page
+			if output.flushes != 3 {
+				t.Errorf("expected 3 flushes, got %d", output.flushes)
+			}
+		})
+	}
+}
+
+// Helper fn: books written through a fresh export writer
+func writeTestExport(t *testing.T, format ExportFormat, columns []string, books []repository.Book) string {
+	t.Helper()
+
+	var output bytes.Buffer
+	writer, err := newBookExportWriter(format, &output, columns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Begin(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, book := range books {
+		if err := writer.WriteBook(book); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := writer.End(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return output.String()
+}
+
+func TestJSONExportWriters(t *testing.T) {
+	columns := []string{ImportFieldTitle, ImportFieldAuthors, ImportFieldNotes, ImportFieldPageCount}
+	books := []repository.Book{newTestExportBook(), {ID: 2, Title: "Untitled draft"}}
+
+	// Empty lists and rich text are written as [] and empty ops rather than null
+	want := []map[string]interface{}{
+		{
+			"id":        float64(1),
+			"title":     "the fellowship of the ring",
+			"authors":   []interface{}{"J. R. R. Tolkien"},
+			"notes":     map[string]interface{}{"ops": []interface{}{}},
+			"pageCount": float64(0),
+		},
+		{
+			"id":        float64(2),
+			"title":     "Untitled draft",
+			"authors":   []interface{}{},
+			"notes":     map[string]interface{}{"ops": []interface{}{}},
+			"pageCount": float64(0),
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var got []map[string]interface{}
+		if err := json.Unmarshal([]byte(writeTestExport(t, ExportFormatJSON, columns, books)), &got); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected objects\nwant %v\n got %v", want, got)
+		}
+	})
+
+	t.Run("json without books", func(t *testing.T) {
+		if got := writeTestExport(t, ExportFormatJSON, columns, nil); got != "[\n]\n" {
+			t.Errorf("expected an empty array, got %q", got)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSuffix(writeTestExport(t, ExportFormatNDJSON, columns, books), "\n"), "\n")
+		if len(lines) != len(want) {
+			t.Fatalf("expected %d lines, got %d", len(want), len(lines))
+		}
+		for i, line := range lines {
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &got); err != nil {
+				t.Fatalf("invalid NDJSON line %d: %v", i+1, err)
+			}
+			if !reflect.DeepEqual(got, want[i]) {
+				t.Errorf("line %d\nwant %v\n got %v", i+1, want[i], got)
+			}
+		}
+	})
+}
+
+func TestMarkdownExportWriter(t *testing.T) {
+	book := newTestExportBook()
+	book.Title = "Kindred *special* edition"
+
+	got := writeTestExport(t, ExportFormatMarkdown, []string{ImportFieldTitle, ImportFieldAuthors, ImportFieldDescription}, []repository.Book{book})
+
+	for _, want := range []string{
+		"\n## Kindred \\*special\\* edition\n",
+		"J. R. R. Tolkien",
+		"The first volume,",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"9780547928210", "favourites"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("unselected column %q in\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestMarkdownHeadingFollowsSelectedColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		want    string
+		unwant  string
+	}{
+		{
+			name:    "title selected",
+			columns: []string{ImportFieldTitle, ImportFieldAuthors},
+			want:    "\n## the fellowship of the ring\n",
+		},
+		{
+			name:    "title left out",
+			columns: []string{ImportFieldAuthors},
+			want:    "\n## Book 1\n",
+			unwant:  "fellowship",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			writer, err := newBookExportWriter(ExportFormatMarkdown, &output, tt.columns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := writer.WriteBook(newTestExportBook()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(output.String(), tt.want) {
+				t.Errorf("expected %q in\n%s", tt.want, output.String())
+			}
+			if tt.unwant != "" && strings.Contains(output.String(), tt.unwant) {
+				t.Errorf("expected no %q in\n%s", tt.unwant, output.String())
+			}
+		})
+	}
+}
+
+func TestRenderDeltaMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []repository.DeltaOp
+		want string
+	}{
+		{
+			name: "paragraphs",
+			ops:  []repository.DeltaOp{{Insert: "First line\nSecond line\n"}},
+			want: "First line\n\nSecond line",
+		},
+		{
+			name: "inline formats keep spaces outside the markers",
+			ops: []repository.DeltaOp{
+				{Insert: "Read "},
+				{Insert: "this ", Attributes: map[string]interface{}{"bold": true}},
+				{Insert: "now", Attributes: map[string]interface{}{"italic": true, "strike": true}},
+				{Insert: "\n"},
+			},
+			want: "Read **this** ~~*now*~~",
+		},
+		{
+			name: "headers sit below the book sections",
+			ops: []repository.DeltaOp{
+				{Insert: "Chapter one"},
+				{Insert: "\n", Attributes: map[string]interface{}{"header": float64(1)}},
+			},
+			want: "#### Chapter one",
+		},
+		{
+			name: "lists end with a blank line",
+			ops: []repository.DeltaOp{
+				{Insert: "One"},
+				{Insert: "\n", Attributes: map[string]interface{}{"list": "ordered"}},
+				{Insert: "Two"},
+				{Insert: "\n", Attributes: map[string]interface{}{"list": "ordered"}},
+				{Insert: "Nested"},
+				{Insert: "\n", Attributes: map[string]interface{}{"list": "bullet", "indent": float64(1)}},
+				{Insert: "After\n"},
+			},
+			want: "1. One\n2. Two\n  - Nested\n\nAfter",
+		},
+		{
+			name: "blockquote",
+			ops: []repository.DeltaOp{
+				{Insert: "Not all those who wander are lost"},
+				{Insert: "\n", Attributes: map[string]interface{}{"blockquote": true}},
+			},
+			want: "> Not all those who wander are lost",
+		},
+		{
+			name: "markdown characters are escaped",
+			ops:  []repository.DeltaOp{{Insert: "*not bold* [not a link] #1\n"}},
+			want: `\*not bold\* \[not a link\] \#1`,
+		},
+		{
+			name: "embeds are skipped",
+			ops: []repository.DeltaOp{
+				{Insert: "Cover:"},
+				{Insert: map[string]interface{}{"image": "https://example.com/cover.jpg"}},
+				{Insert: "\n"},
+			},
+			want: "Cover:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderDeltaMarkdown(repository.RichText{Ops: tt.ops}); got != tt.want {
+				t.Errorf("unexpected Markdown\nwant %q\n got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// ImportFormat maps rows from a specific export layout onto Books
+type ImportFormat interface {
+	Name() string
+	MapRecord(record []string) (repository.Book, error)
+}
+
+// Source-specific layouts, tried in order against the first row before falling back to generic column mapping
+var importFormatDetectors = []func(header importHeader) (ImportFormat, bool){
+	detectGoodreadsImport,
+	detectLibraryThingImport,
+}
+
+// Format values understood by the frontend
+const (
+	importFormatPhysical  = "physical"
+	importFormatEBook     = "eBook"
+	importFormatAudioBook = "audioBook"
+)
+
+// importHeader indexes a header row by lowercased column name
+type importHeader map[string]int
+
+func newImportHeader(record []string) importHeader {
+	header := make(importHeader, len(record))
+	for i, column := range record {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\uFEFF")))
+		if _, exists := header[name]; !exists {
+			header[name] = i
+		}
+	}
+	return header
+}
+
+func (h importHeader) has(columns ...string) bool {
+	for _, column := range columns {
+		if _, ok := h[strings.ToLower(column)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// value returns the trimmed cell for a column, or "" when the column or cell is missing
+func (h importHeader) value(record []string, column string) string {
+	i, ok := h[strings.ToLower(column)]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// importSource wraps the CSV reader with the detected format
+type importSource struct {
+	reader    *csv.Reader
+	format    ImportFormat
+	pending   []string // First record when the file has no header row
+	rowNumber int
+}
+
+func newImportSource(reader io.Reader, mapping *ImportColumnMapping) (*importSource, error) {
+	buffered := bufio.NewReader(reader)
+
+	csvReader := csv.NewReader(buffered)
+	csvReader.FieldsPerRecord = -1 // Column count is checked per format
+	csvReader.TrimLeadingSpace = true
+
+	// LibraryThing exports tab-separated files with unescaped quotes
+	if detectImportDelimiter(buffered) == '\t' {
+		csvReader.Comma = '\t'
+		csvReader.LazyQuotes = true
+		csvReader.TrimLeadingSpace = false // Would swallow the tab before an empty field
+	}
+
+	source := &importSource{reader: csvReader}
+
+	first, err := csvReader.Read()
+	if err == io.EOF {
+		source.format, _ = newGenericImportFormat(ImportColumns, nil)
+		return source, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A user mapping always describes a header row
+	if mapping != nil {
+		format, err := newGenericImportFormat(first, mapping)
+		if err != nil {
+			return nil, err
+		}
+		source.format = format
+		source.rowNumber = 1
+		return source, nil
+	}
+
+	header := newImportHeader(first)
+	for _, detect := range importFormatDetectors {
+		if format, ok := detect(header); ok {
+			source.format = format
+			source.rowNumber = 1
+			return source, nil
+		}
+	}
+
+	if format, err := newGenericImportFormat(first, nil); err == nil {
+		source.format = format
+		source.rowNumber = 1
+		return source, nil
+	}
+
+	// No recognizable header, read columns in our export order and treat the first record as data
+	source.format, _ = newGenericImportFormat(ImportColumns, nil)
+	source.pending = first
+	return source, nil
+}
+
+// next returns the next data record along with its 1-based row number in the file
+func (src *importSource) next() ([]string, int, error) {
+	if src.pending != nil {
+		record := src.pending
+		src.pending = nil
+		src.rowNumber++
+		return record, src.rowNumber, nil
+	}
+
+	record, err := src.reader.Read()
+	if err == io.EOF {
+		return nil, src.rowNumber, err
+	}
+	src.rowNumber++
+	return record, src.rowNumber, err
+}
+
+// Helper fn: pick tab over comma when the first line has more tabs
+func detectImportDelimiter(reader *bufio.Reader) rune {
+	peeked, _ := reader.Peek(4096)
+	if end := bytes.IndexByte(peeked, '\n'); end >= 0 {
+		peeked = peeked[:end]
+	}
+	if bytes.Count(peeked, []byte("\t")) > bytes.Count(peeked, []byte(",")) {
+		return '\t'
+	}
+	return ','
+}
+
+// Helper fn: empty page counts are allowed, anything else must be a non-negative integer
+func parseImportPageCount(field string) (int, error) {
+	if field == "" {
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(field)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("Page Count: invalid number %q", field)
+	}
+	return count, nil
+}
+
+// Helper fn: map free-form binding/media descriptions onto our format values
+func mapImportBookFormat(binding string) string {
+	binding = strings.ToLower(strings.TrimSpace(binding))
+	switch {
+	case binding == "":
+		return ""
+	case strings.Contains(binding, "audio"):
+		return importFormatAudioBook
+	case strings.Contains(binding, "ebook"),
+		strings.Contains(binding, "e-book"),
+		strings.Contains(binding, "kindle"),
+		strings.Contains(binding, "nook"),
+		strings.Contains(binding, "digital"):
+		return importFormatEBook
+	default:
+		return importFormatPhysical
+	}
+}
+
+// Helper fn: ratings are kept as tags since Book has no rating field
+func importRatingTag(rating string) string {
+	value, err := strconv.ParseFloat(strings.TrimSpace(rating), 64)
+	if err != nil || value <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("Rated %s/5", strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// Helper fn: undo the leading quote our export adds to cells that look like formulas
+func unescapeImportFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsAny(cell[1:2], "=+-@") {
+		return cell[1:]
+	}
+	return cell
+}
+
+// Helper fn: join labelled note sections, skipping empty ones
+func buildImportNotes(sections ...[2]string) string {
+	var notes []string
+	for _, section := range sections {
+		label, text := section[0], strings.TrimSpace(section[1])
+		if text == "" {
+			continue
+		}
+		notes = append(notes, label+": "+text)
+	}
+	return strings.Join(notes, "\n")
+}
+
+// Helper fn: append non-empty values that aren't already present
+func appendUniqueImportValues(values []string, additions ...string) []string {
+	for _, addition := range additions {
+		addition = strings.TrimSpace(addition)
+		if addition == "" {
+			continue
+		}
+
+		exists := false
+		for _, value := range values {
+			if strings.EqualFold(value, addition) {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			values = append(values, addition)
+		}
+	}
+	return values
+}
+
+// Helper fn: split a comma separated cell, dropping blanks
+func splitImportCommaList(field string) []string {
+	items := []string{}
+	for _, item := range strings.Split(field, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Helper fn: sort a bare ISBN into its ISBN-10 or ISBN-13 slot
+func assignImportISBN(book *repository.Book, isbn string) {
+	isbn = normalizeImportISBN(isbn)
+	switch len(isbn) {
+	case 10:
+		if book.ISBN10 == "" {
+			book.ISBN10 = isbn
+		}
+	case 13:
+		if book.ISBN13 == "" {
+			book.ISBN13 = isbn
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// Book fields a generic CSV column can be mapped onto
+const (
+	ImportFieldTitle       = "title"
+	ImportFieldSubtitle    = "subtitle"
+	ImportFieldAuthors     = "authors"
+	ImportFieldDescription = "description"
+	ImportFieldNotes       = "notes"
+	ImportFieldLanguage    = "language"
+	ImportFieldPageCount   = "pageCount"
+	ImportFieldPublishDate = "publishDate"
+	ImportFieldImageLink   = "imageLink"
+	ImportFieldGenres      = "genres"
+	ImportFieldFormats     = "formats"
+	ImportFieldTags        = "tags"
+	ImportFieldISBN10      = "isbn10"
+	ImportFieldISBN13      = "isbn13"
+	ImportFieldISBN        = "isbn" // ISBN-10 or ISBN-13, sorted by length
+	ImportFieldIgnore      = "ignore"
+)
+
+// What to do with columns that don't match a known field
+const (
+	ImportUnknownColumnsIgnore = "ignore"
+	ImportUnknownColumnsTags   = "tags"
+)
+
+var ErrImportNoTitleColumn = errors.New("no column maps to title")
+
+// Header aliases per field, compared after dropping case, spaces and punctuation
+var importFieldAliases = map[string][]string{
+	ImportFieldTitle:       {"title", "booktitle", "name"},
+	ImportFieldSubtitle:    {"subtitle"},
+	ImportFieldAuthors:     {"authors", "author", "authorname", "authornames", "writer", "writers"},
+	ImportFieldDescription: {"description", "summary", "synopsis"},
+	ImportFieldNotes:       {"notes", "note", "comments", "comment", "mynotes"},
+	ImportFieldLanguage:    {"language", "lang"},
+	ImportFieldPageCount:   {"pagecount", "pages", "numberofpages", "numpages"},
+	ImportFieldPublishDate: {"publishdate", "publisheddate", "publicationdate", "published", "datepublished", "year", "yearpublished", "publicationyear"},
+	ImportFieldImageLink:   {"imagelink", "image", "imageurl", "cover", "coverurl", "coverimage", "thumbnail"},
+	ImportFieldGenres:      {"genres", "genre", "categories", "category", "subjects", "subject"},
+	ImportFieldFormats:     {"formats", "format", "binding", "media"},
+	ImportFieldTags:        {"tags", "tag", "shelves", "shelf", "bookshelves", "labels"},
+	ImportFieldISBN10:      {"isbn10"},
+	ImportFieldISBN13:      {"isbn13", "ean"},
+	ImportFieldISBN:        {"isbn", "isbns"},
+}
+
+// ImportColumnMapping is the optional user-supplied mapping sent with an upload
+type ImportColumnMapping struct {
+	// Header name to field, matched case-insensitively. Takes priority over aliases
+	Columns        map[string]string `json:"columns"`
+	UnknownColumns string            `json:"unknownColumns,omitempty"`
+}
+
+// ParseImportColumnMapping decodes and validates a mapping submitted with an upload
+func ParseImportColumnMapping(data []byte) (*ImportColumnMapping, error) {
+	var mapping ImportColumnMapping
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&mapping); err != nil {
+		return nil, fmt.Errorf("invalid column mapping: %w", err)
+	}
+
+	for column, field := range mapping.Columns {
+		if _, ok := importFieldAliases[field]; !ok && field != ImportFieldIgnore {
+			return nil, fmt.Errorf("invalid column mapping: unknown field %q for column %q", field, column)
+		}
+	}
+
+	switch mapping.UnknownColumns {
+	case "", ImportUnknownColumnsIgnore, ImportUnknownColumnsTags:
+	default:
+		return nil, fmt.Errorf("invalid column mapping: unknownColumns must be %q or %q", ImportUnknownColumnsIgnore, ImportUnknownColumnsTags)
+	}
+
+	return &mapping, nil
+}
+
+// genericImportFormat reads any spreadsheet whose header names our fields
+type genericImportFormat struct {
+	columnNames   []string
+	fields        []string // Field per column index, "" when unknown
+	unknownAsTags bool
+}
+
+func newGenericImportFormat(header []string, mapping *ImportColumnMapping) (ImportFormat, error) {
+	format := &genericImportFormat{
+		columnNames: make([]string, len(header)),
+		fields:      make([]string, len(header)),
+	}
+
+	userFields := map[string]string{}
+	if mapping != nil {
+		for column, field := range mapping.Columns {
+			userFields[strings.ToLower(strings.TrimSpace(column))] = field
+		}
+		format.unknownAsTags = mapping.UnknownColumns == ImportUnknownColumnsTags
+	}
+
+	hasTitle := false
+	for i, column := range header {
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\uFEFF"))
+		format.columnNames[i] = column
+
+		field, ok := userFields[strings.ToLower(column)]
+		if !ok {
+			field = matchImportField(column)
+		}
+		format.fields[i] = field
+
+		if field == ImportFieldTitle {
+			hasTitle = true
+		}
+	}
+
+	if !hasTitle {
+		return nil, ErrImportNoTitleColumn
+	}
+
+	return format, nil
+}
+
+func (f *genericImportFormat) Name() string { return "generic" }
+
+func (f *genericImportFormat) MapRecord(record []string) (repository.Book, error) {
+	book := repository.Book{
+		Authors: []string{},
+		Genres:  []string{},
+		Formats: []string{},
+		Tags:    []string{},
+	}
+
+	for i, cell := range record {
+		if i >= len(f.fields) {
+			break
+		}
+
+		cell = unescapeImportFormula(strings.TrimSpace(cell))
+		if cell == "" {
+			continue
+		}
+
+		switch f.fields[i] {
+		case ImportFieldTitle:
+			book.Title = cell
+		case ImportFieldSubtitle:
+			book.Subtitle = cell
+		case ImportFieldAuthors:
+			book.Authors = appendUniqueImportValues(book.Authors, splitImportList(cell)...)
+		case ImportFieldDescription:
+			book.Description = plainTextToRichText(cell)
+		case ImportFieldNotes:
+			book.Notes = plainTextToRichText(cell)
+		case ImportFieldLanguage:
+			book.Language = cell
+		case ImportFieldPageCount:
+			pageCount, err := parseImportPageCount(cell)
+			if err != nil {
+				return repository.Book{}, err
+			}
+			book.PageCount = pageCount
+		case ImportFieldPublishDate:
+			book.PublishDate = cell
+		case ImportFieldImageLink:
+			book.ImageLink = cell
+		case ImportFieldGenres:
+			book.Genres = appendUniqueImportValues(book.Genres, splitImportList(cell)...)
+		case ImportFieldFormats:
+			for _, format := range splitImportList(cell) {
+				book.Formats = appendUniqueImportValues(book.Formats, mapImportBookFormat(format))
+			}
+		case ImportFieldTags:
+			book.Tags = appendUniqueImportValues(book.Tags, splitImportList(cell)...)
+		case ImportFieldISBN10:
+			book.ISBN10 = normalizeImportISBN(cell)
+		case ImportFieldISBN13:
+			book.ISBN13 = normalizeImportISBN(cell)
+		case ImportFieldISBN:
+			for _, isbn := range splitImportList(cell) {
+				assignImportISBN(&book, isbn)
+			}
+		case "":
+			if f.unknownAsTags {
+				book.Tags = appendUniqueImportValues(book.Tags, f.columnNames[i]+": "+cell)
+			}
+		}
+	}
+
+	return book, nil
+}
+
+// Helper fn: resolve a header name against the field aliases
+func matchImportField(column string) string {
+	key := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, column)
+
+	for field, aliases := range importFieldAliases {
+		for _, alias := range aliases {
+			if key == alias {
+				return field
+			}
+		}
+	}
+	return ""
+}
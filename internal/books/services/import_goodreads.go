@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// goodreadsImportFormat reads goodreads_library_export.csv
+type goodreadsImportFormat struct {
+	header importHeader
+}
+
+func (f goodreadsImportFormat) Name() string { return "goodreads" }
+
+func detectGoodreadsImport(header importHeader) (ImportFormat, bool) {
+	matched := header.has("Book Id", "Title", "Author", "Exclusive Shelf", "My Rating")
+	return goodreadsImportFormat{header: header}, matched
+}
+
+func (f goodreadsImportFormat) MapRecord(record []string) (repository.Book, error) {
+	book := repository.Book{
+		Title:       f.header.value(record, "Title"),
+		Authors:     []string{},
+		Genres:      []string{},
+		Formats:     []string{},
+		Tags:        []string{},
+		PublishDate: f.header.value(record, "Year Published"),
+	}
+
+	book.Authors = appendUniqueImportValues(book.Authors, f.header.value(record, "Author"))
+	book.Authors = appendUniqueImportValues(book.Authors, splitImportCommaList(f.header.value(record, "Additional Authors"))...)
+
+	assignImportISBN(&book, unwrapGoodreadsISBN(f.header.value(record, "ISBN")))
+	assignImportISBN(&book, unwrapGoodreadsISBN(f.header.value(record, "ISBN13")))
+
+	pageCount, err := parseImportPageCount(f.header.value(record, "Number of Pages"))
+	if err != nil {
+		return repository.Book{}, err
+	}
+	book.PageCount = pageCount
+
+	if book.PublishDate == "" {
+		book.PublishDate = f.header.value(record, "Original Publication Year")
+	}
+
+	book.Formats = appendUniqueImportValues(book.Formats, mapImportBookFormat(f.header.value(record, "Binding")))
+
+	// Shelves become tags, the exclusive shelf is usually repeated in Bookshelves
+	book.Tags = appendUniqueImportValues(book.Tags, f.header.value(record, "Exclusive Shelf"))
+	book.Tags = appendUniqueImportValues(book.Tags, splitImportCommaList(f.header.value(record, "Bookshelves"))...)
+	book.Tags = appendUniqueImportValues(book.Tags, importRatingTag(f.header.value(record, "My Rating")))
+
+	book.Notes = plainTextToRichText(buildImportNotes(
+		[2]string{"Date read", f.header.value(record, "Date Read")},
+		[2]string{"Review", f.header.value(record, "My Review")},
+		[2]string{"Private notes", f.header.value(record, "Private Notes")},
+	))
+
+	return book, nil
+}
+
+// Helper fn: Goodreads wraps ISBNs as ="0439023483" to stop spreadsheets dropping leading zeros
+func unwrapGoodreadsISBN(isbn string) string {
+	isbn = strings.TrimPrefix(strings.TrimSpace(isbn), "=")
+	return strings.Trim(isbn, `"`)
+}
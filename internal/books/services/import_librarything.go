@@ -0,0 +1,105 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// LibraryThing exports language names, we store ISO 639-1 codes
+var libraryThingLanguageCodes = map[string]string{
+	"english":    "en",
+	"spanish":    "es",
+	"french":     "fr",
+	"german":     "de",
+	"italian":    "it",
+	"portuguese": "pt",
+	"dutch":      "nl",
+	"swedish":    "sv",
+	"norwegian":  "no",
+	"danish":     "da",
+	"finnish":    "fi",
+	"polish":     "pl",
+	"russian":    "ru",
+	"japanese":   "ja",
+	"chinese":    "zh",
+	"korean":     "ko",
+}
+
+// Default collection every LibraryThing book belongs to, not worth a tag
+const libraryThingDefaultCollection = "your library"
+
+// libraryThingImportFormat reads the tab-delimited LibraryThing export
+type libraryThingImportFormat struct {
+	header importHeader
+}
+
+func (f libraryThingImportFormat) Name() string { return "librarything" }
+
+func detectLibraryThingImport(header importHeader) (ImportFormat, bool) {
+	matched := header.has("Book Id", "Title", "Primary Author") &&
+		(header.has("ISBNs") || header.has("Collections"))
+	return libraryThingImportFormat{header: header}, matched
+}
+
+func (f libraryThingImportFormat) MapRecord(record []string) (repository.Book, error) {
+	book := repository.Book{
+		Title:       f.header.value(record, "Title"),
+		Authors:     []string{},
+		Genres:      []string{},
+		Formats:     []string{},
+		Tags:        []string{},
+		PublishDate: f.header.value(record, "Date"),
+	}
+
+	book.Authors = appendUniqueImportValues(book.Authors,
+		invertLibraryThingAuthor(f.header.value(record, "Primary Author")),
+		invertLibraryThingAuthor(f.header.value(record, "Secondary Author")),
+	)
+
+	// ISBN holds the primary ISBN as [0439023483], ISBNs lists every known one
+	assignImportISBN(&book, strings.Trim(f.header.value(record, "ISBN"), "[]"))
+	for _, isbn := range splitImportCommaList(f.header.value(record, "ISBNs")) {
+		assignImportISBN(&book, strings.Trim(isbn, "[]"))
+	}
+
+	pageCount, err := parseImportPageCount(f.header.value(record, "Page Count"))
+	if err != nil {
+		return repository.Book{}, err
+	}
+	book.PageCount = pageCount
+
+	if languages := splitImportCommaList(f.header.value(record, "Languages")); len(languages) > 0 {
+		if code, ok := libraryThingLanguageCodes[strings.ToLower(languages[0])]; ok {
+			book.Language = code
+		}
+	}
+
+	book.Formats = appendUniqueImportValues(book.Formats, mapImportBookFormat(f.header.value(record, "Media")))
+
+	book.Tags = appendUniqueImportValues(book.Tags, splitImportCommaList(f.header.value(record, "Tags"))...)
+	for _, collection := range splitImportCommaList(f.header.value(record, "Collections")) {
+		if !strings.EqualFold(collection, libraryThingDefaultCollection) {
+			book.Tags = appendUniqueImportValues(book.Tags, collection)
+		}
+	}
+	book.Tags = appendUniqueImportValues(book.Tags, importRatingTag(f.header.value(record, "Rating")))
+
+	book.Notes = plainTextToRichText(buildImportNotes(
+		[2]string{"Date read", f.header.value(record, "Date Read")},
+		[2]string{"Review", f.header.value(record, "Review")},
+		[2]string{"Comment", f.header.value(record, "Comment")},
+		[2]string{"Private comment", f.header.value(record, "Private Comment")},
+	))
+
+	return book, nil
+}
+
+// Helper fn: LibraryThing writes authors as "Last, First"
+func invertLibraryThingAuthor(name string) string {
+	last, first, found := strings.Cut(name, ",")
+	if !found {
+		return strings.TrimSpace(name)
+	}
+	return strings.TrimSpace(strings.TrimSpace(first) + " " + strings.TrimSpace(last))
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/collections"
+	"github.com/lokeam/bravo-kilo/internal/shared/transaction"
+	"github.com/lokeam/bravo-kilo/internal/shared/utils"
+)
+
+// Column order of our own CSV export, assumed when a file has no recognizable header row
+var ImportColumns = []string{
+	"Title",
+	"Subtitle",
+	"Authors",
+	"Description",
+	"Notes",
+	"Language",
+	"Page Count",
+	"Publish Date",
+	"Image Link",
+	"Genres",
+	"Formats",
+	"Tags",
+	"ISBN-10",
+	"ISBN-13",
+}
+
+const (
+	// Separator for multi-value cells (authors, genres, formats, tags)
+	ImportListSeparator = ";"
+
+	importMaxFieldLength    = 250
+	importMaxRichTextLength = repository.MaxOperationLength
+)
+
+type ImportRowStatus string
+
+const (
+	ImportRowInserted  ImportRowStatus = "inserted"
+	ImportRowDuplicate ImportRowStatus = "duplicate"
+	ImportRowRejected  ImportRowStatus = "rejected"
+)
+
+// ImportRowResult describes what happened to a single CSV row
+type ImportRowResult struct {
+	Row    int             `json:"row"`
+	Status ImportRowStatus `json:"status"`
+	Title  string          `json:"title,omitempty"`
+	BookID int             `json:"bookId,omitempty"`
+	Reason string          `json:"reason,omitempty"`
+}
+
+// ImportReport summarizes an import run
+type ImportReport struct {
+	Format     string            `json:"format"`
+	DryRun     bool              `json:"dryRun"`
+	TotalRows  int               `json:"totalRows"`
+	Inserted   int               `json:"inserted"`
+	Duplicates int               `json:"duplicates"`
+	Rejected   int               `json:"rejected"`
+	Rows       []ImportRowResult `json:"rows"`
+}
+
+// ImportProgressFunc is called after each row, returning an error stops the import
+type ImportProgressFunc func(result ImportRowResult) error
+
+var ErrImportCanceled = errors.New("import canceled")
+
+// ImportOptions carries per-upload settings through to the row mapper
+type ImportOptions struct {
+	Mapping *ImportColumnMapping // Optional, forces generic header mapping
+	DryRun  bool                 // Run every insert inside a transaction that is always rolled back
+}
+
+type ImportService interface {
+	ImportBooksCSV(ctx context.Context, userID int, reader io.Reader, opts ImportOptions, onRow ImportProgressFunc) (*ImportReport, error)
+	CountImportRows(reader io.Reader, opts ImportOptions) (int, error)
+}
+
+// Inserts one mapped row, returning the new book ID
+type importInsertFunc func(book repository.Book) (int, error)
+
+type ImportServiceImpl struct {
+	bookService BookService
+	bookCache   repository.BookCache
+	dbManager   transaction.DBManager
+	logger      *slog.Logger
+}
+
+func NewImportService(
+	logger *slog.Logger,
+	bookService BookService,
+	bookCache repository.BookCache,
+	dbManager transaction.DBManager,
+) (ImportService, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	if bookService == nil {
+		return nil, fmt.Errorf("book service is nil")
+	}
+
+	if bookCache == nil {
+		return nil, fmt.Errorf("book cache is nil")
+	}
+
+	if dbManager == nil {
+		return nil, fmt.Errorf("db manager is nil")
+	}
+
+	return &ImportServiceImpl{
+		bookService: bookService,
+		bookCache:   bookCache,
+		dbManager:   dbManager,
+		logger:      logger,
+	}, nil
+}
+
+// ImportBooksCSV maps each row onto a Book, dedupes against the user's library and inserts it
+func (s *ImportServiceImpl) ImportBooksCSV(
+	ctx context.Context,
+	userID int,
+	reader io.Reader,
+	opts ImportOptions,
+	onRow ImportProgressFunc,
+) (*ImportReport, error) {
+	isbn10Set, isbn13Set, err := s.loadExistingISBNs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := newImportSource(reader, opts.Mapping)
+	if errors.Is(err, ErrImportNoTitleColumn) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Error("Error reading import header", "error", err)
+		return nil, fmt.Errorf("error reading CSV: %w", err)
+	}
+
+	insert := func(book repository.Book) (int, error) {
+		return s.bookService.CreateBookEntry(ctx, book, userID)
+	}
+
+	if opts.DryRun {
+		tx, err := s.dbManager.BeginTransaction(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// Nothing from a preview is ever committed
+		defer s.dbManager.RollbackTransaction(tx)
+
+		insert = func(book repository.Book) (int, error) {
+			return s.previewInsert(ctx, tx, book, userID)
+		}
+	}
+
+	report := &ImportReport{
+		Format: source.format.Name(),
+		DryRun: opts.DryRun,
+		Rows:   []ImportRowResult{},
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		record, rowNumber, err := source.next()
+		if err == io.EOF {
+			break
+		}
+
+		var result ImportRowResult
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				s.logger.Error("Error reading CSV", "error", err, "row", rowNumber)
+				return report, fmt.Errorf("error reading CSV: %w", err)
+			}
+			result = ImportRowResult{
+				Row:    rowNumber,
+				Status: ImportRowRejected,
+				Reason: fmt.Sprintf("malformed CSV: %v", parseErr.Err),
+			}
+		} else {
+			result = s.importRow(userID, rowNumber, source.format, record, insert, isbn10Set, isbn13Set)
+		}
+
+		report.addResult(result)
+		if onRow != nil {
+			if err := onRow(result); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	s.logger.Info("CSV import completed",
+		"userID", userID,
+		"format", report.Format,
+		"dryRun", report.DryRun,
+		"totalRows", report.TotalRows,
+		"inserted", report.Inserted,
+		"duplicates", report.Duplicates,
+		"rejected", report.Rejected,
+	)
+
+	return report, nil
+}
+
+// CountImportRows returns the number of data rows, excluding the header row when present
+func (s *ImportServiceImpl) CountImportRows(reader io.Reader, opts ImportOptions) (int, error) {
+	source, err := newImportSource(reader, opts.Mapping)
+	if errors.Is(err, ErrImportNoTitleColumn) {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading CSV: %w", err)
+	}
+
+	count := 0
+	for {
+		_, _, err := source.next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return 0, fmt.Errorf("error reading CSV: %w", err)
+			}
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// Process a single record, recording inserted ISBNs so later rows dedupe against them
+func (s *ImportServiceImpl) importRow(
+	userID int,
+	rowNumber int,
+	format ImportFormat,
+	record []string,
+	insert importInsertFunc,
+	isbn10Set *collections.Set,
+	isbn13Set *collections.Set,
+) ImportRowResult {
+	result := ImportRowResult{Row: rowNumber}
+
+	book, err := format.MapRecord(record)
+	if err != nil {
+		result.Status = ImportRowRejected
+		result.Reason = err.Error()
+		return result
+	}
+
+	s.bookService.NormalizeBookData(&book)
+	s.bookService.SanitizeBookData(&book)
+	result.Title = book.Title
+
+	if err := validateImportedBook(book); err != nil {
+		result.Status = ImportRowRejected
+		result.Reason = err.Error()
+		return result
+	}
+
+	if (book.ISBN10 != "" && isbn10Set.Has(book.ISBN10)) || (book.ISBN13 != "" && isbn13Set.Has(book.ISBN13)) {
+		result.Status = ImportRowDuplicate
+		result.Reason = "book with matching ISBN already in library"
+		return result
+	}
+
+	bookID, err := insert(book)
+	if err != nil {
+		s.logger.Error("Error inserting imported book", "error", err, "row", rowNumber, "userID", userID)
+		result.Status = ImportRowRejected
+		result.Reason = "unable to save book"
+		return result
+	}
+
+	if book.ISBN10 != "" {
+		isbn10Set.Add(book.ISBN10)
+	}
+	if book.ISBN13 != "" {
+		isbn13Set.Add(book.ISBN13)
+	}
+
+	result.Status = ImportRowInserted
+	result.BookID = bookID
+	return result
+}
+
+// Insert inside a savepoint so a failed row doesn't abort the preview transaction
+func (s *ImportServiceImpl) previewInsert(ctx context.Context, tx *sql.Tx, book repository.Book, userID int) (int, error) {
+	if _, err := createBookEntryInSavepoint(ctx, tx, s.bookService, book, userID); err != nil {
+		return 0, err
+	}
+
+	// IDs from a transaction that is rolled back never exist
+	return 0, nil
+}
+
+// Copy the user's ISBN sets so the L1 cache entries aren't mutated during import
+func (s *ImportServiceImpl) loadExistingISBNs(userID int) (*collections.Set, *collections.Set, error) {
+	cached10, err := s.bookCache.GetAllBooksISBN10(userID)
+	if err != nil {
+		s.logger.Error("Error retrieving user's ISBN10", "error", err)
+		return nil, nil, err
+	}
+
+	cached13, err := s.bookCache.GetAllBooksISBN13(userID)
+	if err != nil {
+		s.logger.Error("Error retrieving user's ISBN13", "error", err)
+		return nil, nil, err
+	}
+
+	isbn10Set := collections.NewSet()
+	for _, isbn := range cached10.Elements() {
+		if isbn != "" {
+			isbn10Set.Add(isbn)
+		}
+	}
+
+	isbn13Set := collections.NewSet()
+	for _, isbn := range cached13.Elements() {
+		if isbn != "" {
+			isbn13Set.Add(isbn)
+		}
+	}
+
+	return isbn10Set, isbn13Set, nil
+}
+
+func (r *ImportReport) addResult(result ImportRowResult) {
+	r.TotalRows++
+	switch result.Status {
+	case ImportRowInserted:
+		r.Inserted++
+	case ImportRowDuplicate:
+		r.Duplicates++
+	case ImportRowRejected:
+		r.Rejected++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+// Helper fn: checks run after normalization + sanitization
+func validateImportedBook(book repository.Book) error {
+	if book.Title == "" {
+		return errors.New("title is required")
+	}
+
+	if len(book.Authors) == 0 {
+		return errors.New("at least one author is required")
+	}
+
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"title", book.Title},
+		{"subtitle", book.Subtitle},
+		{"language", book.Language},
+		{"publish date", book.PublishDate},
+		{"image link", book.ImageLink},
+		{"authors", strings.Join(book.Authors, ImportListSeparator)},
+		{"genres", strings.Join(book.Genres, ImportListSeparator)},
+		{"formats", strings.Join(book.Formats, ImportListSeparator)},
+		{"tags", strings.Join(book.Tags, ImportListSeparator)},
+	}
+	for _, field := range fields {
+		if err := utils.ValidateFieldLength(field.value, importMaxFieldLength); err != nil {
+			return fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+
+	if err := utils.ValidateFieldLength(utils.RichTextToString(book.Description), importMaxRichTextLength); err != nil {
+		return fmt.Errorf("description: %w", err)
+	}
+	if err := utils.ValidateFieldLength(utils.RichTextToString(book.Notes), importMaxRichTextLength); err != nil {
+		return fmt.Errorf("notes: %w", err)
+	}
+
+	if book.ISBN10 != "" && !utils.IsValidISBN10(book.ISBN10) {
+		return fmt.Errorf("invalid ISBN-10 %q", book.ISBN10)
+	}
+
+	if book.ISBN13 != "" && !utils.IsValidISBN13(book.ISBN13) {
+		return fmt.Errorf("invalid ISBN-13 %q", book.ISBN13)
+	}
+
+	if book.ImageLink != "" {
+		parsedURL, err := url.ParseRequestURI(book.ImageLink)
+		if err != nil || parsedURL.Scheme != "https" {
+			return errors.New("image link must be a valid HTTPS URL")
+		}
+	}
+
+	return nil
+}
+
+// Helper fn: split a multi-value cell, dropping blanks
+func splitImportList(field string) []string {
+	items := []string{}
+	for _, item := range strings.Split(field, ImportListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Helper fn: strip hyphens and spaces from an ISBN cell
+func normalizeImportISBN(isbn string) string {
+	isbn = strings.ReplaceAll(isbn, "-", "")
+	isbn = strings.ReplaceAll(isbn, " ", "")
+	return strings.ToUpper(isbn)
+}
+
+// Helper fn: wrap plain text as a single Quill Delta insert
+func plainTextToRichText(text string) repository.RichText {
+	if text == "" {
+		return repository.RichText{}
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return repository.RichText{
+		Ops: []repository.DeltaOp{{Insert: text}},
+	}
+}
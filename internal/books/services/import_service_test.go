@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/collections"
+)
+
+// Helper fn: every book in a CSV, along with the detected format and the file row each came from
+func readTestImport(t *testing.T, csvText string, mapping *ImportColumnMapping) (string, []repository.Book, []int) {
+	t.Helper()
+
+	source, err := newImportSource(strings.NewReader(csvText), mapping)
+	if err != nil {
+		t.Fatalf("unexpected error reading header: %v", err)
+	}
+
+	var books []repository.Book
+	var rows []int
+	for {
+		record, row, err := source.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading row %d: %v", row, err)
+		}
+
+		book, err := source.format.MapRecord(record)
+		if err != nil {
+			t.Fatalf("unexpected error mapping row %d: %v", row, err)
+		}
+		books = append(books, book)
+		rows = append(rows, row)
+	}
+	return source.format.Name(), books, rows
+}
+
+func TestImportFormatDetectionAndMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		csv        string
+		mapping    *ImportColumnMapping
+		wantFormat string
+		wantRows   []int
+		want       []repository.Book
+	}{
+		{
+			name: "goodreads export",
+			csv: "Book Id,Title,Author,Author l-f,Additional Authors,ISBN,ISBN13,My Rating,Binding,Number of Pages,Year Published,Original Publication Year,Date Read,Bookshelves,Exclusive Shelf,My Review,Private Notes\n" +
+				`2767052,The Hunger Games,Suzanne Collins,"Collins, Suzanne",,"=""0439023483""","=""9780439023481""",4,Hardcover,374,2008,2008,2012/05/01,"favorites, read",read,,` + "\n",
+			wantFormat: "goodreads",
+			wantRows:   []int{2},
+			want: []repository.Book{{
+				Title:       "The Hunger Games",
+				Authors:     []string{"Suzanne Collins"},
+				Genres:      []string{},
+				Formats:     []string{"physical"},
+				Tags:        []string{"read", "favorites", "Rated 4/5"},
+				PublishDate: "2008",
+				PageCount:   374,
+				ISBN10:      "0439023483",
+				ISBN13:      "9780439023481",
+				Notes:       repository.RichText{Ops: []repository.DeltaOp{{Insert: "Date read: 2012/05/01\n"}}},
+			}},
+		},
+		{
+			name: "goodreads export without ISBNs",
+			csv: "Book Id,Title,Author,Additional Authors,ISBN,ISBN13,My Rating,Exclusive Shelf\n" +
+				`1,A Zine,Anonymous,"Friend One, Friend Two","=""""","=""""",0,to-read` + "\n",
+			wantFormat: "goodreads",
+			wantRows:   []int{2},
+			want: []repository.Book{{
+				Title:   "A Zine",
+				Authors: []string{"Anonymous", "Friend One", "Friend Two"},
+				Genres:  []string{},
+				Formats: []string{},
+				Tags:    []string{"to-read"},
+			}},
+		},
+		{
+			name: "librarything tab separated export",
+			csv: "Book Id\tTitle\tPrimary Author\tSecondary Author\tDate\tISBNs\tISBN\tPage Count\tLanguages\tMedia\tCollections\tTags\tRating\n" +
+				"1234\tThe Left Hand of Darkness\tLe Guin, Ursula K.\t\t1969\t9780441478125, 0441478123\t[0441478123]\t304\tEnglish\tPaperback\tYour library, To read\tsf\t5\n",
+			wantFormat: "librarything",
+			wantRows:   []int{2},
+			want: []repository.Book{{
+				Title:       "The Left Hand of Darkness",
+				Authors:     []string{"Ursula K. Le Guin"},
+				Genres:      []string{},
+				Formats:     []string{"physical"},
+				Tags:        []string{"sf", "To read", "Rated 5/5"},
+				PublishDate: "1969",
+				PageCount:   304,
+				Language:    "en",
+				ISBN10:      "0441478123",
+				ISBN13:      "9780441478125",
+			}},
+		},
+		{
+			name: "our own export",
+			csv: strings.Join(ImportColumns, ",") + "\n" +
+				"Kindred,A Novel,Octavia E. Butler,,,en,264,,,,eBook,owned,,9780807083055\n",
+			wantFormat: "generic",
+			wantRows:   []int{2},
+			want: []repository.Book{{
+				Title:     "Kindred",
+				Subtitle:  "A Novel",
+				Authors:   []string{"Octavia E. Butler"},
+				Genres:    []string{},
+				Formats:   []string{"eBook"},
+				Tags:      []string{"owned"},
+				Language:  "en",
+				PageCount: 264,
+				ISBN13:    "9780807083055",
+			}},
+		},
+		{
+			name: "generic header matched by aliases",
+			csv: "Book Title,Author Name,Pages,ISBN,Shelves,Binding,Condition\n" +
+				"Kindred,Octavia E. Butler; Someone Else,264,978-0-8070-8305-5,owned; favourites,Kindle,Worn\n",
+			wantFormat: "generic",
+			wantRows:   []int{2},
+			want: []repository.Book{{
+				Title:     "Kindred",
+				Authors:   []string{"Octavia E. Butler", "Someone Else"},
+				Genres:    []string{},
+				Formats:   []string{"eBook"},
+				Tags:      []string{"owned", "favourites"},
+				PageCount: 264,
+				ISBN13:    "9780807083055",
+			}},
+		},
+		{
+			name: "generic header with a user mapping",
+			csv: "Book Title,Writer,Pages,Rating,Condition\n" +
+				"Kindred,Octavia E. Butler,264,5,Worn\n",
+			mapping: &ImportColumnMapping{
+				Columns:        map[string]string{"pages": ImportFieldIgnore, "Rating": ImportFieldTags},
+				UnknownColumns: ImportUnknownColumnsTags,
+			},
+			wantFormat: "generic",
+			wantRows:   []int{2},
+			want: []repository.Book{{
+				Title:   "Kindred",
+				Authors: []string{"Octavia E. Butler"},
+				Genres:  []string{},
+				Formats: []string{},
+				Tags:    []string{"5", "Condition: Worn"},
+			}},
+		},
+		{
+			name:       "no header row reads our export column order",
+			csv:        "Kindred,,Octavia E. Butler,,,en,264\nDawn,,Octavia E. Butler\n",
+			wantFormat: "generic",
+			wantRows:   []int{1, 2},
+			want: []repository.Book{
+				{Title: "Kindred", Authors: []string{"Octavia E. Butler"}, Genres: []string{}, Formats: []string{}, Tags: []string{}, Language: "en", PageCount: 264},
+				{Title: "Dawn", Authors: []string{"Octavia E. Butler"}, Genres: []string{}, Formats: []string{}, Tags: []string{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, books, rows := readTestImport(t, tt.csv, tt.mapping)
+
+			if format != tt.wantFormat {
+				t.Errorf("expected format %q, got %q", tt.wantFormat, format)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("expected rows %v, got %v", tt.wantRows, rows)
+			}
+			if !reflect.DeepEqual(books, tt.want) {
+				t.Errorf("unexpected books\nwant %+v\n got %+v", tt.want, books)
+			}
+		})
+	}
+}
+
+func TestUnwrapGoodreadsISBN(t *testing.T) {
+	tests := map[string]string{
+		`="0439023483"`:    "0439023483",
+		`="9780439023481"`: "9780439023481",
+		` ="" `:            "",
+		"0439023483":       "0439023483",
+	}
+
+	for input, want := range tests {
+		if got := unwrapGoodreadsISBN(input); got != want {
+			t.Errorf("unwrapGoodreadsISBN(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestImportColumnMappingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+	}{
+		{name: "unknown field", mapping: `{"columns": {"Name": "heading"}}`},
+		{name: "unknown option", mapping: `{"columns": {}, "extra": true}`},
+		{name: "bad unknownColumns", mapping: `{"columns": {}, "unknownColumns": "notes"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseImportColumnMapping([]byte(tt.mapping)); err == nil {
+				t.Error("expected an invalid mapping error")
+			}
+		})
+	}
+
+	mapping, err := ParseImportColumnMapping([]byte(`{"columns": {"Name": "ignore"}}`))
+	if err != nil {
+		t.Fatalf("unexpected mapping error: %v", err)
+	}
+	_, err = newImportSource(strings.NewReader("Name,Author\nKindred,Octavia E. Butler\n"), mapping)
+	if !errors.Is(err, ErrImportNoTitleColumn) {
+		t.Errorf("expected ErrImportNoTitleColumn, got %v", err)
+	}
+}
+
+func TestValidateImportedBook(t *testing.T) {
+	valid := repository.Book{
+		Title:     "Kindred",
+		Authors:   []string{"Octavia E. Butler"},
+		ISBN10:    "0807083054",
+		ISBN13:    "9780807083055",
+		ImageLink: "https://example.com/kindred.jpg",
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(book *repository.Book)
+		wantErr string
+	}{
+		{name: "valid", edit: func(book *repository.Book) {}},
+		{name: "missing title", edit: func(book *repository.Book) { book.Title = "" }, wantErr: "title is required"},
+		{name: "missing authors", edit: func(book *repository.Book) { book.Authors = nil }, wantErr: "at least one author"},
+		{name: "long title", edit: func(book *repository.Book) { book.Title = strings.Repeat("a", importMaxFieldLength+1) }, wantErr: "title:"},
+		{name: "bad ISBN-10 check digit", edit: func(book *repository.Book) { book.ISBN10 = "0807083055" }, wantErr: "invalid ISBN-10"},
+		{name: "bad ISBN-13 check digit", edit: func(book *repository.Book) { book.ISBN13 = "9780807083056" }, wantErr: "invalid ISBN-13"},
+		{name: "plain HTTP image link", edit: func(book *repository.Book) { book.ImageLink = "http://example.com/kindred.jpg" }, wantErr: "HTTPS"},
+		{
+			name: "long notes",
+			edit: func(book *repository.Book) {
+				book.Notes = plainTextToRichText(strings.Repeat("a", importMaxRichTextLength+1))
+			},
+			wantErr: "notes:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := valid
+			tt.edit(&book)
+
+			err := validateImportedBook(book)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+type fakeImportBookService struct {
+	BookService
+	failTitle   string
+	createdTx   []string
+	createdNoTx int
+}
+
+func (f *fakeImportBookService) CreateBookEntry(ctx context.Context, book repository.Book, userID int) (int, error) {
+	f.createdNoTx++
+	return f.createdNoTx, nil
+}
+
+func (f *fakeImportBookService) CreateBookEntryTx(ctx context.Context, tx *sql.Tx, book repository.Book, userID int) (int, error) {
+	if book.Title == f.failTitle {
+		return 0, errors.New("insert failed")
+	}
+	f.createdTx = append(f.createdTx, book.Title)
+	return len(f.createdTx), nil
+}
+
+func (f *fakeImportBookService) NormalizeBookData(book *repository.Book) {}
+func (f *fakeImportBookService) SanitizeBookData(book *repository.Book)  {}
+
+// The user's library, by ISBN-13 only
+type fakeImportBookCache struct {
+	repository.BookCache
+	isbn13 []string
+}
+
+func (f *fakeImportBookCache) GetAllBooksISBN10(userID int) (*collections.Set, error) {
+	return collections.NewSet(), nil
+}
+
+func (f *fakeImportBookCache) GetAllBooksISBN13(userID int) (*collections.Set, error) {
+	set := collections.NewSet()
+	for _, isbn := range f.isbn13 {
+		set.Add(isbn)
+	}
+	return set, nil
+}
+
+// fakeDBManager hands out transactions from whichever fake driver backs db
+type fakeDBManager struct{ db *sql.DB }
+
+func (m *fakeDBManager) BeginTransaction(ctx context.Context) (*sql.Tx, error) {
+	return m.db.BeginTx(ctx, nil)
+}
+func (m *fakeDBManager) CommitTransaction(tx *sql.Tx) error   { return tx.Commit() }
+func (m *fakeDBManager) RollbackTransaction(tx *sql.Tx) error { return tx.Rollback() }
+func (m *fakeDBManager) GetDB() *sql.DB                       { return m.db }
+
+// Records every statement and how the transaction ended
+type fakeImportConnector struct {
+	log *fakeImportSQLLog
+}
+
+type fakeImportSQLLog struct {
+	statements []string
+	commits    int
+	rollbacks  int
+}
+
+func (c fakeImportConnector) Connect(ctx context.Context) (driver.Conn, error) { return c, nil }
+func (c fakeImportConnector) Driver() driver.Driver                            { return c }
+func (c fakeImportConnector) Open(name string) (driver.Conn, error)            { return c, nil }
+func (c fakeImportConnector) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c fakeImportConnector) Close() error              { return nil }
+func (c fakeImportConnector) Begin() (driver.Tx, error) { return c, nil }
+func (c fakeImportConnector) Commit() error             { c.log.commits++; return nil }
+func (c fakeImportConnector) Rollback() error           { c.log.rollbacks++; return nil }
+func (c fakeImportConnector) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.log.statements = append(c.log.statements, query)
+	return driver.RowsAffected(0), nil
+}
+
+func TestImportBooksCSVReportsEachRow(t *testing.T) {
+	bookService := &fakeImportBookService{}
+	service, err := NewImportService(slog.New(slog.NewTextHandler(io.Discard, nil)), bookService, &fakeImportBookCache{isbn13: []string{"9780441478125"}}, &fakeDBManager{})
+	if err != nil {
+		t.Fatalf("unexpected error creating import service: %v", err)
+	}
+
+	csvText := strings.Join(ImportColumns, ",") + "\n" +
+		"Kindred,,Octavia E. Butler,,,en,264,1979,,,,,,9780807083055\n" +
+		"Kindred,,Octavia E. Butler,,,,,,,,,,,978-0-8070-8305-5\n" +
+		"The Left Hand of Darkness,,Ursula K. Le Guin,,,,,,,,,,,9780441478125\n" +
+		"Anonymous Zine,,,,,,,,,,,,,\n" +
+		"Short,row\n"
+	var progress []int
+	report, err := service.ImportBooksCSV(context.Background(), 1, strings.NewReader(csvText), ImportOptions{}, func(result ImportRowResult) error {
+		progress = append(progress, result.Row)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected import error: %v", err)
+	}
+	if !reflect.DeepEqual(progress, []int{2, 3, 4, 5, 6}) {
+		t.Errorf("expected progress for every row, got %v", progress)
+	}
+
+	wantStatuses := []ImportRowStatus{ImportRowInserted, ImportRowDuplicate, ImportRowDuplicate, ImportRowRejected, ImportRowRejected}
+	var statuses []ImportRowStatus
+	for i, row := range report.Rows {
+		statuses = append(statuses, row.Status)
+		if row.Row != i+2 {
+			t.Errorf("expected file row %d, got %d", i+2, row.Row)
+		}
+	}
+	if !reflect.DeepEqual(statuses, wantStatuses) {
+		t.Errorf("expected statuses %v, got %v", wantStatuses, statuses)
+	}
+	if report.TotalRows != 5 || report.Inserted != 1 || report.Duplicates != 2 || report.Rejected != 2 {
+		t.Errorf("unexpected totals %+v", report)
+	}
+	if bookService.createdNoTx != 1 {
+		t.Errorf("expected one book to be created, got %d", bookService.createdNoTx)
+	}
+}
+
+func TestImportBooksCSVDryRunRollsBackEachFailedRow(t *testing.T) {
+	sqlLog := &fakeImportSQLLog{}
+	db := sql.OpenDB(fakeImportConnector{log: sqlLog})
+	defer db.Close()
+
+	bookService := &fakeImportBookService{failTitle: "Broken"}
+	service, err := NewImportService(slog.New(slog.NewTextHandler(io.Discard, nil)), bookService, &fakeImportBookCache{}, &fakeDBManager{db: db})
+	if err != nil {
+		t.Fatalf("unexpected error creating import service: %v", err)
+	}
+
+	csvText := "Title,Authors\nKindred,Octavia E. Butler\nBroken,Someone\nDawn,Octavia E. Butler\n"
+	report, err := service.ImportBooksCSV(context.Background(), 1, strings.NewReader(csvText), ImportOptions{DryRun: true}, nil)
+	if err != nil {
+		t.Fatalf("unexpected import error: %v", err)
+	}
+
+	if report.Inserted != 2 || report.Rejected != 1 || !report.DryRun {
+		t.Errorf("unexpected report: inserted=%d rejected=%d dryRun=%v", report.Inserted, report.Rejected, report.DryRun)
+	}
+	for _, row := range report.Rows {
+		if row.BookID != 0 {
+			t.Errorf("row %d reported book ID %d from a rolled back preview", row.Row, row.BookID)
+		}
+	}
+
+	wantStatements := []string{
+		"SAVEPOINT book_entry", "RELEASE SAVEPOINT book_entry",
+		"SAVEPOINT book_entry", "ROLLBACK TO SAVEPOINT book_entry",
+		"SAVEPOINT book_entry", "RELEASE SAVEPOINT book_entry",
+	}
+	if !reflect.DeepEqual(sqlLog.statements, wantStatements) {
+		t.Errorf("unexpected statements\nwant %v\n got %v", wantStatements, sqlLog.statements)
+	}
+	if sqlLog.commits != 0 || sqlLog.rollbacks != 1 {
+		t.Errorf("expected the preview to roll back once and never commit, got commits=%d rollbacks=%d", sqlLog.commits, sqlLog.rollbacks)
+	}
+	if bookService.createdNoTx != 0 {
+		t.Errorf("a dry run inserted %d books outside the preview transaction", bookService.createdNoTx)
+	}
+}
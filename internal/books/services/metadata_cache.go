@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+// CachedMetadataProvider keeps search pages in Redis. Cached pages are shared across users,
+// per-user fields such as IsInLibrary have to be filled in after the read
+type CachedMetadataProvider struct {
+	provider     MetadataProvider
+	cacheService BookCacheService
+	ttl          time.Duration
+	logger       *slog.Logger
+}
+
+func NewCachedMetadataProvider(
+	logger *slog.Logger,
+	provider MetadataProvider,
+	cacheService BookCacheService,
+	ttl time.Duration,
+) (*CachedMetadataProvider, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	if provider == nil {
+		return nil, fmt.Errorf("metadata provider is nil")
+	}
+
+	if cacheService == nil {
+		return nil, fmt.Errorf("cache service is nil")
+	}
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("metadata search cache ttl must be positive")
+	}
+
+	return &CachedMetadataProvider{
+		provider:     provider,
+		cacheService: cacheService,
+		ttl:          ttl,
+		logger:       logger,
+	}, nil
+}
+
+func (c *CachedMetadataProvider) Name() string { return c.provider.Name() }
+
+// Search serves from cache when it can, a cache outage falls through to the provider
+func (c *CachedMetadataProvider) Search(ctx context.Context, query MetadataSearchQuery) (*MetadataSearchResult, error) {
+	cacheKey := query.CacheKey()
+
+	var cached MetadataSearchResult
+	found, err := c.cacheService.GetCachedMetadataSearch(ctx, cacheKey, &cached)
+	if err != nil {
+		c.logger.Warn("Metadata search cache read failed", "error", err)
+	} else if found {
+		c.logger.Debug("Cache hit for metadata search", "cacheKey", cacheKey, "provider", cached.Provider)
+		return &cached, nil
+	}
+
+	result, err := c.provider.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stored before the caller touches the books, so no user's overlay ends up in the shared copy
+	if err := c.cacheService.SetCachedMetadataSearch(ctx, cacheKey, result, c.ttl); err != nil {
+		c.logger.Warn("Metadata search cache write failed", "error", err)
+	}
+
+	return result, nil
+}
+
+func (c *CachedMetadataProvider) LookupISBN(ctx context.Context, isbn string) (*repository.Book, error) {
+	return c.provider.LookupISBN(ctx, isbn)
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Only the metadata search methods are used, anything else panics on the nil embedded interface
+type memoryMetadataSearchCache struct {
+	BookCacheService
+	entries map[string][]byte
+	readErr error
+}
+
+func (m *memoryMetadataSearchCache) GetCachedMetadataSearch(ctx context.Context, queryKey string, result interface{}) (bool, error) {
+	if m.readErr != nil {
+		return false, m.readErr
+	}
+	data, ok := m.entries[queryKey]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, result)
+}
+
+func (m *memoryMetadataSearchCache) SetCachedMetadataSearch(ctx context.Context, queryKey string, value interface{}, duration time.Duration) error {
+	data, err := json.Marshal(value)
+	m.entries[queryKey] = data
+	return err
+}
+
+// Counts searches and fails them while err is set
+type countingMetadataProvider struct {
+	MetadataProvider
+	calls int
+	err   error
+}
+
+func (p *countingMetadataProvider) Search(ctx context.Context, query MetadataSearchQuery) (*MetadataSearchResult, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &MetadataSearchResult{Provider: MetadataProviderOpenLibrary, TotalItems: 1}, nil
+}
+
+func TestCachedMetadataProviderSharesResultsWithoutUserOverlay(t *testing.T) {
+	logger := newTestMetadataLogger()
+	openLibrary := newTestOpenLibraryServer(t)
+
+	calls := 0
+	counted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		openLibrary.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer counted.Close()
+
+	cache := &memoryMetadataSearchCache{entries: map[string][]byte{}}
+	provider, err := NewCachedMetadataProvider(logger, NewOpenLibraryProvider(logger, counted.Client(), counted.URL), cache, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error creating cached provider: %v", err)
+	}
+
+	query := MetadataSearchQuery{Query: "left hand of darkness"}
+	first, err := provider.Search(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first.Books[0].IsInLibrary = true
+
+	second, err := provider.Search(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the second search to be served from cache, got %d provider calls", calls)
+	}
+	if second.TotalItems != 1 || len(second.Books) != 1 || second.Books[0].IsInLibrary {
+		t.Errorf("expected the cached page without another user's overlay, got %+v", second)
+	}
+
+	query.StartIndex = 35
+	if _, err := provider.Search(context.Background(), query); err != nil || calls != 2 {
+		t.Errorf("expected a different page to miss the cache, calls=%d err=%v", calls, err)
+	}
+}
+
+func TestCachedMetadataProviderDoesNotCacheFailures(t *testing.T) {
+	upstream := &countingMetadataProvider{err: ErrMetadataUnavailable}
+	cache := &memoryMetadataSearchCache{entries: map[string][]byte{}}
+	provider, err := NewCachedMetadataProvider(newTestMetadataLogger(), upstream, cache, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error creating cached provider: %v", err)
+	}
+
+	query := MetadataSearchQuery{Query: "kindred"}
+	if _, err := provider.Search(context.Background(), query); !errors.Is(err, ErrMetadataUnavailable) {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("expected nothing cached after a failed search, got %d entries", len(cache.entries))
+	}
+
+	// Once the provider recovers the next search goes through to it
+	upstream.err = nil
+	if _, err := provider.Search(context.Background(), query); err != nil {
+		t.Fatalf("unexpected error after recovery: %v", err)
+	}
+	if upstream.calls != 2 || len(cache.entries) != 1 {
+		t.Errorf("expected a second provider call that gets cached, calls=%d entries=%d", upstream.calls, len(cache.entries))
+	}
+}
+
+func TestCachedMetadataProviderFallsThroughWhenCacheIsDown(t *testing.T) {
+	upstream := &countingMetadataProvider{}
+	cache := &memoryMetadataSearchCache{entries: map[string][]byte{}, readErr: errors.New("redis: connection refused")}
+	provider, err := NewCachedMetadataProvider(newTestMetadataLogger(), upstream, cache, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error creating cached provider: %v", err)
+	}
+
+	result, err := provider.Search(context.Background(), MetadataSearchQuery{Query: "kindred"})
+	if err != nil {
+		t.Fatalf("expected a cache outage not to fail the search, got %v", err)
+	}
+	if upstream.calls != 1 || result.TotalItems != 1 {
+		t.Errorf("expected the provider's result, calls=%d result=%+v", upstream.calls, result)
+	}
+}
+
+func TestMetadataSearchQueryCacheKey(t *testing.T) {
+	base := MetadataSearchQuery{Query: "Left Hand", Author: "Le Guin", Language: "EN"}
+
+	same := MetadataSearchQuery{Query: "left hand", Author: "le guin", Language: "en", MaxResults: defaultMetadataMaxResults}
+	if base.CacheKey() != same.CacheKey() {
+		t.Error("expected letter case and the default page size not to change the cache key")
+	}
+
+	different := map[string]MetadataSearchQuery{
+		"page":       {Query: "Left Hand", Author: "Le Guin", Language: "EN", StartIndex: 35},
+		"page size":  {Query: "Left Hand", Author: "Le Guin", Language: "EN", MaxResults: 10},
+		"language":   {Query: "Left Hand", Author: "Le Guin", Language: "fr"},
+		"order":      {Query: "Left Hand", Author: "Le Guin", Language: "EN", OrderBy: "newest"},
+		"print type": {Query: "Left Hand", Author: "Le Guin", Language: "EN", PrintType: "magazines"},
+		"field":      {Query: "Left Hand", Title: "Le Guin", Language: "EN"},
+	}
+	for name, query := range different {
+		if query.CacheKey() == base.CacheKey() {
+			t.Errorf("expected a different %s to change the cache key", name)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/utils"
+)
+
+const googleBooksBaseURL = "https://www.googleapis.com/books/v1"
+
+// GoogleBooksProvider searches the Google Books volumes API. Requests carry the user's OAuth token when there is one,
+// and are retried with the API key alone when Google rejects the token
+type GoogleBooksProvider struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	logger     *slog.Logger
+}
+
+func NewGoogleBooksProvider(logger *slog.Logger, httpClient *http.Client, baseURL string, apiKey string) *GoogleBooksProvider {
+	return &GoogleBooksProvider{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		logger:     logger,
+	}
+}
+
+func (g *GoogleBooksProvider) Name() string { return MetadataProviderGoogleBooks }
+
+func (g *GoogleBooksProvider) Search(ctx context.Context, query MetadataSearchQuery) (*MetadataSearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query.googleBooksQuery())
+	params.Set("startIndex", strconv.Itoa(query.StartIndex))
+	params.Set("maxResults", strconv.Itoa(metadataMaxResults(query)))
+	if query.OrderBy == MetadataOrderNewest {
+		params.Set("orderBy", MetadataOrderNewest)
+	}
+	if query.Language != "" {
+		params.Set("langRestrict", query.Language)
+	}
+	if query.PrintType != "" {
+		params.Set("printType", query.PrintType)
+	}
+
+	volumes, err := g.getVolumes(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetadataSearchResult{
+		Provider:   g.Name(),
+		Books:      formatGoogleBooksResponse(g.logger, volumes),
+		TotalItems: volumes.TotalItems,
+	}, nil
+}
+
+func (g *GoogleBooksProvider) LookupISBN(ctx context.Context, isbn string) (*repository.Book, error) {
+	params := url.Values{}
+	params.Set("q", "isbn:"+isbn)
+	params.Set("maxResults", "1")
+
+	volumes, err := g.getVolumes(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	books := formatGoogleBooksResponse(g.logger, volumes)
+	if len(books) == 0 {
+		return nil, fmt.Errorf("%s: %w", g.Name(), ErrMetadataNotFound)
+	}
+	return &books[0], nil
+}
+
+// Helper fn: GET /volumes, falling back to key-only auth if the user's token was revoked
+func (g *GoogleBooksProvider) getVolumes(ctx context.Context, params url.Values) (*googleBooksVolumesResponse, error) {
+	if g.apiKey != "" {
+		params.Set("key", g.apiKey)
+	}
+	requestURL := g.baseURL + "/volumes?" + params.Encode()
+
+	token := metadataAccessToken(ctx)
+	volumes, statusCode, err := g.get(ctx, requestURL, token != nil)
+	if token != nil && (statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden) {
+		g.logger.Warn("Google Books rejected user token, retrying without it", "status", statusCode)
+		volumes, _, err = g.get(ctx, requestURL, false)
+	}
+	return volumes, err
+}
+
+func (g *GoogleBooksProvider) get(ctx context.Context, requestURL string, withToken bool) (*googleBooksVolumesResponse, int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if withToken {
+		metadataAccessToken(ctx).SetAuthHeader(request)
+	}
+
+	g.logger.Info("Requesting Google Books API", "url", g.baseURL+"/volumes", "authenticated", withToken)
+
+	booksResponse, err := g.httpClient.Do(request)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w: %v", g.Name(), ErrMetadataUnavailable, err)
+	}
+	defer booksResponse.Body.Close()
+
+	if booksResponse.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(booksResponse.Body, 4096))
+		g.logger.Error("Google Books API responded with non-OK status", "status", booksResponse.StatusCode, "body", string(body))
+		return nil, booksResponse.StatusCode, metadataStatusError(g.Name(), booksResponse.StatusCode)
+	}
+
+	var volumes googleBooksVolumesResponse
+	if err := json.NewDecoder(booksResponse.Body).Decode(&volumes); err != nil {
+		return nil, booksResponse.StatusCode, fmt.Errorf("%s: error decoding response: %w", g.Name(), err)
+	}
+	return &volumes, booksResponse.StatusCode, nil
+}
+
+// Google Books volumes response, https://developers.google.com/books/docs/v1/reference/volumes
+type googleBooksVolumesResponse struct {
+	TotalItems int               `json:"totalItems"`
+	Items      []json.RawMessage `json:"items"` // Decoded one at a time so a malformed volume doesn't sink the page
+}
+
+type googleBooksVolume struct {
+	ID         string                `json:"id"`
+	VolumeInfo googleBooksVolumeInfo `json:"volumeInfo"`
+}
+
+type googleBooksVolumeInfo struct {
+	Title               string                          `json:"title"`
+	Subtitle            string                          `json:"subtitle"`
+	Authors             []string                        `json:"authors"`
+	Publisher           string                          `json:"publisher"`
+	PublishedDate       string                          `json:"publishedDate"`
+	Description         string                          `json:"description"`
+	IndustryIdentifiers []googleBooksIndustryIdentifier `json:"industryIdentifiers"`
+	PageCount           int                             `json:"pageCount"`
+	Dimensions          *googleBooksDimensions          `json:"dimensions"`
+	PrintType           string                          `json:"printType"`
+	Categories          []string                        `json:"categories"`
+	AverageRating       float64                         `json:"averageRating"`
+	RatingsCount        int                             `json:"ratingsCount"`
+	MaturityRating      string                          `json:"maturityRating"`
+	ImageLinks          googleBooksImageLinks           `json:"imageLinks"`
+	Language            string                          `json:"language"`
+	PreviewLink         string                          `json:"previewLink"`
+	InfoLink            string                          `json:"infoLink"`
+}
+
+// Type is ISBN_10, ISBN_13, ISSN or OTHER
+type googleBooksIndustryIdentifier struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+}
+
+type googleBooksDimensions struct {
+	Height    string `json:"height"`
+	Width     string `json:"width"`
+	Thickness string `json:"thickness"`
+}
+
+// Search results only carry the two thumbnails, a single volume lookup can include the larger sizes
+type googleBooksImageLinks struct {
+	SmallThumbnail string `json:"smallThumbnail"`
+	Thumbnail      string `json:"thumbnail"`
+	Small          string `json:"small"`
+	Medium         string `json:"medium"`
+	Large          string `json:"large"`
+	ExtraLarge     string `json:"extraLarge"`
+}
+
+// Format Google Books Response
+func formatGoogleBooksResponse(logger *slog.Logger, volumes *googleBooksVolumesResponse) []repository.Book {
+	gBooksResponse := make([]repository.Book, 0, len(volumes.Items))
+
+	for i, item := range volumes.Items {
+		var volume googleBooksVolume
+		if err := json.Unmarshal(item, &volume); err != nil {
+			logger.Warn("Skipping malformed Google Books volume", "index", i, "error", err)
+			continue
+		}
+		if strings.TrimSpace(volume.VolumeInfo.Title) == "" {
+			logger.Warn("Skipping Google Books volume without a title", "index", i, "volumeID", volume.ID)
+			continue
+		}
+
+		gBooksResponse = append(gBooksResponse, volume.toBook())
+	}
+
+	return gBooksResponse
+}
+
+func (v googleBooksVolume) toBook() repository.Book {
+	info := v.VolumeInfo
+
+	book := repository.Book{
+		Title:          info.Title,
+		Subtitle:       info.Subtitle,
+		Description:    utils.StringToRichText(info.Description),
+		Language:       info.Language,
+		PageCount:      info.PageCount,
+		PublishDate:    info.PublishedDate,
+		ImageLink:      info.ImageLinks.largest(),
+		Authors:        appendUniqueImportValues([]string{}, info.Authors...),
+		Genres:         appendUniqueImportValues([]string{}, info.Categories...),
+		Publisher:      info.Publisher,
+		AverageRating:  info.AverageRating,
+		RatingsCount:   info.RatingsCount,
+		MaturityRating: info.MaturityRating,
+		PrintType:      info.PrintType,
+	}
+
+	if dimensions := info.Dimensions; dimensions != nil {
+		book.Dimensions = &repository.BookDimensions{
+			Height:    dimensions.Height,
+			Width:     dimensions.Width,
+			Thickness: dimensions.Thickness,
+		}
+	}
+
+	for _, identifier := range info.IndustryIdentifiers {
+		switch identifier.Type {
+		case "ISBN_13":
+			book.ISBN13 = identifier.Identifier
+		case "ISBN_10":
+			book.ISBN10 = identifier.Identifier
+		default:
+			book.OtherIdentifiers = append(book.OtherIdentifiers, repository.BookIdentifier{
+				Type:       identifier.Type,
+				Identifier: identifier.Identifier,
+			})
+		}
+	}
+
+	return book
+}
+
+// Helper fn: biggest cover on offer, served over https so browsers don't block it as mixed content
+func (links googleBooksImageLinks) largest() string {
+	for _, link := range []string{links.ExtraLarge, links.Large, links.Medium, links.Small, links.Thumbnail, links.SmallThumbnail} {
+		if link = utils.CleanImageLink(link); link != "" {
+			if strings.HasPrefix(link, "http://") {
+				link = "https://" + strings.TrimPrefix(link, "http://")
+			}
+			return link
+		}
+	}
+	return ""
+}
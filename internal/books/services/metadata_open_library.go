@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/utils"
+)
+
+const (
+	openLibraryBaseURL      = "https://openlibrary.org"
+	openLibraryCoversURL    = "https://covers.openlibrary.org/b/id/%d-M.jpg"
+	openLibrarySearchFields = "title,subtitle,author_name,first_publish_year,isbn,language,number_of_pages_median,subject,cover_i"
+)
+
+// Edition publish dates are free text, e.g. "March 1987", "Oct 12, 2004" or "c1987"
+var (
+	openLibraryDateLayouts = []string{"2006-01-02", "January 2, 2006", "Jan 2, 2006", "2 January 2006", "January 2006", "Jan 2006"}
+	openLibraryYearPattern = regexp.MustCompile(`\d{4}`)
+)
+
+// Open Library uses MARC language codes, the rest of the app uses the ISO 639-1 codes Google Books returns
+var openLibraryLanguageCodes = map[string]string{
+	"eng": "en",
+	"spa": "es",
+	"fre": "fr",
+	"ger": "de",
+	"ita": "it",
+	"por": "pt",
+	"dut": "nl",
+	"rus": "ru",
+	"jpn": "ja",
+	"chi": "zh",
+	"kor": "ko",
+	"ara": "ar",
+	"swe": "sv",
+	"pol": "pl",
+}
+
+// OpenLibraryProvider needs no credentials, which makes it the fallback when Google Books is unavailable
+type OpenLibraryProvider struct {
+	httpClient *http.Client
+	baseURL    string
+	logger     *slog.Logger
+}
+
+func NewOpenLibraryProvider(logger *slog.Logger, httpClient *http.Client, baseURL string) *OpenLibraryProvider {
+	return &OpenLibraryProvider{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		logger:     logger,
+	}
+}
+
+func (o *OpenLibraryProvider) Name() string { return MetadataProviderOpenLibrary }
+
+type openLibrarySearchResponse struct {
+	NumFound int                    `json:"numFound"`
+	Docs     []openLibrarySearchDoc `json:"docs"`
+}
+
+type openLibrarySearchDoc struct {
+	Title            string   `json:"title"`
+	Subtitle         string   `json:"subtitle"`
+	AuthorName       []string `json:"author_name"`
+	FirstPublishYear int      `json:"first_publish_year"`
+	ISBN             []string `json:"isbn"`
+	Language         []string `json:"language"`
+	PageCount        int      `json:"number_of_pages_median"`
+	Subject          []string `json:"subject"`
+	CoverID          int      `json:"cover_i"`
+}
+
+// /api/books?jscmd=data response entry, keyed by "ISBN:<isbn>"
+type openLibraryBookData struct {
+	Title         string `json:"title"`
+	Subtitle      string `json:"subtitle"`
+	PublishDate   string `json:"publish_date"`
+	NumberOfPages int    `json:"number_of_pages"`
+	Authors       []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Identifiers struct {
+		ISBN10 []string `json:"isbn_10"`
+		ISBN13 []string `json:"isbn_13"`
+	} `json:"identifiers"`
+	Subjects []struct {
+		Name string `json:"name"`
+	} `json:"subjects"`
+	Cover struct {
+		Medium string `json:"medium"`
+	} `json:"cover"`
+}
+
+func (o *OpenLibraryProvider) Search(ctx context.Context, query MetadataSearchQuery) (*MetadataSearchResult, error) {
+	// Open Library only catalogues books
+	if query.PrintType == MetadataPrintTypeMagazines {
+		return &MetadataSearchResult{Provider: o.Name(), Books: []repository.Book{}}, nil
+	}
+
+	params := url.Values{}
+	if query.Query != "" {
+		params.Set("q", query.Query)
+	}
+	if query.Title != "" {
+		params.Set("title", query.Title)
+	}
+	if query.Author != "" {
+		params.Set("author", query.Author)
+	}
+	if query.ISBN != "" {
+		params.Set("isbn", query.ISBN)
+	}
+	if query.Language != "" {
+		params.Set("language", openLibraryMARCLanguage(query.Language))
+	}
+	if query.OrderBy == MetadataOrderNewest {
+		params.Set("sort", "new")
+	}
+	params.Set("offset", strconv.Itoa(query.StartIndex))
+	params.Set("limit", strconv.Itoa(metadataMaxResults(query)))
+	params.Set("fields", openLibrarySearchFields)
+
+	var searchResponse openLibrarySearchResponse
+	if err := o.getJSON(ctx, "/search.json?"+params.Encode(), &searchResponse); err != nil {
+		return nil, err
+	}
+
+	books := make([]repository.Book, 0, len(searchResponse.Docs))
+	for _, doc := range searchResponse.Docs {
+		books = append(books, doc.toBook())
+	}
+
+	return &MetadataSearchResult{
+		Provider:   o.Name(),
+		Books:      books,
+		TotalItems: searchResponse.NumFound,
+	}, nil
+}
+
+func (o *OpenLibraryProvider) LookupISBN(ctx context.Context, isbn string) (*repository.Book, error) {
+	bibKey := "ISBN:" + isbn
+
+	params := url.Values{}
+	params.Set("bibkeys", bibKey)
+	params.Set("format", "json")
+	params.Set("jscmd", "data")
+
+	// Unknown ISBNs come back as an empty object rather than a 404
+	var lookupResponse map[string]openLibraryBookData
+	if err := o.getJSON(ctx, "/api/books?"+params.Encode(), &lookupResponse); err != nil {
+		return nil, err
+	}
+
+	data, ok := lookupResponse[bibKey]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", o.Name(), ErrMetadataNotFound)
+	}
+
+	book := data.toBook()
+	if book.ISBN10 == "" && book.ISBN13 == "" {
+		assignImportISBN(&book, isbn)
+	}
+	return &book, nil
+}
+
+func (o *OpenLibraryProvider) getJSON(ctx context.Context, path string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	o.logger.Info("Requesting Open Library API", "path", strings.SplitN(path, "?", 2)[0])
+
+	libraryResponse, err := o.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s: %w: %v", o.Name(), ErrMetadataUnavailable, err)
+	}
+	defer libraryResponse.Body.Close()
+
+	if libraryResponse.StatusCode != http.StatusOK {
+		o.logger.Error("Open Library API responded with non-OK status", "status", libraryResponse.StatusCode)
+		return metadataStatusError(o.Name(), libraryResponse.StatusCode)
+	}
+
+	if err := json.NewDecoder(libraryResponse.Body).Decode(target); err != nil {
+		return fmt.Errorf("%s: error decoding response: %w", o.Name(), err)
+	}
+	return nil
+}
+
+func (doc openLibrarySearchDoc) toBook() repository.Book {
+	book := repository.Book{
+		Title:       doc.Title,
+		Subtitle:    doc.Subtitle,
+		Description: utils.StringToRichText(""),
+		PageCount:   doc.PageCount,
+		Authors:     appendUniqueImportValues([]string{}, doc.AuthorName...),
+		Genres:      openLibrarySubjects(doc.Subject),
+	}
+
+	if doc.FirstPublishYear > 0 {
+		book.PublishDate = strconv.Itoa(doc.FirstPublishYear)
+	}
+	if len(doc.Language) > 0 {
+		book.Language = openLibraryLanguage(doc.Language[0])
+	}
+	if doc.CoverID > 0 {
+		book.ImageLink = fmt.Sprintf(openLibraryCoversURL, doc.CoverID)
+	}
+
+	// Works list every edition's ISBN, keep the first of each length
+	for _, isbn := range doc.ISBN {
+		assignImportISBN(&book, isbn)
+	}
+
+	return book
+}
+
+func (data openLibraryBookData) toBook() repository.Book {
+	book := repository.Book{
+		Title:       data.Title,
+		Subtitle:    data.Subtitle,
+		PageCount:   data.NumberOfPages,
+		PublishDate: openLibraryPublishDate(data.PublishDate),
+		Description: utils.StringToRichText(""),
+		ImageLink:   utils.CleanImageLink(data.Cover.Medium),
+		Authors:     []string{},
+	}
+
+	for _, author := range data.Authors {
+		book.Authors = appendUniqueImportValues(book.Authors, author.Name)
+	}
+
+	subjects := make([]string, 0, len(data.Subjects))
+	for _, subject := range data.Subjects {
+		subjects = append(subjects, subject.Name)
+	}
+	book.Genres = openLibrarySubjects(subjects)
+
+	if len(data.Identifiers.ISBN10) > 0 {
+		book.ISBN10 = data.Identifiers.ISBN10[0]
+	}
+	if len(data.Identifiers.ISBN13) > 0 {
+		book.ISBN13 = data.Identifiers.ISBN13[0]
+	}
+
+	return book
+}
+
+// Helper fn: Open Library subjects run to hundreds of entries, keep the first few as genres
+func openLibrarySubjects(subjects []string) []string {
+	return appendUniqueImportValues([]string{}, subjects[:min(len(subjects), 5)]...)
+}
+
+// Helper fn: ISO date where the text is precise enough, otherwise the bare year
+func openLibraryPublishDate(raw string) string {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range openLibraryDateLayouts {
+		if date, err := time.Parse(layout, raw); err == nil {
+			return date.Format("2006-01-02")
+		}
+	}
+	return openLibraryYearPattern.FindString(raw)
+}
+
+func openLibraryMARCLanguage(code string) string {
+	for marc, iso := range openLibraryLanguageCodes {
+		if iso == code {
+			return marc
+		}
+	}
+	return code
+}
+
+func openLibraryLanguage(code string) string {
+	if mapped, ok := openLibraryLanguageCodes[code]; ok {
+		return mapped
+	}
+	return code
+}
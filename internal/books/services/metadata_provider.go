@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"golang.org/x/oauth2"
+)
+
+// MetadataProvider looks up book metadata in an external catalogue
+type MetadataProvider interface {
+	Name() string
+	Search(ctx context.Context, query MetadataSearchQuery) (*MetadataSearchResult, error)
+	LookupISBN(ctx context.Context, isbn string) (*repository.Book, error)
+}
+
+// Provider names accepted in config.AppConfig.MetadataProviders
+const (
+	MetadataProviderGoogleBooks = "googlebooks"
+	MetadataProviderOpenLibrary = "openlibrary"
+)
+
+const (
+	defaultMetadataMaxResults = 35
+	maxMetadataMaxResults     = 40 // Google Books' upper limit for maxResults
+)
+
+// Search orders and print types accepted by MetadataSearchQuery
+const (
+	MetadataOrderRelevance = "relevance"
+	MetadataOrderNewest    = "newest"
+
+	MetadataPrintTypeAll       = "all"
+	MetadataPrintTypeBooks     = "books"
+	MetadataPrintTypeMagazines = "magazines"
+)
+
+var (
+	ErrMetadataNotFound     = errors.New("no metadata found")
+	ErrMetadataUnavailable  = errors.New("metadata provider unavailable")
+	ErrMetadataUnauthorized = errors.New("metadata provider rejected credentials")
+)
+
+// MetadataSearchQuery is provider neutral, field-scoped terms are kept apart from the free text so
+// providers without Google's intitle:/inauthor:/isbn: syntax can map them onto their own parameters
+type MetadataSearchQuery struct {
+	Query      string
+	Title      string
+	Author     string
+	ISBN       string
+	StartIndex int
+	MaxResults int
+	OrderBy    string // MetadataOrderRelevance (default) or MetadataOrderNewest
+	Language   string // ISO 639-1 code
+	PrintType  string // MetadataPrintTypeAll (default), MetadataPrintTypeBooks or MetadataPrintTypeMagazines
+}
+
+type MetadataSearchResult struct {
+	Provider   string            `json:"provider"`
+	Books      []repository.Book `json:"books"`
+	TotalItems int               `json:"totalItems"`
+}
+
+// Helper fn: requested page size, clamped to what every provider accepts
+func metadataMaxResults(query MetadataSearchQuery) int {
+	if query.MaxResults <= 0 {
+		return defaultMetadataMaxResults
+	}
+	return min(query.MaxResults, maxMetadataMaxResults)
+}
+
+type metadataAccessTokenKey struct{}
+
+// WithMetadataAccessToken attaches the user's OAuth token, providers that don't use one ignore it
+func WithMetadataAccessToken(ctx context.Context, token *oauth2.Token) context.Context {
+	return context.WithValue(ctx, metadataAccessTokenKey{}, token)
+}
+
+func metadataAccessToken(ctx context.Context) *oauth2.Token {
+	token, _ := ctx.Value(metadataAccessTokenKey{}).(*oauth2.Token)
+	if token == nil || !token.Valid() {
+		return nil
+	}
+	return token
+}
+
+// Helper fn: sort a provider's HTTP status into the errors the chain falls back on
+func metadataStatusError(provider string, statusCode int) error {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return fmt.Errorf("%s: %w (status %d)", provider, ErrMetadataUnauthorized, statusCode)
+	case statusCode == http.StatusNotFound:
+		return fmt.Errorf("%s: %w", provider, ErrMetadataNotFound)
+	default:
+		return fmt.Errorf("%s: %w (status %d)", provider, ErrMetadataUnavailable, statusCode)
+	}
+}
+
+// NewMetadataProviders builds providers in the configured priority order
+func NewMetadataProviders(
+	logger *slog.Logger,
+	names []string,
+	timeout time.Duration,
+	googleBooksAPIKey string,
+) ([]MetadataProvider, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	httpClient := &http.Client{Timeout: timeout}
+
+	providers := make([]MetadataProvider, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case MetadataProviderGoogleBooks:
+			providers = append(providers, NewGoogleBooksProvider(logger, httpClient, googleBooksBaseURL, googleBooksAPIKey))
+		case MetadataProviderOpenLibrary:
+			providers = append(providers, NewOpenLibraryProvider(logger, httpClient, openLibraryBaseURL))
+		case "":
+			continue
+		default:
+			return nil, fmt.Errorf("unknown metadata provider %q", name)
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no metadata providers configured")
+	}
+	return providers, nil
+}
+
+// MetadataProviderChain tries providers in priority order, falling back when one fails or has nothing
+type MetadataProviderChain struct {
+	providers []MetadataProvider
+	logger    *slog.Logger
+}
+
+func NewMetadataProviderChain(logger *slog.Logger, providers ...MetadataProvider) (*MetadataProviderChain, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("at least one metadata provider is required")
+	}
+
+	return &MetadataProviderChain{
+		providers: providers,
+		logger:    logger,
+	}, nil
+}
+
+func (c *MetadataProviderChain) Name() string {
+	names := make([]string, len(c.providers))
+	for i, provider := range c.providers {
+		names[i] = provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Search returns the first non-empty result, an empty result only when every provider came back empty.
+// Past the first page an empty result is final, another provider's page N would be a different result set
+func (c *MetadataProviderChain) Search(ctx context.Context, query MetadataSearchQuery) (*MetadataSearchResult, error) {
+	var empty *MetadataSearchResult
+	var errs []error
+
+	for _, provider := range c.providers {
+		result, err := provider.Search(ctx, query)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.logger.Warn("Metadata provider search failed, trying next provider", "provider", provider.Name(), "error", err)
+			errs = append(errs, err)
+			continue
+		}
+
+		if len(result.Books) > 0 || query.StartIndex > 0 {
+			return result, nil
+		}
+		if empty == nil {
+			empty = result
+		}
+	}
+
+	if empty != nil {
+		return empty, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// LookupISBN returns the first provider's match
+func (c *MetadataProviderChain) LookupISBN(ctx context.Context, isbn string) (*repository.Book, error) {
+	var errs []error
+
+	for _, provider := range c.providers {
+		book, err := provider.LookupISBN(ctx, isbn)
+		if err == nil {
+			return book, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if !errors.Is(err, ErrMetadataNotFound) {
+			c.logger.Warn("Metadata provider lookup failed, trying next provider", "provider", provider.Name(), "isbn", isbn, "error", err)
+			errs = append(errs, err)
+		}
+	}
+
+	// Only report an outage when no provider could give a definite answer
+	if len(errs) == len(c.providers) {
+		return nil, errors.Join(errs...)
+	}
+	return nil, ErrMetadataNotFound
+}
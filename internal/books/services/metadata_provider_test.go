@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func newTestMetadataLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestOpenLibraryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"numFound":1,"docs":[{
+			"title":"The Left Hand of Darkness",
+			"author_name":["Ursula K. Le Guin"],
+			"first_publish_year":1969,
+			"isbn":["0441478123","9780441478125"],
+			"language":["eng"],
+			"number_of_pages_median":304,
+			"subject":["Science fiction"],
+			"cover_i":12345
+		}]}`)
+	})
+	mux.HandleFunc("/api/books", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("bibkeys") != "ISBN:9780441478125" {
+			io.WriteString(w, `{}`)
+			return
+		}
+		io.WriteString(w, `{"ISBN:9780441478125":{
+			"title":"The Left Hand of Darkness",
+			"publish_date":"1987",
+			"number_of_pages":304,
+			"authors":[{"name":"Ursula K. Le Guin"}],
+			"identifiers":{"isbn_10":["0441478123"],"isbn_13":["9780441478125"]}
+		}}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMetadataProviderChainFallsBackWhenGoogleIsRateLimited(t *testing.T) {
+	logger := newTestMetadataLogger()
+
+	googleCalls := 0
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		googleCalls++
+		http.Error(w, `{"error":{"code":429}}`, http.StatusTooManyRequests)
+	}))
+	defer google.Close()
+	openLibrary := newTestOpenLibraryServer(t)
+
+	chain, err := NewMetadataProviderChain(logger,
+		NewGoogleBooksProvider(logger, google.Client(), google.URL, ""),
+		NewOpenLibraryProvider(logger, openLibrary.Client(), openLibrary.URL),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating chain: %v", err)
+	}
+
+	result, err := chain.Search(context.Background(), MetadataSearchQuery{Query: "left hand of darkness"})
+	if err != nil {
+		t.Fatalf("expected fallback result, got error: %v", err)
+	}
+	if googleCalls != 1 {
+		t.Errorf("expected Google Books to be tried once, got %d calls", googleCalls)
+	}
+	if result.Provider != MetadataProviderOpenLibrary {
+		t.Errorf("expected provider %q, got %q", MetadataProviderOpenLibrary, result.Provider)
+	}
+	if len(result.Books) != 1 {
+		t.Fatalf("expected 1 book, got %d", len(result.Books))
+	}
+
+	book := result.Books[0]
+	if book.ISBN10 != "0441478123" || book.ISBN13 != "9780441478125" {
+		t.Errorf("unexpected ISBNs %q / %q", book.ISBN10, book.ISBN13)
+	}
+	if book.Language != "en" {
+		t.Errorf("expected language to map to en, got %q", book.Language)
+	}
+	if book.PublishDate != "1969" || book.PageCount != 304 {
+		t.Errorf("unexpected publish date %q or page count %d", book.PublishDate, book.PageCount)
+	}
+}
+
+func TestGoogleBooksProviderRetriesWithoutRevokedToken(t *testing.T) {
+	logger := newTestMetadataLogger()
+
+	var sawToken, sawAnonymous bool
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			sawToken = true
+			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+			return
+		}
+		sawAnonymous = true
+		if r.URL.Query().Get("key") != "test-key" {
+			t.Errorf("expected API key on retry, got %q", r.URL.Query().Get("key"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"totalItems":1,"items":[{"volumeInfo":{
+			"title":"Kindred",
+			"authors":["Octavia E. Butler"],
+			"industryIdentifiers":[{"type":"ISBN_13","identifier":"9780807083697"}]
+		}}]}`)
+	}))
+	defer google.Close()
+
+	provider := NewGoogleBooksProvider(logger, google.Client(), google.URL, "test-key")
+	ctx := WithMetadataAccessToken(context.Background(), &oauth2.Token{
+		AccessToken: "revoked",
+		Expiry:      time.Now().Add(time.Hour),
+	})
+
+	book, err := provider.LookupISBN(ctx, "9780807083697")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !sawToken || !sawAnonymous {
+		t.Errorf("expected a token request followed by an anonymous retry, token=%v anonymous=%v", sawToken, sawAnonymous)
+	}
+	if book.Title != "Kindred" || book.ISBN13 != "9780807083697" {
+		t.Errorf("unexpected book %+v", book)
+	}
+}
+
+func TestMetadataProviderChainLookupISBN(t *testing.T) {
+	logger := newTestMetadataLogger()
+
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"totalItems":0}`)
+	}))
+	defer google.Close()
+	openLibrary := newTestOpenLibraryServer(t)
+
+	chain, err := NewMetadataProviderChain(logger,
+		NewGoogleBooksProvider(logger, google.Client(), google.URL, ""),
+		NewOpenLibraryProvider(logger, openLibrary.Client(), openLibrary.URL),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating chain: %v", err)
+	}
+
+	book, err := chain.LookupISBN(context.Background(), "9780441478125")
+	if err != nil {
+		t.Fatalf("expected Open Library match, got error: %v", err)
+	}
+	if book.Title != "The Left Hand of Darkness" || len(book.Authors) != 1 {
+		t.Errorf("unexpected book %+v", book)
+	}
+
+	_, err = chain.LookupISBN(context.Background(), "9780000000002")
+	if !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("expected ErrMetadataNotFound, got %v", err)
+	}
+}
+
+func TestMetadataProviderChainReportsOutage(t *testing.T) {
+	logger := newTestMetadataLogger()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	chain, err := NewMetadataProviderChain(logger,
+		NewGoogleBooksProvider(logger, down.Client(), down.URL, ""),
+		NewOpenLibraryProvider(logger, down.Client(), down.URL),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating chain: %v", err)
+	}
+
+	if _, err := chain.Search(context.Background(), MetadataSearchQuery{Query: "anything"}); !errors.Is(err, ErrMetadataUnavailable) {
+		t.Errorf("expected ErrMetadataUnavailable from search, got %v", err)
+	}
+	if _, err := chain.LookupISBN(context.Background(), "9780441478125"); errors.Is(err, ErrMetadataNotFound) || !errors.Is(err, ErrMetadataUnavailable) {
+		t.Errorf("expected ErrMetadataUnavailable from lookup, got %v", err)
+	}
+}
+
+func TestFormatGoogleBooksResponseSkipsMalformedVolumes(t *testing.T) {
+	var volumes googleBooksVolumesResponse
+	err := json.Unmarshal([]byte(`{"totalItems":3,"items":[
+		{"volumeInfo":{"title":["not","a","string"]}},
+		{"volumeInfo":{"authors":["No Title"]}},
+		{"id":"abc","volumeInfo":{
+			"title":"Parable of the Sower",
+			"publisher":"Seven Stories Press",
+			"printType":"BOOK",
+			"maturityRating":"NOT_MATURE",
+			"averageRating":4.5,
+			"dimensions":{"height":"21.00 cm"},
+			"industryIdentifiers":[
+				{"type":"ISBN_10","identifier":"1609804430"},
+				{"type":"ISSN","identifier":"1234-5678"},
+				{"type":"OTHER","identifier":"OCLC:12345"}
+			],
+			"imageLinks":{"smallThumbnail":"http://books.google.com/small","thumbnail":"http://books.google.com/thumb"}
+		},"saleInfo":{"isEbook":true}}
+	]}`), &volumes)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+
+	books := formatGoogleBooksResponse(newTestMetadataLogger(), &volumes)
+	if len(books) != 1 {
+		t.Fatalf("expected malformed and untitled volumes to be skipped, got %d books", len(books))
+	}
+
+	book := books[0]
+	if book.Publisher != "Seven Stories Press" || book.PrintType != "BOOK" || book.AverageRating != 4.5 {
+		t.Errorf("catalogue details not mapped: %+v", book)
+	}
+	if book.Dimensions == nil || book.Dimensions.Height != "21.00 cm" {
+		t.Errorf("expected dimensions to be mapped, got %+v", book.Dimensions)
+	}
+	if book.ISBN10 != "1609804430" || len(book.OtherIdentifiers) != 2 {
+		t.Errorf("unexpected identifiers %q %+v", book.ISBN10, book.OtherIdentifiers)
+	}
+	if book.ImageLink != "https://books.google.com/thumb" {
+		t.Errorf("expected the larger thumbnail over https, got %q", book.ImageLink)
+	}
+}
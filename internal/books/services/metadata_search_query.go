@@ -0,0 +1,153 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// Field prefixes understood in free-text search, same syntax as Google Books
+var metadataSearchPrefixes = map[string]func(query *MetadataSearchQuery, value string){
+	"intitle:":  func(query *MetadataSearchQuery, value string) { query.Title = joinSearchTerms(query.Title, value) },
+	"inauthor:": func(query *MetadataSearchQuery, value string) { query.Author = joinSearchTerms(query.Author, value) },
+	"isbn:":     func(query *MetadataSearchQuery, value string) { query.ISBN = normalizeImportISBN(value) },
+}
+
+// AddSearchText splits a free-text search into plain terms and intitle:/inauthor:/isbn: terms.
+// Quoted phrases stay together, e.g. `intitle:"left hand" le guin`, and keep their quotes in the free text
+func (q *MetadataSearchQuery) AddSearchText(raw string) {
+	for _, term := range splitSearchTerms(raw) {
+		matched := false
+		for prefix, assign := range metadataSearchPrefixes {
+			if len(term) > len(prefix) && strings.EqualFold(term[:len(prefix)], prefix) {
+				assign(q, strings.Trim(term[len(prefix):], `"`))
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			q.Query = joinSearchTerms(q.Query, term)
+		}
+	}
+}
+
+func (q MetadataSearchQuery) IsEmpty() bool {
+	return q.Query == "" && q.Title == "" && q.Author == "" && q.ISBN == ""
+}
+
+// Normalized renders the query in a canonical form, used for page tokens and cache keys
+func (q MetadataSearchQuery) Normalized() string {
+	return strings.Join([]string{
+		strings.ToLower(q.Query),
+		strings.ToLower(q.Title),
+		strings.ToLower(q.Author),
+		q.ISBN,
+		q.OrderBy,
+		strings.ToLower(q.Language),
+		q.PrintType,
+	}, "|")
+}
+
+// CacheKey identifies one page of results for this query
+func (q MetadataSearchQuery) CacheKey() string {
+	sum := sha256.Sum256([]byte(q.Normalized() + "|" + strconv.Itoa(q.StartIndex) + "|" + strconv.Itoa(metadataMaxResults(q))))
+	return hex.EncodeToString(sum[:])
+}
+
+// Helper fn: Google Books takes the field-scoped terms inline
+func (q MetadataSearchQuery) googleBooksQuery() string {
+	terms := []string{}
+	if q.Query != "" {
+		terms = append(terms, q.Query)
+	}
+	if q.Title != "" {
+		terms = append(terms, "intitle:"+quoteSearchTerm(q.Title))
+	}
+	if q.Author != "" {
+		terms = append(terms, "inauthor:"+quoteSearchTerm(q.Author))
+	}
+	if q.ISBN != "" {
+		terms = append(terms, "isbn:"+q.ISBN)
+	}
+	return strings.Join(terms, " ")
+}
+
+// EncodeSearchPageToken returns an opaque token for the given page of this query
+func EncodeSearchPageToken(query MetadataSearchQuery, page int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(page) + "." + searchQueryFingerprint(query)))
+}
+
+// DecodeSearchPageToken returns the page a token points at, tokens issued for a different query are rejected
+func DecodeSearchPageToken(query MetadataSearchQuery, token string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidPageToken
+	}
+
+	rawPage, fingerprint, found := strings.Cut(string(decoded), ".")
+	if !found || fingerprint != searchQueryFingerprint(query) {
+		return 0, ErrInvalidPageToken
+	}
+
+	page, err := strconv.Atoi(rawPage)
+	if err != nil || page < 1 {
+		return 0, ErrInvalidPageToken
+	}
+	return page, nil
+}
+
+// Helper fn: page size is part of the fingerprint, a token only makes sense with the size it was issued for
+func searchQueryFingerprint(query MetadataSearchQuery) string {
+	sum := sha256.Sum256([]byte(query.Normalized() + "|" + strconv.Itoa(query.MaxResults)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Helper fn: whitespace separated terms, double quotes group a phrase
+func splitSearchTerms(raw string) []string {
+	var terms []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+
+	return terms
+}
+
+func joinSearchTerms(existing string, addition string) string {
+	addition = strings.TrimSpace(addition)
+	if existing == "" {
+		return addition
+	}
+	if addition == "" {
+		return existing
+	}
+	return existing + " " + addition
+}
+
+func quoteSearchTerm(term string) string {
+	if strings.ContainsAny(term, " \t") {
+		return `"` + term + `"`
+	}
+	return term
+}
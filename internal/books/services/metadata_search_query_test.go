@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestMetadataSearchQueryParsesFieldPrefixesAndPageTokens(t *testing.T) {
+	var query MetadataSearchQuery
+	query.AddSearchText(`intitle:"left hand" inauthor:guin isbn:978-0441478125 "science fiction"`)
+
+	if query.Title != "left hand" || query.Author != "guin" || query.ISBN != "9780441478125" {
+		t.Errorf("field prefixes not parsed: %+v", query)
+	}
+	if query.Query != `"science fiction"` {
+		t.Errorf("expected the quoted phrase as free text, got %q", query.Query)
+	}
+	if got := query.googleBooksQuery(); got != `"science fiction" intitle:"left hand" inauthor:guin isbn:9780441478125` {
+		t.Errorf("unexpected Google Books query %q", got)
+	}
+
+	query.MaxResults = 20
+	token := EncodeSearchPageToken(query, 3)
+	if page, err := DecodeSearchPageToken(query, token); err != nil || page != 3 {
+		t.Errorf("expected page 3 from token, got %d (%v)", page, err)
+	}
+
+	other := query
+	other.OrderBy = MetadataOrderNewest
+	if _, err := DecodeSearchPageToken(other, token); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("expected a token for another query to be rejected, got %v", err)
+	}
+}
+
+func TestMetadataSearchFiltersReachProviders(t *testing.T) {
+	var googleParams, openLibraryParams url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/search.json" {
+			openLibraryParams = r.URL.Query()
+			io.WriteString(w, `{"numFound":0,"docs":[]}`)
+			return
+		}
+		googleParams = r.URL.Query()
+		io.WriteString(w, `{"totalItems":0}`)
+	}))
+	defer server.Close()
+
+	logger := newTestMetadataLogger()
+	query := MetadataSearchQuery{
+		Query:      "dune",
+		Author:     "frank herbert",
+		StartIndex: 80,
+		MaxResults: 100,
+		OrderBy:    MetadataOrderNewest,
+		Language:   "fr",
+		PrintType:  MetadataPrintTypeBooks,
+	}
+
+	if _, err := NewGoogleBooksProvider(logger, server.Client(), server.URL, "").Search(context.Background(), query); err != nil {
+		t.Fatalf("unexpected Google Books error: %v", err)
+	}
+	if _, err := NewOpenLibraryProvider(logger, server.Client(), server.URL).Search(context.Background(), query); err != nil {
+		t.Fatalf("unexpected Open Library error: %v", err)
+	}
+
+	// Page sizes are clamped to what Google Books accepts
+	wantGoogle := map[string]string{
+		"q":            `dune inauthor:"frank herbert"`,
+		"startIndex":   "80",
+		"maxResults":   "40",
+		"orderBy":      "newest",
+		"langRestrict": "fr",
+		"printType":    "books",
+	}
+	for param, want := range wantGoogle {
+		if got := googleParams.Get(param); got != want {
+			t.Errorf("Google Books %s = %q, want %q", param, got, want)
+		}
+	}
+
+	wantOpenLibrary := map[string]string{
+		"q":        "dune",
+		"author":   "frank herbert",
+		"offset":   "80",
+		"limit":    "40",
+		"sort":     "new",
+		"language": "fre",
+	}
+	for param, want := range wantOpenLibrary {
+		if got := openLibraryParams.Get(param); got != want {
+			t.Errorf("Open Library %s = %q, want %q", param, got, want)
+		}
+	}
+}
+
+func TestDecodeSearchPageTokenRejectsTampering(t *testing.T) {
+	query := MetadataSearchQuery{Query: "dune", MaxResults: 20}
+
+	for _, token := range []string{"", "not base64!", EncodeSearchPageToken(query, 0), EncodeSearchPageToken(MetadataSearchQuery{Query: "dune", MaxResults: 10}, 2)} {
+		if _, err := DecodeSearchPageToken(query, token); !errors.Is(err, ErrInvalidPageToken) {
+			t.Errorf("expected ErrInvalidPageToken for %q, got %v", token, err)
+		}
+	}
+}
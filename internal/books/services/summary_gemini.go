@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// GeminiSummaryProvider shares one genai client across requests
+type GeminiSummaryProvider struct {
+	client  *genai.Client
+	model   *genai.GenerativeModel
+	name    string
+	timeout time.Duration
+	logger  *slog.Logger
+}
+
+func NewGeminiSummaryProvider(ctx context.Context, logger *slog.Logger, cfg SummaryProviderConfig) (*GeminiSummaryProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("gemini summary provider needs an API key")
+	}
+
+	client, err := genai.NewClient(ctx, option.WithAPIKey(cfg.APIKey))
+	if err != nil {
+		return nil, fmt.Errorf("error creating Gemini client: %w", err)
+	}
+
+	model := client.GenerativeModel(cfg.Model)
+	model.SetTemperature(cfg.Temperature)
+
+	return &GeminiSummaryProvider{
+		client:  client,
+		model:   model,
+		name:    cfg.Model,
+		timeout: cfg.Timeout,
+		logger:  logger,
+	}, nil
+}
+
+func (g *GeminiSummaryProvider) Name() string  { return SummaryProviderGemini }
+func (g *GeminiSummaryProvider) Model() string { return g.name }
+
+func (g *GeminiSummaryProvider) Summarize(ctx context.Context, prompt string) (*SummaryResult, error) {
+	ctx, cancel := withSummaryTimeout(ctx, g.timeout)
+	defer cancel()
+
+	g.logger.Info("Requesting Gemini summary", "model", g.name)
+
+	responseData, err := g.model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", g.Name(), ErrSummaryUnavailable, err)
+	}
+
+	result := &SummaryResult{Provider: g.Name(), Model: g.name, Text: geminiResponseText(responseData)}
+	g.recordUsage(result, responseData)
+	if result.Text == "" {
+		return result, fmt.Errorf("%s: %w", g.Name(), ErrSummaryEmpty)
+	}
+	return result, nil
+}
+
+func (g *GeminiSummaryProvider) SummarizeStream(ctx context.Context, prompt string, onChunk func(text string) error) (*SummaryResult, error) {
+	ctx, touch, stop := withStreamIdleTimeout(ctx, g.timeout)
+	defer stop()
+
+	g.logger.Info("Streaming Gemini summary", "model", g.name)
+
+	result := &SummaryResult{Provider: g.Name(), Model: g.name}
+	var text strings.Builder
+
+	stream := g.model.GenerateContentStream(ctx, genai.Text(prompt))
+	for {
+		responseData, err := stream.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			result.Text = text.String()
+			return result, fmt.Errorf("%s: %w: %v", g.Name(), ErrSummaryUnavailable, summaryStreamError(ctx, err))
+		}
+		touch()
+
+		// Usage is reported on the last chunk
+		g.recordUsage(result, responseData)
+
+		chunk := geminiResponseText(responseData)
+		if chunk == "" {
+			continue
+		}
+		text.WriteString(chunk)
+		if err := onChunk(chunk); err != nil {
+			result.Text = text.String()
+			return result, err
+		}
+	}
+
+	if text.Len() == 0 {
+		return result, fmt.Errorf("%s: %w", g.Name(), ErrSummaryEmpty)
+	}
+	result.Text = text.String()
+	return result, nil
+}
+
+func (g *GeminiSummaryProvider) recordUsage(result *SummaryResult, responseData *genai.GenerateContentResponse) {
+	if usage := responseData.UsageMetadata; usage != nil {
+		result.PromptTokens = int(usage.PromptTokenCount)
+		result.OutputTokens = int(usage.CandidatesTokenCount)
+	}
+}
+
+// Helper fn: text parts of the first candidate
+func geminiResponseText(responseData *genai.GenerateContentResponse) string {
+	if len(responseData.Candidates) == 0 || responseData.Candidates[0].Content == nil {
+		return ""
+	}
+
+	var text strings.Builder
+	for _, part := range responseData.Candidates[0].Content.Parts {
+		if textPart, ok := part.(genai.Text); ok {
+			text.WriteString(string(textPart))
+		}
+	}
+	return text.String()
+}
+
+func (g *GeminiSummaryProvider) Close() error {
+	return g.client.Close()
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAISummaryProvider calls a chat completions endpoint. BaseURL can point at any compatible server,
+// e.g. http://localhost:11434/v1 for a local model, the API key is optional for those
+type OpenAISummaryProvider struct {
+	httpClient  *http.Client
+	baseURL     string
+	apiKey      string
+	model       string
+	temperature float32
+	timeout     time.Duration
+	logger      *slog.Logger
+}
+
+func NewOpenAISummaryProvider(logger *slog.Logger, httpClient *http.Client, cfg SummaryProviderConfig) *OpenAISummaryProvider {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = openAIBaseURL
+	}
+
+	return &OpenAISummaryProvider{
+		httpClient:  httpClient,
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      cfg.APIKey,
+		model:       cfg.Model,
+		temperature: cfg.Temperature,
+		timeout:     cfg.Timeout,
+		logger:      logger,
+	}
+}
+
+func (o *OpenAISummaryProvider) Name() string  { return SummaryProviderOpenAI }
+func (o *OpenAISummaryProvider) Model() string { return o.model }
+
+type openAIChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model         string               `json:"model"`
+	Messages      []openAIChatMessage  `json:"messages"`
+	Temperature   float32              `json:"temperature"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Chat completions response, https://platform.openai.com/docs/api-reference/chat/object
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIChatMessage `json:"message"`
+	} `json:"choices"`
+	Usage openAIUsage `json:"usage"`
+}
+
+// One server-sent event of a streamed completion, usage only arrives on the last one
+type openAIChatChunk struct {
+	Choices []struct {
+		Delta openAIChatMessage `json:"delta"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+func (o *OpenAISummaryProvider) Summarize(ctx context.Context, prompt string) (*SummaryResult, error) {
+	ctx, cancel := withSummaryTimeout(ctx, o.timeout)
+	defer cancel()
+
+	chatResponse, err := o.post(ctx, openAIChatRequest{
+		Model:       o.model,
+		Messages:    []openAIChatMessage{{Role: "user", Content: prompt}},
+		Temperature: o.temperature,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer chatResponse.Body.Close()
+
+	var completion openAIChatResponse
+	if err := json.NewDecoder(chatResponse.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("%s: error decoding response: %w", o.Name(), err)
+	}
+
+	result := &SummaryResult{
+		Provider:     o.Name(),
+		Model:        o.model,
+		PromptTokens: completion.Usage.PromptTokens,
+		OutputTokens: completion.Usage.CompletionTokens,
+	}
+	if len(completion.Choices) == 0 || completion.Choices[0].Message.Content == "" {
+		return result, fmt.Errorf("%s: %w", o.Name(), ErrSummaryEmpty)
+	}
+
+	result.Text = completion.Choices[0].Message.Content
+	return result, nil
+}
+
+func (o *OpenAISummaryProvider) SummarizeStream(ctx context.Context, prompt string, onChunk func(text string) error) (*SummaryResult, error) {
+	ctx, touch, stop := withStreamIdleTimeout(ctx, o.timeout)
+	defer stop()
+
+	chatResponse, err := o.post(ctx, openAIChatRequest{
+		Model:         o.model,
+		Messages:      []openAIChatMessage{{Role: "user", Content: prompt}},
+		Temperature:   o.temperature,
+		Stream:        true,
+		StreamOptions: &openAIStreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer chatResponse.Body.Close()
+
+	result := &SummaryResult{Provider: o.Name(), Model: o.model}
+	var text strings.Builder
+
+	scanner := bufio.NewScanner(chatResponse.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		touch()
+		data, found := strings.CutPrefix(scanner.Text(), "data:")
+		if !found {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIChatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			result.Text = text.String()
+			return result, fmt.Errorf("%s: error decoding stream chunk: %w", o.Name(), err)
+		}
+		if chunk.Usage != nil {
+			result.PromptTokens = chunk.Usage.PromptTokens
+			result.OutputTokens = chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		text.WriteString(chunk.Choices[0].Delta.Content)
+		if err := onChunk(chunk.Choices[0].Delta.Content); err != nil {
+			result.Text = text.String()
+			return result, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		result.Text = text.String()
+		return result, fmt.Errorf("%s: %w: %v", o.Name(), ErrSummaryUnavailable, summaryStreamError(ctx, err))
+	}
+
+	if text.Len() == 0 {
+		return result, fmt.Errorf("%s: %w", o.Name(), ErrSummaryEmpty)
+	}
+	result.Text = text.String()
+	return result, nil
+}
+
+// Helper fn: send a chat completions request, non-OK statuses come back as ErrSummaryUnavailable
+func (o *OpenAISummaryProvider) post(ctx context.Context, chatRequest openAIChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(chatRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		request.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	o.logger.Info("Requesting chat completion", "url", o.baseURL+"/chat/completions", "model", o.model, "stream", chatRequest.Stream)
+
+	chatResponse, err := o.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", o.Name(), ErrSummaryUnavailable, summaryStreamError(ctx, err))
+	}
+
+	if chatResponse.StatusCode != http.StatusOK {
+		defer chatResponse.Body.Close()
+		errorBody, _ := io.ReadAll(io.LimitReader(chatResponse.Body, 4096))
+		o.logger.Error("Chat completions API responded with non-OK status", "status", chatResponse.StatusCode, "body", string(errorBody))
+		return nil, fmt.Errorf("%s: %w (status %d)", o.Name(), ErrSummaryUnavailable, chatResponse.StatusCode)
+	}
+
+	return chatResponse, nil
+}
+
+// Nothing to release, the HTTP client's idle connections are shared
+func (o *OpenAISummaryProvider) Close() error {
+	return nil
+}
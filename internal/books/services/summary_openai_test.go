@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewSummaryProviderSelectsBackend(t *testing.T) {
+	logger := newTestMetadataLogger()
+
+	if _, err := NewSummaryProvider(context.Background(), logger, SummaryProviderConfig{Provider: SummaryProviderOpenAI}); err == nil {
+		t.Error("expected an error without a model")
+	}
+	if _, err := NewSummaryProvider(context.Background(), logger, SummaryProviderConfig{Provider: "claude", Model: "m"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+
+	provider, err := NewSummaryProvider(context.Background(), logger, SummaryProviderConfig{Provider: " OpenAI ", Model: "llama3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer provider.Close()
+	if _, ok := provider.(*OpenAISummaryProvider); !ok || provider.Name() != SummaryProviderOpenAI || provider.Model() != "llama3" {
+		t.Errorf("expected the OpenAI compatible provider for llama3, got %T %q %q", provider, provider.Name(), provider.Model())
+	}
+}
+
+func TestOpenAISummarize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+
+		var chatRequest openAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&chatRequest); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		if chatRequest.Model != "test-model" || chatRequest.Temperature != 0.5 ||
+			len(chatRequest.Messages) != 1 || chatRequest.Messages[0] != (openAIChatMessage{Role: "user", Content: "Summarize Kindred"}) {
+			t.Errorf("unexpected request %+v", chatRequest)
+		}
+
+		io.WriteString(w, `{
+			"choices":[{"message":{"role":"assistant","content":"A time travel novel."}}],
+			"usage":{"prompt_tokens":9,"completion_tokens":4}
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAISummaryProvider(newTestMetadataLogger(), server.Client(), SummaryProviderConfig{
+		Model:       "test-model",
+		Temperature: 0.5,
+		BaseURL:     server.URL + "/v1/",
+		APIKey:      "test-key",
+	})
+
+	result, err := provider.Summarize(context.Background(), "Summarize Kindred")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := SummaryResult{Provider: SummaryProviderOpenAI, Model: "test-model", Text: "A time travel novel.", PromptTokens: 9, OutputTokens: 4}
+	if *result != want {
+		t.Errorf("expected %+v, got %+v", want, *result)
+	}
+}
+
+func TestOpenAISummarizeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{name: "server error", status: http.StatusServiceUnavailable, body: `{"error":"overloaded"}`, wantErr: ErrSummaryUnavailable},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: `{"error":"slow down"}`, wantErr: ErrSummaryUnavailable},
+		{name: "no choices", status: http.StatusOK, body: `{"choices":[]}`, wantErr: ErrSummaryEmpty},
+		{name: "empty content", status: http.StatusOK, body: `{"choices":[{"message":{"role":"assistant","content":""}}]}`, wantErr: ErrSummaryEmpty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			provider := NewOpenAISummaryProvider(newTestMetadataLogger(), server.Client(), SummaryProviderConfig{Model: "test-model", BaseURL: server.URL})
+			if _, err := provider.Summarize(context.Background(), "prompt"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SummaryProvider generates text from a prompt with a large language model
+type SummaryProvider interface {
+	Name() string
+	Model() string
+	// Summarize returns the partial result with errors raised once the provider answered, e.g. ErrSummaryEmpty,
+	// so the tokens it used can still be charged
+	Summarize(ctx context.Context, prompt string) (*SummaryResult, error)
+	// SummarizeStream calls onChunk with each piece of text as it is generated and returns the assembled result.
+	// An error from onChunk stops the stream. The configured timeout limits the wait for each chunk, not the whole stream.
+	// Errors once the stream has started come with the text and usage received so far
+	SummarizeStream(ctx context.Context, prompt string, onChunk func(text string) error) (*SummaryResult, error)
+	Close() error
+}
+
+// Provider names accepted in config.AppConfig.SummaryProvider
+const (
+	SummaryProviderGemini = "gemini"
+	SummaryProviderOpenAI = "openai" // Any server speaking the OpenAI chat completions API, e.g. a local model server
+)
+
+var (
+	ErrSummaryUnavailable = errors.New("summary provider unavailable")
+	ErrSummaryEmpty       = errors.New("summary provider returned no content")
+
+	errSummaryStreamIdle = errors.New("no summary text received within the timeout")
+)
+
+type SummaryResult struct {
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	Text         string `json:"text"`
+	PromptTokens int    `json:"promptTokens"`
+	OutputTokens int    `json:"outputTokens"`
+}
+
+type SummaryProviderConfig struct {
+	Provider    string
+	Model       string
+	Temperature float32
+	Timeout     time.Duration // Whole response for Summarize, time between chunks for SummarizeStream
+	BaseURL     string        // OpenAI compatible providers only
+	APIKey      string
+}
+
+// NewSummaryProvider builds the configured provider. The client is long lived, call Close on shutdown
+func NewSummaryProvider(ctx context.Context, logger *slog.Logger, cfg SummaryProviderConfig) (SummaryProvider, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("summary provider model is not configured")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case SummaryProviderGemini:
+		provider, err := NewGeminiSummaryProvider(ctx, logger, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return provider, nil
+	case SummaryProviderOpenAI:
+		// No client timeout, it would also cut off a stream that's still sending text
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ResponseHeaderTimeout = cfg.Timeout
+		return NewOpenAISummaryProvider(logger, &http.Client{Transport: transport}, cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown summary provider %q", cfg.Provider)
+	}
+}
+
+// Helper fn: bound the whole of a blocking request
+func withSummaryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Helper fn: cancel a stream that goes quiet for longer than timeout, including the wait for its first chunk.
+// Call touch whenever the stream makes progress
+func withStreamIdleTimeout(ctx context.Context, timeout time.Duration) (streamCtx context.Context, touch func(), stop func()) {
+	streamCtx, cancel := context.WithCancelCause(ctx)
+	if timeout <= 0 {
+		return streamCtx, func() {}, func() { cancel(nil) }
+	}
+
+	timer := time.AfterFunc(timeout, func() { cancel(errSummaryStreamIdle) })
+	return streamCtx, func() { timer.Reset(timeout) }, func() {
+		timer.Stop()
+		cancel(nil)
+	}
+}
+
+// Helper fn: report an idle timeout rather than the bare context.Canceled it surfaces as
+func summaryStreamError(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); errors.Is(cause, errSummaryStreamIdle) {
+		return cause
+	}
+	return err
+}
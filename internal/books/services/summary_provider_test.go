@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Streams one chunk per delay, then waits stall before sending usage and [DONE]
+func newTestChatStreamServer(t *testing.T, chunks []string, delay time.Duration, stall time.Duration) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, chunk := range chunks {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", chunk)
+			flusher.Flush()
+		}
+		if stall > 0 {
+			select {
+			case <-time.After(stall):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		tail := `data: {"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":5}}` + "\n\ndata: [DONE]\n\n"
+		fmt.Fprint(w, tail)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestOpenAIProvider(server *httptest.Server, timeout time.Duration) *OpenAISummaryProvider {
+	return NewOpenAISummaryProvider(newTestMetadataLogger(), server.Client(), SummaryProviderConfig{
+		Model:   "test-model",
+		Timeout: timeout,
+		BaseURL: server.URL,
+	})
+}
+
+func TestOpenAISummarizeStreamOutlastsTimeoutWhileTextArrives(t *testing.T) {
+	chunks := []string{"One ", "two ", "three ", "four ", "five"}
+	server := newTestChatStreamServer(t, chunks, 60*time.Millisecond, 0)
+	provider := newTestOpenAIProvider(server, 150*time.Millisecond)
+
+	started := time.Now()
+	result, err := provider.SummarizeStream(context.Background(), "prompt", func(string) error { return nil })
+	if err != nil {
+		t.Fatalf("expected the stream to finish, got error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 150*time.Millisecond {
+		t.Fatalf("expected the stream to run longer than the timeout, took %v", elapsed)
+	}
+	if result.Text != strings.Join(chunks, "") {
+		t.Errorf("unexpected text %q", result.Text)
+	}
+	if result.PromptTokens != 12 || result.OutputTokens != 5 {
+		t.Errorf("unexpected usage %d/%d", result.PromptTokens, result.OutputTokens)
+	}
+}
+
+func TestOpenAISummarizeStreamFailsWhenIdle(t *testing.T) {
+	server := newTestChatStreamServer(t, []string{"One "}, 0, time.Second)
+	provider := newTestOpenAIProvider(server, 100*time.Millisecond)
+
+	_, err := provider.SummarizeStream(context.Background(), "prompt", func(string) error { return nil })
+	if !errors.Is(err, ErrSummaryUnavailable) {
+		t.Fatalf("expected ErrSummaryUnavailable, got %v", err)
+	}
+	if !strings.Contains(err.Error(), errSummaryStreamIdle.Error()) {
+		t.Errorf("expected the idle timeout to be reported, got %v", err)
+	}
+}
+
+func TestOpenAISummarizeStreamFailsWithoutFirstChunk(t *testing.T) {
+	server := newTestChatStreamServer(t, []string{"late"}, time.Second, 0)
+	provider := newTestOpenAIProvider(server, 100*time.Millisecond)
+
+	if _, err := provider.SummarizeStream(context.Background(), "prompt", func(string) error { return nil }); !errors.Is(err, ErrSummaryUnavailable) {
+		t.Fatalf("expected ErrSummaryUnavailable, got %v", err)
+	}
+}
+
+func TestOpenAISummarizeKeepsTotalTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"late"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+	provider := newTestOpenAIProvider(server, 100*time.Millisecond)
+
+	if _, err := provider.Summarize(context.Background(), "prompt"); !errors.Is(err, ErrSummaryUnavailable) {
+		t.Fatalf("expected ErrSummaryUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+const maxBookSummaryLength = 20000
+
+// Rough tokens per character, for usage a provider didn't get to report
+const summaryCharsPerToken = 4
+
+var (
+	ErrSummaryNotConfigured = errors.New("no summary provider configured")
+	ErrInvalidBookSummary   = errors.New("invalid book summary")
+)
+
+// SummaryService generates AI summaries of a stored book and saves them with it. Callers check ownership first
+type SummaryService interface {
+	// GetBookSummary returns the saved summary of this kind, generating one when there is none or regenerate is set.
+	// Generating counts against the user's AI quota, reading a saved summary doesn't
+	GetBookSummary(ctx context.Context, userID int, bookID int, kind SummaryKind, regenerate bool) (*repository.BookSummary, error)
+	// StreamBookSummary passes text to onChunk as it is generated, a saved summary arrives as a single chunk
+	StreamBookSummary(ctx context.Context, userID int, bookID int, kind SummaryKind, regenerate bool, onChunk func(text string) error) (*repository.BookSummary, error)
+	ListBookSummaries(ctx context.Context, bookID int) ([]repository.BookSummary, error)
+	EditBookSummary(ctx context.Context, bookID int, kind SummaryKind, text string) (*repository.BookSummary, error)
+	// AcceptBookSummary appends the saved summary to the book's notes
+	AcceptBookSummary(ctx context.Context, userID int, bookID int, kind SummaryKind) (*repository.BookSummary, error)
+}
+
+type SummaryServiceImpl struct {
+	logger      *slog.Logger
+	bookRepo    repository.BookRepository
+	summaryRepo repository.BookSummaryRepository
+	bookUpdater BookUpdaterService
+	aiQuota     AIQuotaService
+	provider    SummaryProvider // nil when summaries aren't configured, saved ones can still be read and edited
+}
+
+func NewSummaryService(
+	logger *slog.Logger,
+	bookRepo repository.BookRepository,
+	summaryRepo repository.BookSummaryRepository,
+	bookUpdater BookUpdaterService,
+	aiQuota AIQuotaService,
+	provider SummaryProvider,
+) (SummaryService, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger is nil")
+	}
+
+	if bookRepo == nil || summaryRepo == nil || bookUpdater == nil || aiQuota == nil {
+		return nil, fmt.Errorf("summary service, repositories, book updater or ai quota is nil")
+	}
+
+	return &SummaryServiceImpl{
+		logger:      logger,
+		bookRepo:    bookRepo,
+		summaryRepo: summaryRepo,
+		bookUpdater: bookUpdater,
+		aiQuota:     aiQuota,
+		provider:    provider,
+	}, nil
+}
+
+// A summary request the user's quota has been reserved for
+type summaryGeneration struct {
+	prompt      string
+	reservation *AIQuotaReservation
+}
+
+func (s *SummaryServiceImpl) GetBookSummary(ctx context.Context, userID int, bookID int, kind SummaryKind, regenerate bool) (*repository.BookSummary, error) {
+	saved, generation, err := s.prepare(ctx, userID, bookID, kind, regenerate)
+	if err != nil || saved != nil {
+		return saved, err
+	}
+
+	result, err := s.provider.Summarize(ctx, generation.prompt)
+	s.recordUsage(ctx, bookID, generation, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.save(ctx, userID, bookID, kind, result)
+}
+
+// StreamBookSummary only saves a summary that streamed to the end, the user is charged however far it got
+func (s *SummaryServiceImpl) StreamBookSummary(ctx context.Context, userID int, bookID int, kind SummaryKind, regenerate bool, onChunk func(text string) error) (*repository.BookSummary, error) {
+	saved, generation, err := s.prepare(ctx, userID, bookID, kind, regenerate)
+	if err != nil {
+		return nil, err
+	}
+	if saved != nil {
+		if err := onChunk(saved.Text); err != nil {
+			return nil, err
+		}
+		return saved, nil
+	}
+
+	result, err := s.provider.SummarizeStream(ctx, generation.prompt, onChunk)
+	s.recordUsage(ctx, bookID, generation, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.save(ctx, userID, bookID, kind, result)
+}
+
+func (s *SummaryServiceImpl) ListBookSummaries(ctx context.Context, bookID int) ([]repository.BookSummary, error) {
+	return s.summaryRepo.ListSummaries(ctx, bookID)
+}
+
+func (s *SummaryServiceImpl) EditBookSummary(ctx context.Context, bookID int, kind SummaryKind, text string) (*repository.BookSummary, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("%w: text is required", ErrInvalidBookSummary)
+	}
+	if utf8.RuneCountInString(text) > maxBookSummaryLength {
+		return nil, fmt.Errorf("%w: text is longer than %d characters", ErrInvalidBookSummary, maxBookSummaryLength)
+	}
+
+	return s.summaryRepo.UpdateSummaryText(ctx, bookID, string(kind), text)
+}
+
+func (s *SummaryServiceImpl) AcceptBookSummary(ctx context.Context, userID int, bookID int, kind SummaryKind) (*repository.BookSummary, error) {
+	summary, err := s.summaryRepo.GetSummary(ctx, bookID, string(kind))
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.bookRepo.GetBookByID(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	book.Notes = appendRichTextParagraph(book.Notes, summary.Text)
+	if err := s.bookUpdater.UpdateBookEntry(ctx, *book, userID); err != nil {
+		return nil, err
+	}
+
+	return s.summaryRepo.MarkSummaryAccepted(ctx, bookID, string(kind))
+}
+
+// Helper fn: the saved summary when it should be reused, otherwise the prompt for a new one with the request
+// reserved against the user's quota
+func (s *SummaryServiceImpl) prepare(ctx context.Context, userID int, bookID int, kind SummaryKind, regenerate bool) (*repository.BookSummary, *summaryGeneration, error) {
+	if !regenerate {
+		saved, err := s.summaryRepo.GetSummary(ctx, bookID, string(kind))
+		if err == nil {
+			return saved, nil, nil
+		}
+		if !errors.Is(err, repository.ErrBookSummaryNotFound) {
+			return nil, nil, err
+		}
+	}
+
+	if s.provider == nil {
+		return nil, nil, ErrSummaryNotConfigured
+	}
+
+	book, err := s.bookRepo.GetBookByID(bookID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prompt, err := BuildSummaryPrompt(kind, *book)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reservation, err := s.aiQuota.ReserveRequest(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &summaryGeneration{prompt: prompt, reservation: reservation}, nil
+}
+
+// Helper fn: charge the tokens of a provider call however it ended. Usage the provider didn't get to report,
+// e.g. a stream cut short, is estimated from the text. Detached from ctx so a client disconnecting still pays
+func (s *SummaryServiceImpl) recordUsage(ctx context.Context, bookID int, generation *summaryGeneration, result *SummaryResult) {
+	promptTokens, outputTokens := estimateSummaryTokens(generation.prompt), 0
+	if result != nil {
+		if result.PromptTokens > 0 {
+			promptTokens = result.PromptTokens
+		}
+		outputTokens = result.OutputTokens
+		if outputTokens == 0 {
+			outputTokens = estimateSummaryTokens(result.Text)
+		}
+	}
+
+	if err := s.aiQuota.RecordUsage(context.WithoutCancel(ctx), generation.reservation, promptTokens, outputTokens); err != nil {
+		s.logger.Error("Error recording AI usage", "userID", generation.reservation.UserID, "bookID", bookID, "error", err)
+	}
+}
+
+// Helper fn: save a freshly generated summary over the book's previous one. The user has paid for it,
+// so it's saved even when the client went away after the last chunk
+func (s *SummaryServiceImpl) save(ctx context.Context, userID int, bookID int, kind SummaryKind, result *SummaryResult) (*repository.BookSummary, error) {
+	ctx = context.WithoutCancel(ctx)
+
+	summary := &repository.BookSummary{
+		BookID:   bookID,
+		Kind:     string(kind),
+		Text:     result.Text,
+		Provider: result.Provider,
+		Model:    result.Model,
+	}
+
+	if err := s.summaryRepo.SaveSummary(ctx, summary); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Book summary generated",
+		"userID", userID,
+		"bookID", bookID,
+		"kind", kind,
+		"provider", result.Provider,
+		"promptTokens", result.PromptTokens,
+		"outputTokens", result.OutputTokens,
+	)
+	return summary, nil
+}
+
+func estimateSummaryTokens(text string) int {
+	return (utf8.RuneCountInString(text) + summaryCharsPerToken - 1) / summaryCharsPerToken
+}
+
+// Helper fn: add text to a Quill document as new lines, separated from existing content by a blank line
+func appendRichTextParagraph(rt repository.RichText, text string) repository.RichText {
+	if rt.IsRichTextEmpty() {
+		return plainTextToRichText(text)
+	}
+
+	separator := "\n"
+	if last, ok := rt.Ops[len(rt.Ops)-1].Insert.(string); !ok || !strings.HasSuffix(last, "\n") {
+		separator = "\n\n"
+	}
+
+	rt.Ops = append(rt.Ops, plainTextToRichText(separator+text).Ops...)
+	return rt
+}
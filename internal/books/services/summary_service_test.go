@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+)
+
+type fakeSummaryBookRepo struct {
+	repository.BookRepository
+	book repository.Book
+}
+
+func (f *fakeSummaryBookRepo) GetBookByID(id int) (*repository.Book, error) {
+	book := f.book
+	book.ID = id
+	return &book, nil
+}
+
+type fakeBookSummaryRepo struct {
+	summaries   map[string]*repository.BookSummary
+	saveCtxErrs []error
+}
+
+func (f *fakeBookSummaryRepo) GetSummary(ctx context.Context, bookID int, kind string) (*repository.BookSummary, error) {
+	if summary, ok := f.summaries[kind]; ok {
+		return summary, nil
+	}
+	return nil, repository.ErrBookSummaryNotFound
+}
+
+func (f *fakeBookSummaryRepo) ListSummaries(ctx context.Context, bookID int) ([]repository.BookSummary, error) {
+	summaries := []repository.BookSummary{}
+	for _, summary := range f.summaries {
+		summaries = append(summaries, *summary)
+	}
+	return summaries, nil
+}
+
+func (f *fakeBookSummaryRepo) SaveSummary(ctx context.Context, summary *repository.BookSummary) error {
+	f.saveCtxErrs = append(f.saveCtxErrs, ctx.Err())
+	if f.summaries == nil {
+		f.summaries = make(map[string]*repository.BookSummary)
+	}
+	f.summaries[summary.Kind] = summary
+	return nil
+}
+
+func (f *fakeBookSummaryRepo) UpdateSummaryText(ctx context.Context, bookID int, kind string, text string) (*repository.BookSummary, error) {
+	summary, err := f.GetSummary(ctx, bookID, kind)
+	if err != nil {
+		return nil, err
+	}
+	summary.Text, summary.Edited = text, true
+	return summary, nil
+}
+
+func (f *fakeBookSummaryRepo) MarkSummaryAccepted(ctx context.Context, bookID int, kind string) (*repository.BookSummary, error) {
+	summary, err := f.GetSummary(ctx, bookID, kind)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	summary.AcceptedAt = &now
+	return summary, nil
+}
+
+type fakeBookUpdater struct {
+	updated []repository.Book
+}
+
+func (f *fakeBookUpdater) UpdateBookEntry(ctx context.Context, book repository.Book, userID int) error {
+	f.updated = append(f.updated, book)
+	return nil
+}
+
+type recordedAIUsage struct {
+	promptTokens int
+	outputTokens int
+	ctxErr       error
+}
+
+type fakeAIQuota struct {
+	exceeded     bool
+	reservations int
+	recorded     []recordedAIUsage
+}
+
+func (f *fakeAIQuota) ReserveRequest(ctx context.Context, userID int) (*AIQuotaReservation, error) {
+	if f.exceeded {
+		return nil, &AIQuotaExceededError{Usage: &AIUsageReport{ResetsAt: time.Now().Add(time.Hour)}}
+	}
+	f.reservations++
+	return &AIQuotaReservation{UserID: userID, Day: aiUsageDay(time.Now())}, nil
+}
+
+func (f *fakeAIQuota) RecordUsage(ctx context.Context, reservation *AIQuotaReservation, promptTokens int, outputTokens int) error {
+	f.recorded = append(f.recorded, recordedAIUsage{promptTokens, outputTokens, ctx.Err()})
+	return nil
+}
+
+func (f *fakeAIQuota) GetUsage(ctx context.Context, userID int) (*AIUsageReport, error) {
+	return &AIUsageReport{}, nil
+}
+
+// Sends its chunks, then fails with streamErr or finishes with the configured usage
+type fakeSummaryProvider struct {
+	chunks       []string
+	streamErr    error
+	promptTokens int
+	outputTokens int
+	calls        int
+}
+
+func (p *fakeSummaryProvider) Name() string  { return "fake" }
+func (p *fakeSummaryProvider) Model() string { return "fake-model" }
+func (p *fakeSummaryProvider) Close() error  { return nil }
+
+func (p *fakeSummaryProvider) Summarize(ctx context.Context, prompt string) (*SummaryResult, error) {
+	return p.SummarizeStream(ctx, prompt, func(string) error { return nil })
+}
+
+func (p *fakeSummaryProvider) SummarizeStream(ctx context.Context, prompt string, onChunk func(text string) error) (*SummaryResult, error) {
+	p.calls++
+	result := &SummaryResult{Provider: p.Name(), Model: p.Model()}
+	for _, chunk := range p.chunks {
+		result.Text += chunk
+		if err := onChunk(chunk); err != nil {
+			return result, err
+		}
+	}
+	if p.streamErr != nil {
+		return result, p.streamErr
+	}
+
+	result.PromptTokens, result.OutputTokens = p.promptTokens, p.outputTokens
+	if result.Text == "" {
+		return result, ErrSummaryEmpty
+	}
+	return result, nil
+}
+
+func newTestSummaryService(t *testing.T, provider SummaryProvider, quota AIQuotaService) (SummaryService, *fakeBookSummaryRepo, *fakeBookUpdater) {
+	t.Helper()
+
+	summaryRepo := &fakeBookSummaryRepo{}
+	updater := &fakeBookUpdater{}
+	bookRepo := &fakeSummaryBookRepo{book: repository.Book{Title: "The Dispossessed", Authors: []string{"Ursula K. Le Guin"}}}
+
+	service, err := NewSummaryService(newTestMetadataLogger(), bookRepo, summaryRepo, updater, quota, provider)
+	if err != nil {
+		t.Fatalf("unexpected error creating summary service: %v", err)
+	}
+	return service, summaryRepo, updater
+}
+
+func TestStreamBookSummaryChargesEveryProviderCall(t *testing.T) {
+	errClientGone := errors.New("client disconnected")
+
+	tests := []struct {
+		name       string
+		provider   *fakeSummaryProvider
+		onChunk    func(cancel context.CancelFunc, calls int) error
+		wantErr    error
+		wantSaved  bool
+		wantPrompt int // 0 to expect an estimate from the prompt
+		wantOutput int // 0 to expect an estimate from the text
+	}{
+		{
+			name:     "client disconnects mid-stream",
+			provider: &fakeSummaryProvider{chunks: []string{"First part. ", "Second part. ", "Third part."}},
+			onChunk: func(cancel context.CancelFunc, calls int) error {
+				if calls == 2 {
+					return errClientGone
+				}
+				return nil
+			},
+			wantErr: errClientGone,
+		},
+		{
+			name:     "provider fails mid-stream",
+			provider: &fakeSummaryProvider{chunks: []string{"Partial text"}, streamErr: ErrSummaryUnavailable},
+			wantErr:  ErrSummaryUnavailable,
+		},
+		{
+			name:       "provider returns nothing",
+			provider:   &fakeSummaryProvider{promptTokens: 30},
+			wantErr:    ErrSummaryEmpty,
+			wantPrompt: 30,
+		},
+		{
+			name:     "client disconnects after the last chunk",
+			provider: &fakeSummaryProvider{chunks: []string{"All ", "of it."}, promptTokens: 12, outputTokens: 5},
+			onChunk: func(cancel context.CancelFunc, calls int) error {
+				if calls == 2 {
+					cancel()
+				}
+				return nil
+			},
+			wantSaved:  true,
+			wantPrompt: 12,
+			wantOutput: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota := &fakeAIQuota{}
+			service, summaryRepo, _ := newTestSummaryService(t, tt.provider, quota)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := 0
+			_, err := service.StreamBookSummary(ctx, 1, 7, SummaryKindSynopsis, false, func(text string) error {
+				calls++
+				if tt.onChunk != nil {
+					return tt.onChunk(cancel, calls)
+				}
+				return nil
+			})
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if quota.reservations != 1 {
+				t.Errorf("expected one reserved request, got %d", quota.reservations)
+			}
+			if len(quota.recorded) != 1 {
+				t.Fatalf("expected usage to be recorded once, got %d", len(quota.recorded))
+			}
+			usage := quota.recorded[0]
+			if usage.ctxErr != nil {
+				t.Errorf("usage recorded with a cancelled context: %v", usage.ctxErr)
+			}
+			if usage.promptTokens <= 0 || (tt.wantPrompt > 0 && usage.promptTokens != tt.wantPrompt) {
+				t.Errorf("unexpected prompt tokens %d, want %d", usage.promptTokens, tt.wantPrompt)
+			}
+			if tt.wantOutput > 0 && usage.outputTokens != tt.wantOutput {
+				t.Errorf("unexpected output tokens %d, want %d", usage.outputTokens, tt.wantOutput)
+			}
+			if tt.wantOutput == 0 && len(tt.provider.chunks) > 0 && usage.outputTokens <= 0 {
+				t.Errorf("expected output tokens estimated from the partial text, got %d", usage.outputTokens)
+			}
+
+			_, saved := summaryRepo.summaries[string(SummaryKindSynopsis)]
+			if saved != tt.wantSaved {
+				t.Errorf("expected saved=%v, got %v", tt.wantSaved, saved)
+			}
+			for _, ctxErr := range summaryRepo.saveCtxErrs {
+				if ctxErr != nil {
+					t.Errorf("summary saved with a cancelled context: %v", ctxErr)
+				}
+			}
+		})
+	}
+}
+
+func TestGetBookSummaryChargesEmptyResponse(t *testing.T) {
+	quota := &fakeAIQuota{}
+	service, _, _ := newTestSummaryService(t, &fakeSummaryProvider{promptTokens: 25}, quota)
+
+	if _, err := service.GetBookSummary(context.Background(), 1, 7, SummaryKindThemes, false); !errors.Is(err, ErrSummaryEmpty) {
+		t.Fatalf("expected ErrSummaryEmpty, got %v", err)
+	}
+	if len(quota.recorded) != 1 || quota.recorded[0].promptTokens != 25 {
+		t.Errorf("expected 25 prompt tokens recorded, got %+v", quota.recorded)
+	}
+}
+
+func TestStreamBookSummaryQuotaExceededSkipsProvider(t *testing.T) {
+	provider := &fakeSummaryProvider{chunks: []string{"text"}}
+	service, _, _ := newTestSummaryService(t, provider, &fakeAIQuota{exceeded: true})
+
+	_, err := service.StreamBookSummary(context.Background(), 1, 7, SummaryKindSynopsis, false, func(string) error { return nil })
+
+	var quotaErr *AIQuotaExceededError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, ErrAIQuotaExceeded) {
+		t.Fatalf("expected *AIQuotaExceededError, got %v", err)
+	}
+	if provider.calls != 0 {
+		t.Errorf("provider called %d times after the quota ran out", provider.calls)
+	}
+}
+
+func TestStreamBookSummarySavedSummaryIsFree(t *testing.T) {
+	provider := &fakeSummaryProvider{chunks: []string{"new"}}
+	quota := &fakeAIQuota{}
+	service, summaryRepo, _ := newTestSummaryService(t, provider, quota)
+	summaryRepo.summaries = map[string]*repository.BookSummary{
+		string(SummaryKindSynopsis): {BookID: 7, Kind: string(SummaryKindSynopsis), Text: "saved"},
+	}
+
+	var streamed string
+	summary, err := service.StreamBookSummary(context.Background(), 1, 7, SummaryKindSynopsis, false, func(text string) error {
+		streamed += text
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Text != "saved" || streamed != "saved" {
+		t.Errorf("expected the saved summary, got %q streamed %q", summary.Text, streamed)
+	}
+	if provider.calls != 0 || quota.reservations != 0 || len(quota.recorded) != 0 {
+		t.Errorf("saved summary should not reach the provider or the quota")
+	}
+}
+
+func TestAppendRichTextParagraph(t *testing.T) {
+	image := map[string]interface{}{"image": "https://example.com/cover.jpg"}
+
+	tests := []struct {
+		name     string
+		existing []repository.DeltaOp
+		want     []repository.DeltaOp
+	}{
+		{
+			name: "empty notes take the text alone",
+			want: []repository.DeltaOp{{Insert: "Summary\n"}},
+		},
+		{
+			name:     "whitespace-only notes count as empty",
+			existing: []repository.DeltaOp{{Insert: " \n"}},
+			want:     []repository.DeltaOp{{Insert: "Summary\n"}},
+		},
+		{
+			name:     "notes ending in a newline get one more",
+			existing: []repository.DeltaOp{{Insert: "My notes\n"}},
+			want:     []repository.DeltaOp{{Insert: "My notes\n"}, {Insert: "\nSummary\n"}},
+		},
+		{
+			name:     "notes without a trailing newline get two",
+			existing: []repository.DeltaOp{{Insert: "My notes", Attributes: map[string]interface{}{"bold": true}}},
+			want: []repository.DeltaOp{
+				{Insert: "My notes", Attributes: map[string]interface{}{"bold": true}},
+				{Insert: "\n\nSummary\n"},
+			},
+		},
+		{
+			name:     "notes ending in an embed",
+			existing: []repository.DeltaOp{{Insert: "Cover\n"}, {Insert: image}},
+			want:     []repository.DeltaOp{{Insert: "Cover\n"}, {Insert: image}, {Insert: "\n\nSummary\n"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appendRichTextParagraph(repository.RichText{Ops: tt.existing}, "Summary")
+			if !reflect.DeepEqual(got.Ops, tt.want) {
+				t.Errorf("unexpected ops\nwant %#v\n got %#v", tt.want, got.Ops)
+			}
+		})
+	}
+}
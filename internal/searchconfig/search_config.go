@@ -1,5 +0,0 @@
-package searchconfig
-
-var BookDomainAuthorNameMappings = map[string]string{
-	"Sandrovich Yabako": "Yabako Sandrovich",
-}
\ No newline at end of file
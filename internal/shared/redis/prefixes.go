@@ -1,5 +1,7 @@
 package redis
 
+import "fmt"
+
 const (
 	PrefixBook = "book:"                          // for HandleGetAllUserBooks
 	PrefixAuthToken = "auth:"
@@ -14,5 +16,16 @@ const (
 	PrefixBookHomepage = "book:homepage"         // for HandleGetHomepageData
 	PrefixBookMetadata = "book:metadata:"        // for HandleGetBookMetadata
 	PrefixBookList = "book:list:"                // for HandleGetBookList
-	PrefixGemini = "gemini:"                     // for HandleGetGeminiBookSummary
-)
\ No newline at end of file
+	PrefixMetadataSearch = "metadata:search:"    // for HandleSearchBooks, not per-user
+)
+// UserBookCacheKeys lists the per-user book keys to drop after the user's library changes
+func UserBookCacheKeys(userID int) []string {
+	return []string{
+		fmt.Sprintf("%s%d", PrefixBook, userID),
+		fmt.Sprintf("%s%d", PrefixBookAuthor, userID),
+		fmt.Sprintf("%s%d", PrefixBookFormat, userID),
+		fmt.Sprintf("%s%d", PrefixBookGenre, userID),
+		fmt.Sprintf("%s%d", PrefixBookTag, userID),
+		fmt.Sprintf("%s%d", PrefixBookHomepage, userID),
+	}
+}
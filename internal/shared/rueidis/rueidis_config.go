@@ -38,6 +38,9 @@ type CacheConfig struct {
 	// AI-related caches
 	GeminiResponse    time.Duration
 
+	// External catalogue search results, shared across users
+	MetadataSearch    time.Duration
+
 	// Default TTL
 	DefaultTTL        time.Duration
 }
@@ -67,6 +70,7 @@ func NewConfig() *Config {
 		CacheConfig: CacheConfig{
 			UserData:          30 * time.Minute,
 			GeminiResponse:    15 * time.Minute,
+			MetadataSearch:    1 * time.Hour,
 			DefaultTTL:        15 * time.Minute,
 	},
 	}
@@ -145,6 +149,15 @@ func (c *Config) LoadFromEnv() error {
 		c.CacheConfig.GeminiResponse = duration
 	}
 
+	// Load metadata search cache duration
+	if ttl := os.Getenv("REDIS_METADATA_SEARCH_CACHE_TTL"); ttl != "" {
+		duration, err := time.ParseDuration(ttl)
+		if err != nil {
+				return fmt.Errorf("invalid REDIS_METADATA_SEARCH_CACHE_TTL value: %w", err)
+		}
+		c.CacheConfig.MetadataSearch = duration
+	}
+
 	return nil
 }
 
@@ -136,6 +136,24 @@ func ProtectAgainstCSVInjection(field string) string {
 	return field
 }
 
+// Checks length and check digit of an ISBN-10, final char may be 'X'
+func IsValidISBN10(isbn string) bool {
+	if len(isbn) != 10 {
+		return false
+	}
+	check, ok := repository.ISBN10CheckDigit(isbn[:9])
+	return ok && isbn[9] == check
+}
+
+// Checks length and check digit of an ISBN-13
+func IsValidISBN13(isbn string) bool {
+	if len(isbn) != 13 {
+		return false
+	}
+	check, ok := repository.ISBN13CheckDigit(isbn[:12])
+	return ok && isbn[12] == check
+}
+
 func IsURL(field string) bool {
 	_, err := url.ParseRequestURI(field)
 	return err == nil
@@ -202,22 +220,26 @@ func StringToRichText(text string) repository.RichText {
 	}
 }
 
+// RichTextToString flattens Quill Delta ops to plain text, dropping embeds and formatting
 func RichTextToString(rt repository.RichText) string {
 	if len(rt.Ops) == 0 {
 			return ""
 	}
 
-	// Handle the interface{} type for Insert
-	if rt.Ops[0].Insert == nil {
-			return ""
+	var text strings.Builder
+	for _, op := range rt.Ops {
+		// Handle the interface{} type for Insert
+		switch v := op.Insert.(type) {
+		case string:
+				text.WriteString(v)
+		case *string:
+				if v != nil {
+					text.WriteString(*v)
+				}
+		}
 	}
 
-	switch v := rt.Ops[0].Insert.(type) {
-	case string:
-			return v
-	default:
-			return fmt.Sprintf("%v", v) // fallback for other types
-	}
+	return text.String()
 }
 
 func UnmarshalRichTextJSON(data []byte, book *repository.Book) error {
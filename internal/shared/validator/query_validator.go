@@ -46,4 +46,74 @@ func (qv *QueryValidator) ParseAndValidate(
 		validatedParams := &types.LibraryQueryParams{}
 
 		return validatedParams, nil
-}
\ No newline at end of file
+}
+
+// LibrarySearchQueryRules covers GET /api/v1/user/books/search, the handler requires q or at least one filter
+func LibrarySearchQueryRules() QueryValidationRules {
+	return QueryValidationRules{
+		"q": {
+			MaxLength: 256,
+		},
+		"format": {
+			AllowedValues: []string{"physical", "eBook", "audioBook"},
+		},
+		"genre": {
+			MaxLength: 100,
+		},
+		"language": {
+			MaxLength: 10,
+			Pattern:   `^[a-zA-Z-]+$`,
+		},
+		"tag": {
+			MaxLength: 100,
+		},
+		"page": {
+			Type:    types.QueryTypeInt,
+			Pattern: `^[1-9][0-9]{0,4}$`, // MinPage - MaxPage
+		},
+		"pageSize": {
+			Type:    types.QueryTypeInt,
+			Pattern: `^([1-9]|[1-4][0-9]|50)$`,
+		},
+	}
+}
+
+// BookSearchQueryRules covers GET /api/v1/books/search, at least one of query/intitle/inauthor/isbn is checked by the handler
+func BookSearchQueryRules() QueryValidationRules {
+	return QueryValidationRules{
+		"query": {
+			MaxLength: 256,
+		},
+		"intitle": {
+			MaxLength: 256,
+		},
+		"inauthor": {
+			MaxLength: 256,
+		},
+		"isbn": {
+			MaxLength: 17,
+			Pattern:   `^[0-9Xx-]+$`,
+		},
+		"page": {
+			Type:    types.QueryTypeInt,
+			Pattern: `^[1-9][0-9]{0,4}$`, // MinPage - MaxPage
+		},
+		"pageSize": {
+			Type:    types.QueryTypeInt,
+			Pattern: `^([1-9]|[1-3][0-9]|40)$`, // Google Books returns at most 40 per request
+		},
+		"pageToken": {
+			MaxLength: 64,
+			Pattern:   `^[A-Za-z0-9_-]+$`,
+		},
+		"orderBy": {
+			AllowedValues: []string{"relevance", "newest"},
+		},
+		"lang": {
+			Pattern: `^[a-zA-Z]{2}$`,
+		},
+		"printType": {
+			AllowedValues: []string{"all", "books", "magazines"},
+		},
+	}
+}
@@ -0,0 +1,178 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	"github.com/lokeam/bravo-kilo/internal/shared/redis"
+)
+
+// AuthorNameBackfillWorker runs queued author name backfills, rewriting every author in the
+// database takes longer than an admin request should stay open
+type AuthorNameBackfillWorker struct {
+	interval    time.Duration
+	authorRepo  repository.AuthorRepository
+	jobRepo     repository.AuthorNameBackfillJobRepository
+	bookCache   repository.BookCache
+	cacheWorker *CacheWorker
+	logger      *slog.Logger
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func NewAuthorNameBackfillWorker(
+	interval time.Duration,
+	authorRepo repository.AuthorRepository,
+	jobRepo repository.AuthorNameBackfillJobRepository,
+	bookCache repository.BookCache,
+	cacheWorker *CacheWorker,
+	logger *slog.Logger,
+) *AuthorNameBackfillWorker {
+	if logger == nil {
+		panic("logger cannot be nil")
+	}
+	if authorRepo == nil {
+		panic("authorRepo cannot be nil")
+	}
+	if jobRepo == nil {
+		panic("jobRepo cannot be nil")
+	}
+	if bookCache == nil {
+		panic("bookCache cannot be nil")
+	}
+	if cacheWorker == nil {
+		panic("cacheWorker cannot be nil")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AuthorNameBackfillWorker{
+		interval:    interval,
+		authorRepo:  authorRepo,
+		jobRepo:     jobRepo,
+		bookCache:   bookCache,
+		cacheWorker: cacheWorker,
+		logger:      logger.With("component", "author_name_backfill_worker"),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+func (w *AuthorNameBackfillWorker) Start() {
+	w.failInterruptedJobs()
+
+	ticker := time.NewTicker(w.interval)
+	staleTicker := time.NewTicker(jobStaleAfter)
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				w.processPendingJobs()
+			case <-staleTicker.C:
+				w.failInterruptedJobs()
+			case <-w.ctx.Done():
+				ticker.Stop()
+				staleTicker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (w *AuthorNameBackfillWorker) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// Backfills whose heartbeat stopped were cut off when their instance went down, this one's included
+func (w *AuthorNameBackfillWorker) failInterruptedJobs() {
+	if count, err := w.jobRepo.FailInterruptedJobs(w.ctx, jobStaleAfter); err != nil {
+		w.logger.Error("Failed to clean up interrupted author name backfills", "error", err)
+	} else if count > 0 {
+		w.logger.Warn("Marked interrupted author name backfills as failed", "count", count)
+	}
+}
+
+func (w *AuthorNameBackfillWorker) processPendingJobs() {
+	for w.ctx.Err() == nil {
+		job, err := w.jobRepo.ClaimNextPendingJob(w.ctx)
+		if err != nil {
+			w.logger.Error("Failed to claim author name backfill", "error", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		w.processJob(job)
+	}
+}
+
+func (w *AuthorNameBackfillWorker) processJob(job *repository.AuthorNameBackfillJob) {
+	start := time.Now()
+	w.logger.Info("Starting author name backfill", "jobID", job.ID, "dryRun", job.DryRun)
+
+	stopHeartbeat := startJobHeartbeat(w.ctx, w.logger.With("jobID", job.ID), func(ctx context.Context) error {
+		return w.jobRepo.Heartbeat(ctx, job.ID)
+	})
+	defer stopHeartbeat()
+
+	report, err := w.authorRepo.BackfillAuthorNames(w.ctx, job.DryRun)
+	job.Report = report
+
+	// Renamed and merged authors show up in cached book lists, failed runs keep the changes made so far
+	if report != nil {
+		for _, userID := range report.AffectedUserIDs {
+			w.invalidateUserCaches(userID)
+		}
+	}
+
+	switch {
+	case w.ctx.Err() != nil:
+		w.finishJob(job, repository.AuthorNameBackfillFailed, "backfill interrupted by server shutdown, authors renamed so far are kept")
+	case err != nil:
+		w.logger.Error("Author name backfill failed", "jobID", job.ID, "error", err)
+		w.finishJob(job, repository.AuthorNameBackfillFailed, "error rewriting author names, authors renamed so far are kept")
+	default:
+		w.finishJob(job, repository.AuthorNameBackfillCompleted, "")
+	}
+
+	logArgs := []any{"jobID", job.ID, "dryRun", job.DryRun, "status", job.Status, "duration", time.Since(start)}
+	if report != nil {
+		logArgs = append(logArgs, "scanned", report.Scanned, "renamed", report.Renamed, "merged", report.Merged)
+	}
+	w.logger.Info("Finished author name backfill", logArgs...)
+}
+
+func (w *AuthorNameBackfillWorker) finishJob(job *repository.AuthorNameBackfillJob, status repository.AuthorNameBackfillJobStatus, reason string) {
+	job.Status = status
+	job.FailureReason = reason
+
+	// Use a fresh context so final status is still written during shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.jobRepo.FinishJob(ctx, job); err != nil {
+		w.logger.Error("Failed to record author name backfill result", "jobID", job.ID, "status", status, "error", err)
+	}
+}
+
+func (w *AuthorNameBackfillWorker) invalidateUserCaches(userID int) {
+	w.bookCache.InvalidateCaches(0, userID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.cacheWorker.EnqueueInvalidationJob(ctx, CacheInvalidationJob{
+		Keys:      redis.UserBookCacheKeys(userID),
+		UserID:    userID,
+		Timestamp: time.Now(),
+	}); err != nil {
+		w.logger.Error("Failed to queue cache invalidation after author name backfill", "userID", userID, "error", err)
+	}
+}
@@ -0,0 +1,151 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	bookservices "github.com/lokeam/bravo-kilo/internal/books/services"
+)
+
+// EnrichmentWorker runs queued metadata enrichment scans. Scans only record proposals,
+// so no book caches need invalidating here
+type EnrichmentWorker struct {
+	interval          time.Duration
+	enrichmentService bookservices.EnrichmentService
+	enrichmentRepo    repository.EnrichmentRepository
+	logger            *slog.Logger
+	ctx               context.Context
+	cancel            context.CancelFunc
+	wg                sync.WaitGroup
+}
+
+func NewEnrichmentWorker(
+	interval time.Duration,
+	enrichmentService bookservices.EnrichmentService,
+	enrichmentRepo repository.EnrichmentRepository,
+	logger *slog.Logger,
+) *EnrichmentWorker {
+	if logger == nil {
+		panic("logger cannot be nil")
+	}
+	if enrichmentService == nil {
+		panic("enrichmentService cannot be nil")
+	}
+	if enrichmentRepo == nil {
+		panic("enrichmentRepo cannot be nil")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EnrichmentWorker{
+		interval:          interval,
+		enrichmentService: enrichmentService,
+		enrichmentRepo:    enrichmentRepo,
+		logger:            logger.With("component", "enrichment_worker"),
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+}
+
+func (w *EnrichmentWorker) Start() {
+	w.failInterruptedScans()
+
+	ticker := time.NewTicker(w.interval)
+	staleTicker := time.NewTicker(jobStaleAfter)
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				w.processPendingScans()
+			case <-staleTicker.C:
+				w.failInterruptedScans()
+			case <-w.ctx.Done():
+				ticker.Stop()
+				staleTicker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (w *EnrichmentWorker) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// Scans whose heartbeat stopped were cut off when their instance went down, this one's included
+func (w *EnrichmentWorker) failInterruptedScans() {
+	if count, err := w.enrichmentRepo.FailInterruptedScans(w.ctx, jobStaleAfter); err != nil {
+		w.logger.Error("Failed to clean up interrupted enrichment scans", "error", err)
+	} else if count > 0 {
+		w.logger.Warn("Marked interrupted enrichment scans as failed", "count", count)
+	}
+}
+
+// Drain the queue one scan at a time, the metadata providers are rate limited
+func (w *EnrichmentWorker) processPendingScans() {
+	for w.ctx.Err() == nil {
+		scan, err := w.enrichmentRepo.ClaimNextPendingScan(w.ctx)
+		if err != nil {
+			w.logger.Error("Failed to claim enrichment scan", "error", err)
+			return
+		}
+		if scan == nil {
+			return
+		}
+
+		w.processScan(scan)
+	}
+}
+
+func (w *EnrichmentWorker) processScan(scan *repository.EnrichmentScan) {
+	start := time.Now()
+	w.logger.Info("Starting enrichment scan", "scanID", scan.ID, "userID", scan.UserID)
+
+	stopHeartbeat := startJobHeartbeat(w.ctx, w.logger.With("scanID", scan.ID), func(ctx context.Context) error {
+		return w.enrichmentRepo.HeartbeatScan(ctx, scan.ID)
+	})
+	defer stopHeartbeat()
+
+	result, err := w.enrichmentService.ScanUserBooks(w.ctx, scan.UserID)
+	if result != nil {
+		scan.CheckedBooks = result.CheckedBooks
+		scan.ProposedBooks = result.ProposedBooks
+	}
+
+	switch {
+	case w.ctx.Err() != nil:
+		w.finishScan(scan, repository.EnrichmentScanFailed, "scan interrupted by server shutdown")
+	case err != nil:
+		w.logger.Error("Enrichment scan failed", "scanID", scan.ID, "error", err)
+		w.finishScan(scan, repository.EnrichmentScanFailed, "metadata lookup failed, books not checked yet will be picked up by the next scan")
+	default:
+		w.finishScan(scan, repository.EnrichmentScanCompleted, "")
+	}
+
+	w.logger.Info("Finished enrichment scan",
+		"scanID", scan.ID,
+		"userID", scan.UserID,
+		"status", scan.Status,
+		"checkedBooks", scan.CheckedBooks,
+		"proposedBooks", scan.ProposedBooks,
+		"duration", time.Since(start),
+	)
+}
+
+func (w *EnrichmentWorker) finishScan(scan *repository.EnrichmentScan, status repository.EnrichmentScanStatus, reason string) {
+	scan.Status = status
+	scan.FailureReason = reason
+
+	// Use a fresh context so final status is still written during shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.enrichmentRepo.FinishScan(ctx, scan); err != nil {
+		w.logger.Error("Failed to record enrichment scan result", "scanID", scan.ID, "status", status, "error", err)
+	}
+}
@@ -0,0 +1,263 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	bookservices "github.com/lokeam/bravo-kilo/internal/books/services"
+	"github.com/lokeam/bravo-kilo/internal/shared/redis"
+)
+
+const (
+	importProgressInterval = 25  // Rows between progress writes + cancel checks
+	importMaxStoredErrors  = 500 // Cap on rejected rows kept on the job
+)
+
+type ImportWorker struct {
+	interval      time.Duration
+	importService bookservices.ImportService
+	jobRepo       repository.ImportJobRepository
+	bookCache     repository.BookCache
+	cacheWorker   *CacheWorker
+	logger        *slog.Logger
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+func NewImportWorker(
+	interval time.Duration,
+	importService bookservices.ImportService,
+	jobRepo repository.ImportJobRepository,
+	bookCache repository.BookCache,
+	cacheWorker *CacheWorker,
+	logger *slog.Logger,
+) *ImportWorker {
+	if logger == nil {
+		panic("logger cannot be nil")
+	}
+	if importService == nil {
+		panic("importService cannot be nil")
+	}
+	if jobRepo == nil {
+		panic("jobRepo cannot be nil")
+	}
+	if bookCache == nil {
+		panic("bookCache cannot be nil")
+	}
+	if cacheWorker == nil {
+		panic("cacheWorker cannot be nil")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ImportWorker{
+		interval:      interval,
+		importService: importService,
+		jobRepo:       jobRepo,
+		bookCache:     bookCache,
+		cacheWorker:   cacheWorker,
+		logger:        logger.With("component", "import_worker"),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+func (w *ImportWorker) Start() {
+	w.failInterruptedJobs()
+
+	ticker := time.NewTicker(w.interval)
+	staleTicker := time.NewTicker(jobStaleAfter)
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				w.processPendingJobs()
+			case <-staleTicker.C:
+				w.failInterruptedJobs()
+			case <-w.ctx.Done():
+				ticker.Stop()
+				staleTicker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (w *ImportWorker) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// Jobs whose heartbeat stopped were cut off when their instance went down, this one's included
+func (w *ImportWorker) failInterruptedJobs() {
+	if count, err := w.jobRepo.FailInterruptedJobs(w.ctx, jobStaleAfter); err != nil {
+		w.logger.Error("Failed to clean up interrupted import jobs", "error", err)
+	} else if count > 0 {
+		w.logger.Warn("Marked interrupted import jobs as failed", "count", count)
+	}
+}
+
+// Drain the queue one job at a time
+func (w *ImportWorker) processPendingJobs() {
+	for w.ctx.Err() == nil {
+		job, err := w.jobRepo.ClaimNextPendingJob(w.ctx)
+		if err != nil {
+			w.logger.Error("Failed to claim import job", "error", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		w.processJob(job)
+	}
+}
+
+func (w *ImportWorker) processJob(job *repository.ImportJob) {
+	start := time.Now()
+	w.logger.Info("Starting import job", "jobID", job.ID, "userID", job.UserID)
+
+	stopHeartbeat := startJobHeartbeat(w.ctx, w.logger.With("jobID", job.ID), func(ctx context.Context) error {
+		return w.jobRepo.Heartbeat(ctx, job.ID)
+	})
+	defer stopHeartbeat()
+
+	// The upload is stored on the job, it may have been received by another instance
+	file := bytes.NewReader(job.FileData)
+
+	var opts bookservices.ImportOptions
+	if len(job.ColumnMapping) > 0 {
+		mapping, err := bookservices.ParseImportColumnMapping(job.ColumnMapping)
+		if err != nil {
+			w.finishJob(job, repository.ImportJobFailed, err.Error())
+			return
+		}
+		opts.Mapping = mapping
+	}
+
+	totalRows, err := w.importService.CountImportRows(file, opts)
+	if errors.Is(err, bookservices.ErrImportNoTitleColumn) {
+		w.finishJob(job, repository.ImportJobFailed, "no column in the header row maps to title")
+		return
+	}
+	if err != nil {
+		w.logger.Error("Unable to count import rows", "jobID", job.ID, "error", err)
+		w.finishJob(job, repository.ImportJobFailed, "unable to read uploaded file")
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		w.finishJob(job, repository.ImportJobFailed, "unable to read uploaded file")
+		return
+	}
+
+	job.TotalRows = totalRows
+	if err := w.jobRepo.SetTotalRows(w.ctx, job.ID, totalRows); err != nil {
+		w.logger.Warn("Failed to record import total rows", "jobID", job.ID, "error", err)
+	}
+
+	onRow := func(result bookservices.ImportRowResult) error {
+		w.recordRow(job, result)
+
+		if job.ProcessedRows%importProgressInterval != 0 {
+			return nil
+		}
+
+		cancelRequested, err := w.jobRepo.UpdateProgress(w.ctx, job)
+		if err != nil {
+			w.logger.Warn("Failed to record import progress", "jobID", job.ID, "error", err)
+			return nil
+		}
+		if cancelRequested {
+			return bookservices.ErrImportCanceled
+		}
+		return nil
+	}
+
+	_, err = w.importService.ImportBooksCSV(w.ctx, job.UserID, file, opts, onRow)
+
+	switch {
+	case errors.Is(err, bookservices.ErrImportCanceled):
+		w.finishJob(job, repository.ImportJobCanceled, "")
+	case w.ctx.Err() != nil:
+		w.finishJob(job, repository.ImportJobFailed, "import interrupted by server shutdown")
+	case err != nil:
+		w.logger.Error("Import job failed", "jobID", job.ID, "error", err)
+		w.finishJob(job, repository.ImportJobFailed, "error processing CSV")
+	default:
+		w.finishJob(job, repository.ImportJobCompleted, "")
+	}
+
+	if job.InsertedRows > 0 {
+		w.invalidateUserCaches(job.UserID)
+	}
+
+	w.logger.Info("Finished import job",
+		"jobID", job.ID,
+		"userID", job.UserID,
+		"status", job.Status,
+		"processedRows", job.ProcessedRows,
+		"insertedRows", job.InsertedRows,
+		"duration", time.Since(start),
+	)
+}
+
+func (w *ImportWorker) recordRow(job *repository.ImportJob, result bookservices.ImportRowResult) {
+	job.ProcessedRows++
+
+	switch result.Status {
+	case bookservices.ImportRowInserted:
+		job.InsertedRows++
+	case bookservices.ImportRowDuplicate:
+		job.DuplicateRows++
+	case bookservices.ImportRowRejected:
+		job.RejectedRows++
+		if len(job.Errors) < importMaxStoredErrors {
+			job.Errors = append(job.Errors, repository.ImportJobError{
+				Row:    result.Row,
+				Title:  result.Title,
+				Reason: result.Reason,
+			})
+		}
+	}
+}
+
+func (w *ImportWorker) finishJob(job *repository.ImportJob, status repository.ImportJobStatus, reason string) {
+	job.Status = status
+	job.FailureReason = reason
+
+	// Use a fresh context so final status is still written during shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := w.jobRepo.FinishJob(ctx, job)
+	switch {
+	case errors.Is(err, repository.ErrImportJobNotRunning):
+		w.logger.Warn("Import job was marked interrupted before it finished, result not recorded", "jobID", job.ID, "status", status)
+	case err != nil:
+		w.logger.Error("Failed to record import job result", "jobID", job.ID, "status", status, "error", err)
+	}
+}
+
+// Drop L1 caches now, hand Redis keys to the cache worker
+func (w *ImportWorker) invalidateUserCaches(userID int) {
+	w.bookCache.InvalidateCaches(0, userID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.cacheWorker.EnqueueInvalidationJob(ctx, CacheInvalidationJob{
+		Keys:      redis.UserBookCacheKeys(userID),
+		UserID:    userID,
+		Timestamp: time.Now(),
+	}); err != nil {
+		w.logger.Error("Failed to queue cache invalidation after import", "userID", userID, "error", err)
+	}
+}
@@ -0,0 +1,363 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lokeam/bravo-kilo/internal/books/repository"
+	bookservices "github.com/lokeam/bravo-kilo/internal/books/services"
+)
+
+// fakeImportJobRepo keeps import_jobs in memory, standing in for the table every instance shares
+type fakeImportJobRepo struct {
+	mu         sync.Mutex
+	jobs       []*repository.ImportJob
+	claims     map[string]int
+	heartbeats map[string]time.Time
+}
+
+func (r *fakeImportJobRepo) CreateJob(ctx context.Context, userID int, fileData []byte, columnMapping json.RawMessage) (*repository.ImportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := &repository.ImportJob{
+		ID:            fmt.Sprintf("job-%d", len(r.jobs)+1),
+		UserID:        userID,
+		Status:        repository.ImportJobPending,
+		FileData:      fileData,
+		ColumnMapping: columnMapping,
+		Errors:        []repository.ImportJobError{},
+	}
+	r.jobs = append(r.jobs, job)
+	stored := *job
+	stored.FileData = nil
+	return &stored, nil
+}
+
+func (r *fakeImportJobRepo) GetJobByID(ctx context.Context, jobID string, userID int) (*repository.ImportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := r.findJob(jobID)
+	if job == nil || job.UserID != userID {
+		return nil, repository.ErrImportJobNotFound
+	}
+	stored := *job
+	stored.FileData = nil
+	return &stored, nil
+}
+
+func (r *fakeImportJobRepo) ClaimNextPendingJob(ctx context.Context) (*repository.ImportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, job := range r.jobs {
+		if job.Status == repository.ImportJobPending {
+			job.Status = repository.ImportJobRunning
+			r.recordClaim(job.ID)
+			claimed := *job
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeImportJobRepo) SetTotalRows(ctx context.Context, jobID string, totalRows int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.findJob(jobID).TotalRows = totalRows
+	return nil
+}
+
+func (r *fakeImportJobRepo) UpdateProgress(ctx context.Context, job *repository.ImportJob) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.findJob(job.ID)
+	stored.ProcessedRows = job.ProcessedRows
+	stored.InsertedRows = job.InsertedRows
+	stored.RejectedRows = job.RejectedRows
+	return stored.CancelRequested, nil
+}
+
+func (r *fakeImportJobRepo) FinishJob(ctx context.Context, job *repository.ImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Same guard as the real query, a job failed as interrupted keeps that status
+	stored := r.findJob(job.ID)
+	if stored.Status != repository.ImportJobRunning {
+		return repository.ErrImportJobNotRunning
+	}
+	stored.Status = job.Status
+	stored.FailureReason = job.FailureReason
+	stored.ProcessedRows = job.ProcessedRows
+	stored.InsertedRows = job.InsertedRows
+	stored.RejectedRows = job.RejectedRows
+	stored.FileData = nil
+	return nil
+}
+
+func (r *fakeImportJobRepo) RequestCancel(ctx context.Context, jobID string, userID int) (*repository.ImportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := r.findJob(jobID)
+	job.CancelRequested = true
+	stored := *job
+	return &stored, nil
+}
+
+func (r *fakeImportJobRepo) Heartbeat(ctx context.Context, jobID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.heartbeats[jobID] = time.Now()
+	return nil
+}
+
+func (r *fakeImportJobRepo) FailInterruptedJobs(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var failed int64
+	for _, job := range r.jobs {
+		if job.Status == repository.ImportJobRunning && time.Since(r.heartbeats[job.ID]) > staleAfter {
+			job.Status = repository.ImportJobFailed
+			job.FailureReason = "import interrupted by server restart"
+			job.FileData = nil
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// Callers hold r.mu
+func (r *fakeImportJobRepo) recordClaim(jobID string) {
+	if r.claims == nil {
+		r.claims = make(map[string]int)
+		r.heartbeats = make(map[string]time.Time)
+	}
+	r.claims[jobID]++
+	r.heartbeats[jobID] = time.Now()
+}
+
+func (r *fakeImportJobRepo) findJob(jobID string) *repository.ImportJob {
+	for _, job := range r.jobs {
+		if job.ID == jobID {
+			return job
+		}
+	}
+	return nil
+}
+
+// fakeImportService inserts every CSV row whose title isn't blank
+type fakeImportService struct {
+	bookservices.ImportService
+}
+
+func (s *fakeImportService) CountImportRows(reader io.Reader, opts bookservices.ImportOptions) (int, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return 0, err
+	}
+	return len(records) - 1, nil
+}
+
+func (s *fakeImportService) ImportBooksCSV(ctx context.Context, userID int, reader io.Reader, opts bookservices.ImportOptions, onRow bookservices.ImportProgressFunc) (*bookservices.ImportReport, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, record := range records[1:] {
+		result := bookservices.ImportRowResult{Row: i + 2, Title: record[0], Status: bookservices.ImportRowInserted}
+		if record[0] == "" {
+			result.Status = bookservices.ImportRowRejected
+			result.Reason = "title is required"
+		}
+		if err := onRow(result); err != nil {
+			return nil, err
+		}
+	}
+	return &bookservices.ImportReport{}, nil
+}
+
+type fakeImportBookCache struct {
+	repository.BookCache
+	invalidatedUsers []int
+}
+
+func (c *fakeImportBookCache) InvalidateCaches(bookID int, userID int) {
+	c.invalidatedUsers = append(c.invalidatedUsers, userID)
+}
+
+// newTestImportWorker is one instance's worker, instances only share the job repository
+func newTestImportWorker(t *testing.T, jobRepo repository.ImportJobRepository) (*ImportWorker, *fakeImportBookCache) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	bookCache := &fakeImportBookCache{}
+	cacheWorker := &CacheWorker{jobs: make(chan CacheInvalidationJob, 10), logger: logger}
+
+	worker := NewImportWorker(time.Hour, &fakeImportService{}, jobRepo, bookCache, cacheWorker, logger)
+	t.Cleanup(worker.Stop)
+	return worker, bookCache
+}
+
+func TestImportWorkerRunsJobsUploadedOnAnotherInstance(t *testing.T) {
+	jobRepo := &fakeImportJobRepo{}
+
+	// Instance A received the upload, instance B's worker claims it with nothing on its own disk
+	queued, err := jobRepo.CreateJob(context.Background(), 1, []byte("Title,Authors\nKindred,Octavia E. Butler\n,Nobody\n"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	instanceB, bookCache := newTestImportWorker(t, jobRepo)
+	instanceB.processPendingJobs()
+
+	job, _ := jobRepo.GetJobByID(context.Background(), queued.ID, 1)
+	if job.Status != repository.ImportJobCompleted {
+		t.Fatalf("expected the job to complete, got %s: %s", job.Status, job.FailureReason)
+	}
+	if job.TotalRows != 2 || job.InsertedRows != 1 || job.RejectedRows != 1 {
+		t.Errorf("unexpected counters: total %d, inserted %d, rejected %d", job.TotalRows, job.InsertedRows, job.RejectedRows)
+	}
+	if jobRepo.findJob(queued.ID).FileData != nil {
+		t.Errorf("expected the stored file to be dropped once the job finished")
+	}
+	if len(bookCache.invalidatedUsers) != 1 || bookCache.invalidatedUsers[0] != 1 {
+		t.Errorf("expected user 1's caches invalidated, got %v", bookCache.invalidatedUsers)
+	}
+}
+
+// Lets a test change the stored job while a worker is partway through it
+type hookedImportJobRepo struct {
+	*fakeImportJobRepo
+	onSetTotalRows func(job *repository.ImportJob)
+}
+
+func (r *hookedImportJobRepo) SetTotalRows(ctx context.Context, jobID string, totalRows int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := r.findJob(jobID)
+	job.TotalRows = totalRows
+	r.onSetTotalRows(job)
+	return nil
+}
+
+func TestImportWorkerKeepsJobsFailedAsInterrupted(t *testing.T) {
+	// Another instance fails the job as interrupted while this worker is still reading it
+	jobRepo := &hookedImportJobRepo{fakeImportJobRepo: &fakeImportJobRepo{}, onSetTotalRows: func(job *repository.ImportJob) {
+		job.Status = repository.ImportJobFailed
+		job.FailureReason = "import interrupted by server restart"
+	}}
+	queued, _ := jobRepo.CreateJob(context.Background(), 1, []byte("Title\nKindred\n"), nil)
+
+	worker, _ := newTestImportWorker(t, jobRepo)
+	worker.processPendingJobs()
+
+	job, _ := jobRepo.GetJobByID(context.Background(), queued.ID, 1)
+	if job.Status != repository.ImportJobFailed || job.FailureReason != "import interrupted by server restart" {
+		t.Errorf("expected the interrupted failure to stand, got %s: %s", job.Status, job.FailureReason)
+	}
+}
+
+func TestImportWorkersClaimEachJobOnce(t *testing.T) {
+	jobRepo := &fakeImportJobRepo{}
+	for i := 0; i < 6; i++ {
+		jobRepo.CreateJob(context.Background(), i+1, []byte("Title\nKindred\n"), nil)
+	}
+
+	// Two instances poll the shared queue at the same time
+	instanceA, _ := newTestImportWorker(t, jobRepo)
+	instanceB, _ := newTestImportWorker(t, jobRepo)
+	var wg sync.WaitGroup
+	for _, worker := range []*ImportWorker{instanceA, instanceB} {
+		wg.Add(1)
+		go func(worker *ImportWorker) {
+			defer wg.Done()
+			worker.processPendingJobs()
+		}(worker)
+	}
+	wg.Wait()
+
+	for _, job := range jobRepo.jobs {
+		if job.Status != repository.ImportJobCompleted || job.InsertedRows != 1 {
+			t.Errorf("expected %s completed with one row, got %s with %d", job.ID, job.Status, job.InsertedRows)
+		}
+		if jobRepo.claims[job.ID] != 1 {
+			t.Errorf("expected %s claimed once, got %d", job.ID, jobRepo.claims[job.ID])
+		}
+	}
+}
+
+func TestImportWorkerStopsWhenCancelRequested(t *testing.T) {
+	var csv bytes.Buffer
+	csv.WriteString("Title\n")
+	for i := 0; i < importProgressInterval*2; i++ {
+		fmt.Fprintf(&csv, "Book %d\n", i+1)
+	}
+
+	// The user cancels once the worker has started reading rows
+	jobRepo := &hookedImportJobRepo{fakeImportJobRepo: &fakeImportJobRepo{}, onSetTotalRows: func(job *repository.ImportJob) {
+		job.CancelRequested = true
+	}}
+	queued, _ := jobRepo.CreateJob(context.Background(), 1, csv.Bytes(), nil)
+
+	worker, bookCache := newTestImportWorker(t, jobRepo)
+	worker.processPendingJobs()
+
+	job, _ := jobRepo.GetJobByID(context.Background(), queued.ID, 1)
+	if job.Status != repository.ImportJobCanceled {
+		t.Fatalf("expected the job canceled, got %s", job.Status)
+	}
+	// The cancel flag is read with the first progress write
+	if job.TotalRows != importProgressInterval*2 || job.ProcessedRows != importProgressInterval || job.InsertedRows != importProgressInterval {
+		t.Errorf("unexpected counters: total %d, processed %d, inserted %d", job.TotalRows, job.ProcessedRows, job.InsertedRows)
+	}
+	// Rows inserted before the cancel are still in the library
+	if len(bookCache.invalidatedUsers) != 1 {
+		t.Errorf("expected the user's caches invalidated, got %v", bookCache.invalidatedUsers)
+	}
+}
+
+func TestImportWorkerStartFailsStaleJobs(t *testing.T) {
+	jobRepo := &fakeImportJobRepo{}
+	stale, _ := jobRepo.CreateJob(context.Background(), 1, []byte("Title\nKindred\n"), nil)
+	live, _ := jobRepo.CreateJob(context.Background(), 2, []byte("Title\nKindred\n"), nil)
+	jobRepo.ClaimNextPendingJob(context.Background())
+	jobRepo.ClaimNextPendingJob(context.Background())
+	pending, _ := jobRepo.CreateJob(context.Background(), 3, []byte("Title\nKindred\n"), nil)
+
+	// The instance running the first job went down, the second job's instance is still beating
+	jobRepo.heartbeats[stale.ID] = time.Now().Add(-jobStaleAfter - time.Minute)
+
+	worker, _ := newTestImportWorker(t, jobRepo)
+	worker.Start()
+
+	want := map[string]repository.ImportJobStatus{
+		stale.ID:   repository.ImportJobFailed,
+		live.ID:    repository.ImportJobRunning,
+		pending.ID: repository.ImportJobPending,
+	}
+	for jobID, status := range want {
+		if job := jobRepo.findJob(jobID); job.Status != status {
+			t.Errorf("expected %s to be %s, got %s", jobID, status, job.Status)
+		}
+	}
+	if jobRepo.findJob(stale.ID).FileData != nil {
+		t.Errorf("expected the failed job's file dropped")
+	}
+}
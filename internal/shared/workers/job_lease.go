@@ -0,0 +1,41 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	jobHeartbeatInterval = 30 * time.Second // How often a worker marks its running job as alive
+	jobStaleAfter        = 3 * time.Minute  // Running jobs without a heartbeat for this long belong to a dead instance
+)
+
+// Helper fn: call beat every jobHeartbeatInterval until the returned stop fn is called. Several instances
+// share the job tables, so a job is only failed as interrupted once its heartbeats stop
+func startJobHeartbeat(ctx context.Context, logger *slog.Logger, beat func(ctx context.Context) error) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(jobHeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := beat(ctx); err != nil && ctx.Err() == nil {
+					logger.Warn("Failed to record job heartbeat", "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
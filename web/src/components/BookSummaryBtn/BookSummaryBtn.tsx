@@ -1,11 +1,10 @@
 import { useEffect, useState } from 'react';
 import useDebounce from '../../hooks/useDebounceLD';
-import useGeminiPrompt from '../../hooks/useGeminiPrompt';
+import useBookSummary from '../../hooks/useBookSummary';
 import Loading from '../Loading/Loading';
 
 interface BookSummaryBtnProps {
-  title: string;
-  authors: string[];
+  bookID: string;
   setAiSummaryPreview: (summary: string) => void;
   setIsManualTrigger: (isManualTrigger: boolean) => void;
   openPreviewModal: () => void;
@@ -15,8 +14,7 @@ interface BookSummaryBtnProps {
 
 const BookSummaryBtn = (
   {
-    title,
-    authors,
+    bookID,
     setAiSummaryPreview,
     setIsManualTrigger,
     openPreviewModal,
@@ -24,20 +22,7 @@ const BookSummaryBtn = (
     isManualTrigger
   }: BookSummaryBtnProps) => {
   const [error, setError] = useState<string | null>(null);
-  const prompt = `
-  Ignore all prior instructions.
-
-  Act as an expert on summarization, outlining and structuring. Your style of writing should be informative and logical.
-
-  Provide me with a detailed summary of the book titled "${title}" by ${authors.join(", ")}.
-  This summary should use clear and concise language in order to make it easy to understand.
-  Generate a detailed summary of the book titled "${title}" by ${authors.join(", ")}.
-
-  Do not introduce yourself, do not remind me what I asked you for. Do not apologize. Do not self-reference.
-
-  Generate the output in markdown format. Make sure your response is no longer than four paragraphs `;
-
-  const { data: promptResponse, isLoading, isError, refetch } = useGeminiPrompt(prompt);
+  const { data: promptResponse, isLoading, isError, refetch } = useBookSummary(bookID, 'synopsis');
 
   useEffect(() => {
     if (
@@ -46,7 +31,7 @@ const BookSummaryBtn = (
       !isPreviewModalOpen &&
       isManualTrigger
     ) {
-      const formattedResponse = promptResponse.parts[0].replace(/['‘’"“”]/g, '');
+      const formattedResponse = promptResponse.text.replace(/['‘’"“”]/g, '');
       //console.log('checking formatted response: ', formattedResponse);
 
       setAiSummaryPreview(formattedResponse);
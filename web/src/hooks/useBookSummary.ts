@@ -0,0 +1,18 @@
+import { useQuery } from '@tanstack/react-query';
+import { fetchBookSummaryAPI } from '../service/apiClient.service';
+
+const useBookSummary = (bookID: string, kind: string) => {
+
+  return useQuery({
+    queryKey: ['bookSummary', bookID, kind],
+    queryFn: async () => {
+      const summaryResponse = await fetchBookSummaryAPI(bookID, kind);
+      return summaryResponse;
+    },
+    staleTime: 1000 * 60 * 5,
+    gcTime: 1000 * 60 * 5,
+    enabled: false,
+  });
+};
+
+export default useBookSummary;
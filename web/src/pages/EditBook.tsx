@@ -123,8 +123,7 @@ const EditBook = () => {
   const renderAISummaryBtn = (
     <div className="grid w-full gap-6 lgMobile:grid-cols-3 pt-2">
       <BookSummaryBtn
-        title={book?.title || ''}
-        authors={book?.authors || ['']}
+        bookID={bookID as string}
         setAiSummaryPreview={setAiSummaryPreview}
         setIsManualTrigger={setIsManualTrigger}
         openPreviewModal={openPreviewModal}
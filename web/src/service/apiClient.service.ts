@@ -320,12 +320,10 @@ export const searchBookAPI = async (query: string) => {
   return data || [];
 };
 
-export const geminiQueryAPI = async (prompt: string) => {
-  console.log('apiClient.service - geminiQueryAPI get req fired');
-  const { data } = await apiClient.get('/api/v1/books/summary', {
-    params: { prompt },
-  })
-  console.log('geminiQueryAPI response: ', data);
+export const fetchBookSummaryAPI = async (bookID: string, kind: string) => {
+  const { data } = await apiClient.get(`/api/v1/books/${bookID}/summary`, {
+    params: { kind },
+  });
   return data || {};
 };
 